@@ -1,37 +1,172 @@
 package emptyexporter
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+)
 
 type EncodingType int
 
 const (
 	OTLPCSV EncodingType = iota
+	OTLPParquet
+	OTLPArrowIPC
+	PrometheusText
+	OTLPProtobuf
+	OTLPJSON
+	Logfmt
+	Syslog
+	ChromeTrace
+	FoldedStacks
 )
 
 func (e EncodingType) String() string {
-	return [...]string{"otlp_csv"}[e]
+	return [...]string{"otlp_csv", "otlp_parquet", "otlp_arrow_ipc", "prometheus_text", "otlp_proto", "otlp_json", "logfmt", "syslog", "chrome_trace", "folded_stacks"}[e]
 }
 
 func ParseEncodingType(s string) (EncodingType, error) {
 	switch s {
 	case "otlp_csv":
 		return OTLPCSV, nil
+	case "otlp_parquet":
+		return OTLPParquet, nil
+	case "otlp_arrow_ipc":
+		return OTLPArrowIPC, nil
+	case "prometheus_text":
+		return PrometheusText, nil
+	case "otlp_proto":
+		return OTLPProtobuf, nil
+	case "otlp_json":
+		return OTLPJSON, nil
+	case "logfmt":
+		return Logfmt, nil
+	case "syslog":
+		return Syslog, nil
+	case "chrome_trace":
+		return ChromeTrace, nil
+	case "folded_stacks":
+		return FoldedStacks, nil
 	default:
 		return 0, fmt.Errorf("invalid encoding type: %s", s)
 	}
 }
 
+// splitEncoding splits a combined encoding such as otlp_csv+zstd into the
+// encoding and its compression, empty when the encoding is not compressed.
+func splitEncoding(s string) (string, string) {
+	encoding, compression, _ := strings.Cut(s, "+")
+	return encoding, compression
+}
+
+// concatenable reports whether payloads of an encoding remain readable once
+// appended to the same file. Columnar formats, documents with a single root
+// and compressed payloads do not.
+func concatenable(encoding EncodingType, compression string) bool {
+	if compression != "" {
+		return false
+	}
+	switch encoding {
+	case OTLPParquet, OTLPArrowIPC, PrometheusText, ChromeTrace:
+		return false
+	default:
+		return true
+	}
+}
+
 type Config struct {
-	ShouldLog bool   `mapstructure:"should_log"`
-	Encoding  string `mapstructure:"encoding"`
-	encoding  EncodingType
+	// ShouldLog makes the screen sender log the payloads. Other senders
+	// ignore it.
+	ShouldLog bool `mapstructure:"should_log"`
+
+	// Encoding is the name of the encoding, optionally followed by a
+	// compression, e.g. otlp_csv or otlp_csv+zstd.
+	Encoding string `mapstructure:"encoding"`
+	encoding EncodingType
+
+	// Sender is the name of the sender the payloads are sent with, the
+	// screen sender by default. Senders are registered with WithSender.
+	Sender string `mapstructure:"sender"`
+
+	// File holds the options of the file sender.
+	File FileSenderConfig `mapstructure:"file"`
+
+	// Path is the template of the path every payload is written to, e.g.
+	// warehouse/{resource.service.name}/year_month_date={yyyyMMdd}/part-{uuid}.{ext}.
+	// Batches are split by the values the template references and every
+	// part is marshaled on its own, see marshaler.PathTemplate. Senders get
+	// the path of a payload with PathFromContext.
+	Path string `mapstructure:"path"`
+
+	// Split bounds the size of every payload sent, a batch over the limits
+	// being sent as several payloads.
+	Split marshaler.SplitConfig `mapstructure:"split"`
+
+	// CSV holds the options of the otlp_csv encoding. Its column mapping is
+	// rejected with otlp_parquet and otlp_arrow_ipc, whose schemas are fixed.
+	CSV marshaler.CSVConfig `mapstructure:"csv"`
+
+	// Parquet holds the options of the otlp_parquet encoding.
+	Parquet marshaler.ParquetConfig `mapstructure:"parquet"`
+
+	// ArrowIPC holds the options of the otlp_arrow_ipc encoding.
+	ArrowIPC marshaler.ArrowIPCConfig `mapstructure:"arrow_ipc"`
+
+	// Prometheus holds the options of the prometheus_text encoding, which
+	// only applies to metrics.
+	Prometheus marshaler.PrometheusConfig `mapstructure:"prometheus"`
+
+	// Syslog holds the options of the syslog encoding, which only applies
+	// to logs.
+	Syslog marshaler.SyslogConfig `mapstructure:"syslog"`
 }
 
 func (c *Config) Validate() error {
-	encoding, err := ParseEncodingType(c.Encoding)
+	base, compression := splitEncoding(c.Encoding)
+	encoding, err := ParseEncodingType(base)
 	if err != nil {
 		return err
 	}
+	if err := marshaler.ValidateCompression(compression); err != nil {
+		return err
+	}
+	if err := c.Split.Validate(); err != nil {
+		return fmt.Errorf("invalid split options: %w", err)
+	}
+	if err := c.CSV.Validate(); err != nil {
+		return fmt.Errorf("invalid csv options: %w", err)
+	}
+	if mapping := c.CSV.Mapping; (encoding == OTLPParquet || encoding == OTLPArrowIPC) &&
+		len(mapping.Logs)+len(mapping.Metrics)+len(mapping.Traces) > 0 {
+		return fmt.Errorf("csv mapping is not supported by encoding %s, whose schema is fixed", base)
+	}
+	if err := c.Parquet.Validate(); err != nil {
+		return fmt.Errorf("invalid parquet options: %w", err)
+	}
+	if err := c.ArrowIPC.Validate(); err != nil {
+		return fmt.Errorf("invalid arrow ipc options: %w", err)
+	}
+	if err := c.Prometheus.Validate(); err != nil {
+		return fmt.Errorf("invalid prometheus options: %w", err)
+	}
+	if err := c.Syslog.Validate(); err != nil {
+		return fmt.Errorf("invalid syslog options: %w", err)
+	}
+	if err := c.File.Validate(); err != nil {
+		return fmt.Errorf("invalid file sender options: %w", err)
+	}
+	if c.Sender == FileSender && c.File.Directory == "" {
+		return fmt.Errorf("file sender requires a directory")
+	}
+	if c.Sender == FileSender && (c.File.MaxBytes > 0 || c.File.RotateInterval > 0) && !concatenable(encoding, compression) {
+		return fmt.Errorf("file rotation does not support encoding %s, whose payloads cannot be concatenated", c.Encoding)
+	}
+	if c.Path != "" {
+		if _, err := marshaler.ParsePathTemplate(c.Path); err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+	}
 	c.encoding = encoding
 	return nil
 }
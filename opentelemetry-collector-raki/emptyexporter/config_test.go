@@ -0,0 +1,49 @@
+package emptyexporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+)
+
+func TestConfigFileRotation(t *testing.T) {
+	for _, tt := range []struct {
+		encoding string
+		file     FileSenderConfig
+		valid    bool
+	}{
+		{encoding: "otlp_csv", file: FileSenderConfig{MaxBytes: 1 << 20}, valid: true},
+		{encoding: "syslog", file: FileSenderConfig{RotateInterval: time.Minute}, valid: true},
+		{encoding: "otlp_parquet", file: FileSenderConfig{}, valid: true},
+		{encoding: "otlp_parquet", file: FileSenderConfig{MaxBytes: 1 << 20}},
+		{encoding: "otlp_arrow_ipc", file: FileSenderConfig{RotateInterval: time.Minute}},
+		{encoding: "prometheus_text", file: FileSenderConfig{MaxBytes: 1 << 20}},
+		{encoding: "chrome_trace", file: FileSenderConfig{MaxBytes: 1 << 20}},
+		{encoding: "otlp_csv+gzip", file: FileSenderConfig{}, valid: true},
+		{encoding: "otlp_csv+gzip", file: FileSenderConfig{RotateInterval: time.Minute}},
+	} {
+		tt.file.Directory = t.TempDir()
+		cfg := &Config{Encoding: tt.encoding, Sender: FileSender, File: tt.file}
+		if err := cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate() of %s with %+v: expected valid %t, got %v", tt.encoding, tt.file, tt.valid, err)
+		}
+	}
+}
+
+func TestConfigColumnMapping(t *testing.T) {
+	mapping := marshaler.ColumnMappings{Logs: []marshaler.ColumnMapping{{Name: "severity", Source: "field:severity"}}}
+	for encoding, valid := range map[string]bool{
+		"otlp_csv":            true,
+		"otlp_csv+zstd":       true,
+		"otlp_parquet":        false,
+		"otlp_parquet+gzip":   false,
+		"otlp_arrow_ipc":      false,
+		"otlp_arrow_ipc+zstd": false,
+	} {
+		cfg := &Config{Encoding: encoding, CSV: marshaler.CSVConfig{Mapping: mapping}}
+		if err := cfg.Validate(); (err == nil) != valid {
+			t.Errorf("Validate() of %s with a mapping: expected valid %t, got %v", encoding, valid, err)
+		}
+	}
+}
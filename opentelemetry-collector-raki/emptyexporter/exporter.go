@@ -2,6 +2,8 @@ package emptyexporter
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
 	"go.opentelemetry.io/collector/component"
@@ -11,8 +13,6 @@ import (
 	"go.uber.org/zap"
 )
 
-type senderFunc func(ctx context.Context, e *emptyexporter, content string, contentType string) error
-
 type emptyexporter struct {
 	config Config
 	logger *zap.Logger
@@ -21,48 +21,137 @@ type emptyexporter struct {
 	metricsMarshaler marshaler.Metrics
 	tracesMarshaler  marshaler.Traces
 
-	sender senderFunc
+	sender Sender
+
+	// path is the parsed path template, nil when the config has none.
+	path *marshaler.PathTemplate
 }
 
-func newEmptyexporter(logger *zap.Logger, config component.Config) (*emptyexporter, error) {
-	return &emptyexporter{
+func newEmptyexporter(logger *zap.Logger, config component.Config, sender Sender) (*emptyexporter, error) {
+	s := &emptyexporter{
 		config: *config.(*Config),
 		logger: logger,
-		sender: sendToScreen,
-	}, nil
+		sender: sender,
+	}
+	if s.config.Path != "" {
+		path, err := marshaler.ParsePathTemplate(s.config.Path)
+		if err != nil {
+			return nil, err
+		}
+		s.path = path
+	}
+	return s, nil
+}
+
+func (s *emptyexporter) start(ctx context.Context, host component.Host) error {
+	return s.sender.Start(ctx, host)
 }
 
-func (s *emptyexporter) pushLogs(_ context.Context, ld plog.Logs) error {
-	bytes, err := s.logsMarshaler.Marshal(ld)
-	if err != nil {
-		return err
+func (s *emptyexporter) shutdown(ctx context.Context) error {
+	return s.sender.Shutdown(ctx)
+}
+
+func (s *emptyexporter) pushLogs(ctx context.Context, ld plog.Logs) error {
+	partitions := []marshaler.PathPartition[plog.Logs]{{Batch: ld}}
+	if s.path != nil {
+		partitions = s.path.PartitionLogs(ld)
 	}
-	if s.config.ShouldLog {
-		s.sender(context.Background(), s, string(bytes), s.logsMarshaler.ContentType())
+	var marshalTo func(io.Writer, plog.Logs) error
+	if m, ok := s.logsMarshaler.(marshaler.StreamingLogs); ok {
+		marshalTo = m.MarshalTo
 	}
-	return nil
+	return pushPartitions(ctx, s, s.payloadInfo(SignalLogs, s.logsMarshaler), partitions, func(ld plog.Logs) ([][]byte, error) {
+		return marshaler.MarshalLogsChunks(s.logsMarshaler, ld, s.config.Split)
+	}, marshalTo)
 }
 
 func (s *emptyexporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
-	bytes, err := s.metricsMarshaler.Marshal(md)
-	if err != nil {
-		return err
+	partitions := []marshaler.PathPartition[pmetric.Metrics]{{Batch: md}}
+	if s.path != nil {
+		partitions = s.path.PartitionMetrics(md)
 	}
-	if s.config.ShouldLog {
-		s.sender(ctx, s, string(bytes), s.metricsMarshaler.ContentType())
+	var marshalTo func(io.Writer, pmetric.Metrics) error
+	if m, ok := s.metricsMarshaler.(marshaler.StreamingMetrics); ok {
+		marshalTo = m.MarshalTo
 	}
-	return nil
+	return pushPartitions(ctx, s, s.payloadInfo(SignalMetrics, s.metricsMarshaler), partitions, func(md pmetric.Metrics) ([][]byte, error) {
+		return marshaler.MarshalMetricsChunks(s.metricsMarshaler, md, s.config.Split)
+	}, marshalTo)
 }
 
-func (s *emptyexporter) pushTraces(_ context.Context, td ptrace.Traces) error {
-	bytes, err := s.tracesMarshaler.Marshal(td)
-	if err != nil {
-		return err
+func (s *emptyexporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
+	partitions := []marshaler.PathPartition[ptrace.Traces]{{Batch: td}}
+	if s.path != nil {
+		partitions = s.path.PartitionTraces(td)
+	}
+	var marshalTo func(io.Writer, ptrace.Traces) error
+	if m, ok := s.tracesMarshaler.(marshaler.StreamingTraces); ok {
+		marshalTo = m.MarshalTo
 	}
-	if s.config.ShouldLog {
-		s.sender(context.Background(), s, string(bytes), s.tracesMarshaler.ContentType())
+	return pushPartitions(ctx, s, s.payloadInfo(SignalTraces, s.tracesMarshaler), partitions, func(td ptrace.Traces) ([][]byte, error) {
+		return marshaler.MarshalTracesChunks(s.tracesMarshaler, td, s.config.Split)
+	}, marshalTo)
+}
+
+// payloadInfo describes the payloads of a signal marshaled by m.
+func (s *emptyexporter) payloadInfo(signal string, m interface{ ContentType() string }) PayloadInfo {
+	return PayloadInfo{
+		Signal:          signal,
+		Encoding:        s.config.Encoding,
+		ContentType:     m.ContentType(),
+		ContentEncoding: marshaler.ContentEncodingOf(m),
 	}
-	return nil
+}
+
+// pushPartitions marshals and sends every partition of a batch on its own,
+// so that a failed partition does not prevent the others from being sent.
+// marshalTo is nil unless the marshaler is streaming, in which case the
+// partitions are streamed into a StreamSender when the config sets no split
+// limit, as the size of a payload is only known once marshaled.
+func pushPartitions[T any](ctx context.Context, s *emptyexporter, info PayloadInfo, partitions []marshaler.PathPartition[T], marshal func(T) ([][]byte, error), marshalTo func(io.Writer, T) error) error {
+	extension := marshaler.FileExtension(info.Encoding)
+	stream, ok := s.sender.(StreamSender)
+	if !ok || s.config.Split != (marshaler.SplitConfig{}) {
+		marshalTo = nil
+	}
+	var errs []error
+	for _, p := range partitions {
+		path := func() string {
+			return p.Path(info.Signal, extension)
+		}
+		if marshalTo != nil {
+			errs = append(errs, stream.SendStream(pathContext(ctx, path), info, func(w io.Writer) error {
+				return marshalTo(w, p.Batch)
+			}))
+			continue
+		}
+		chunks, err := marshal(p.Batch)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, s.send(ctx, chunks, info, path))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *emptyexporter) send(ctx context.Context, chunks [][]byte, info PayloadInfo, path func() string) error {
+	var errs []error
+	for _, chunk := range chunks {
+		if err := s.sender.Send(pathContext(ctx, path), chunk, info); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pathContext returns a context carrying a new path of a payload, unless the
+// config has no path template.
+func pathContext(ctx context.Context, path func() string) context.Context {
+	if p := path(); p != "" {
+		return ContextWithPath(ctx, p)
+	}
+	return ctx
 }
 
 // registerTracesMarshaler sets the traces marshaler to use
@@ -79,8 +168,3 @@ func (e *emptyexporter) registerMetricsMarshaler(marshaler marshaler.Metrics) {
 func (e *emptyexporter) registerLogsMarshaler(marshaler marshaler.Logs) {
 	e.logsMarshaler = marshaler
 }
-
-func sendToScreen(_ context.Context, e *emptyexporter, content string, contentType string) error {
-	e.logger.Info("Empty send ->", zap.String("content", content), zap.String("contentType", contentType))
-	return nil
-}
@@ -0,0 +1,199 @@
+package emptyexporter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// newTestLogsExporter returns a started logs exporter sending with a file
+// sender.
+func newTestLogsExporter(t *testing.T, cfg *Config) *emptyexporter {
+	t.Helper()
+	cfg.Sender = FileSender
+	if cfg.File.Directory == "" {
+		cfg.File.Directory = t.TempDir()
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	s, err := newEmptyexporter(zap.NewNop(), cfg, &fileSender{config: cfg.File, signal: SignalLogs, logger: zap.NewNop()})
+	if err != nil {
+		t.Fatalf("newEmptyexporter() failed: %v", err)
+	}
+	m, err := newEmptyExporterFactory().logsMarshaler(cfg)
+	if err != nil {
+		t.Fatalf("logsMarshaler() failed: %v", err)
+	}
+	s.registerLogsMarshaler(m)
+	if err := s.start(context.Background(), nil); err != nil {
+		t.Fatalf("start() failed: %v", err)
+	}
+	return s
+}
+
+func TestExporterFileSenderWithoutShouldLog(t *testing.T) {
+	s := newTestLogsExporter(t, &Config{Encoding: "otlp_csv"})
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("hello")
+	if err := s.pushLogs(context.Background(), ld); err != nil {
+		t.Fatalf("pushLogs() failed: %v", err)
+	}
+	if err := s.shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(s.config.File.Directory)
+	if err != nil {
+		t.Fatalf("ReadDir() failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected a single file, got %d", len(entries))
+	}
+}
+
+// serviceLogs returns a log record for every service, each in its own
+// resource.
+func serviceLogs(services ...string) plog.Logs {
+	ld := plog.NewLogs()
+	for _, service := range services {
+		rl := ld.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("service.name", service)
+		rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(service)
+	}
+	return ld
+}
+
+// walkFiles returns the paths of the files under a directory, relative to it
+// and in lexical order.
+func walkFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		paths = append(paths, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WalkDir() failed: %v", err)
+	}
+	return paths
+}
+
+func TestExporterPathTemplate(t *testing.T) {
+	s := newTestLogsExporter(t, &Config{Encoding: "otlp_csv+gzip", Path: "{signal}/{resource.service.name}/part-{uuid}.{ext}"})
+	if err := s.pushLogs(context.Background(), serviceLogs("atm", "bank", "atm")); err != nil {
+		t.Fatalf("pushLogs() failed: %v", err)
+	}
+
+	paths := walkFiles(t, s.config.File.Directory)
+	uuid := `[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}`
+	want := []string{`logs/atm/part-` + uuid + `\.csv\.gz`, `logs/bank/part-` + uuid + `\.csv\.gz`}
+	if len(paths) != len(want) {
+		t.Fatalf("Expected %d files, got %q", len(want), paths)
+	}
+	for i, path := range paths {
+		if !regexp.MustCompile("^" + want[i] + "$").MatchString(path) {
+			t.Errorf("Expected file %d to match %s, got %s", i, want[i], path)
+		}
+	}
+}
+
+func TestExporterPathTemplateWithoutUUID(t *testing.T) {
+	s := newTestLogsExporter(t, &Config{Encoding: "otlp_csv", Path: "{resource.service.name}/part.{ext}"})
+	for _, service := range []string{"atm", "atm", "atm"} {
+		if err := s.pushLogs(context.Background(), serviceLogs(service)); err != nil {
+			t.Fatalf("pushLogs() failed: %v", err)
+		}
+	}
+
+	want := []string{"atm/part-1.csv", "atm/part-2.csv", "atm/part.csv"}
+	if got := walkFiles(t, s.config.File.Directory); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected files %q, got %q", want, got)
+	}
+}
+
+// recordingSender records the payloads it is sent and how many were
+// streamed.
+type recordingSender struct {
+	payloads []string
+	streamed int
+}
+
+func (s *recordingSender) Start(context.Context, component.Host) error { return nil }
+func (s *recordingSender) Shutdown(context.Context) error              { return nil }
+
+func (s *recordingSender) Send(_ context.Context, payload []byte, _ PayloadInfo) error {
+	s.payloads = append(s.payloads, string(payload))
+	return nil
+}
+
+func (s *recordingSender) SendStream(_ context.Context, _ PayloadInfo, write func(w io.Writer) error) error {
+	var b bytes.Buffer
+	if err := write(&b); err != nil {
+		return err
+	}
+	s.payloads = append(s.payloads, b.String())
+	s.streamed++
+	return nil
+}
+
+func TestExporterStreamsPayloads(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		split    marshaler.SplitConfig
+		payloads int
+		streamed int
+	}{
+		{"streamed", marshaler.SplitConfig{}, 1, 1},
+		{"split", marshaler.SplitConfig{MaxRecords: 1}, 2, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Encoding: "otlp_csv+gzip", Split: tt.split}
+			if err := cfg.Validate(); err != nil {
+				t.Fatalf("Validate() failed: %v", err)
+			}
+			sender := &recordingSender{}
+			s, err := newEmptyexporter(zap.NewNop(), cfg, sender)
+			if err != nil {
+				t.Fatalf("newEmptyexporter() failed: %v", err)
+			}
+			m, err := newEmptyExporterFactory().logsMarshaler(cfg)
+			if err != nil {
+				t.Fatalf("logsMarshaler() failed: %v", err)
+			}
+			s.registerLogsMarshaler(m)
+
+			ld := serviceLogs("atm", "bank")
+			if err := s.pushLogs(context.Background(), ld); err != nil {
+				t.Fatalf("pushLogs() failed: %v", err)
+			}
+			if len(sender.payloads) != tt.payloads || sender.streamed != tt.streamed {
+				t.Fatalf("Expected %d payloads with %d streamed, got %d with %d streamed", tt.payloads, tt.streamed, len(sender.payloads), sender.streamed)
+			}
+			if tt.streamed > 0 {
+				want, err := m.Marshal(ld)
+				if err != nil {
+					t.Fatalf("Marshal() failed: %v", err)
+				}
+				if got := sender.payloads[0]; got != string(want) {
+					t.Errorf("Expected the streamed payload to match Marshal(), got %q", got)
+				}
+			}
+		})
+	}
+}
@@ -16,58 +16,58 @@ var (
 
 type emptyExporterFactory struct {
 	Marshalers *marshaler.Marshalers
-	sender     senderFunc
+	// overrides holds the marshalers registered with the factory options,
+	// used in place of the built-in marshaler of the same encoding.
+	overrides *marshaler.Marshalers
+	senders   map[string]SenderFactory
 }
 
 // FactoryOption is used to configure a factory.
 type FactoryOption func(*emptyExporterFactory)
 
-// WithMarshalers adds additional marshalers to the factory or overrides
-// existing marshalers if present.
+// WithLogsMarshalers adds additional marshalers to the factory or overrides
+// existing marshalers if present, the built-in ones included.
 func WithLogsMarshalers(marshalers ...marshaler.Logs) FactoryOption {
 	return func(f *emptyExporterFactory) {
 		for _, m := range marshalers {
 			f.Marshalers.Logs[m.Encoding()] = m
+			f.overrides.Logs[m.Encoding()] = m
 		}
 	}
 }
 
 // WithMetricsMarshalers adds additional marshalers to the factory or overrides
-// existing marshalers if present.
+// existing marshalers if present, the built-in ones included.
 func WithMetricsMarshalers(marshalers ...marshaler.Metrics) FactoryOption {
 	return func(f *emptyExporterFactory) {
 		for _, m := range marshalers {
 			f.Marshalers.Metrics[m.Encoding()] = m
+			f.overrides.Metrics[m.Encoding()] = m
 		}
 	}
 }
 
 // WithTracesMarshalers adds additional marshalers to the factory or overrides
-// existing marshalers if present.
+// existing marshalers if present, the built-in ones included.
 func WithTracesMarshalers(marshalers ...marshaler.Traces) FactoryOption {
 	return func(f *emptyExporterFactory) {
 		for _, m := range marshalers {
 			f.Marshalers.Traces[m.Encoding()] = m
+			f.overrides.Traces[m.Encoding()] = m
 		}
 	}
 }
 
-// WithSender overrides the default sender with a custom sender.
-func WithSender(sender senderFunc) FactoryOption {
+// WithSender registers a sender under a name, for configs to select it, or
+// overrides the sender of that name if present.
+func WithSender(name string, sender SenderFactory) FactoryOption {
 	return func(f *emptyExporterFactory) {
-		f.sender = sender
+		f.senders[name] = sender
 	}
 }
 
 func NewFactory(options ...FactoryOption) exporter.Factory {
-	f := &emptyExporterFactory{
-		Marshalers: marshaler.BaseMarshalers(),
-		sender:     nil,
-	}
-
-	for _, opt := range options {
-		opt(f)
-	}
+	f := newEmptyExporterFactory(options...)
 	return exporter.NewFactory(
 		typeStr,
 		createDefaultConfig,
@@ -77,21 +77,47 @@ func NewFactory(options ...FactoryOption) exporter.Factory {
 	)
 }
 
+func newEmptyExporterFactory(options ...FactoryOption) *emptyExporterFactory {
+	f := &emptyExporterFactory{
+		Marshalers: marshaler.BaseMarshalers(),
+		overrides: &marshaler.Marshalers{
+			Logs:    map[string]marshaler.Logs{},
+			Metrics: map[string]marshaler.Metrics{},
+			Traces:  map[string]marshaler.Traces{},
+		},
+		senders: map[string]SenderFactory{
+			ScreenSender: newScreenSender,
+			FileSender:   newFileSender,
+		},
+	}
+
+	for _, opt := range options {
+		opt(f)
+	}
+	return f
+}
+
 func (f *emptyExporterFactory) createTracesExporter(
 	ctx context.Context,
 	params exporter.Settings,
 	config component.Config) (exporter.Traces, error) {
 	cfg := config.(*Config)
-	s, err := newEmptyexporter(params.Logger, config.(*Config))
+	sender, err := f.newSender(params, cfg, SignalTraces)
 	if err != nil {
 		return nil, err
 	}
-	if marshaler, ok := f.Marshalers.Traces[cfg.Encoding]; ok {
-		s.registerTracesMarshaler(marshaler)
-	} else {
-		return nil, fmt.Errorf("marshaler %s not found", cfg.Encoding)
+	s, err := newEmptyexporter(params.Logger, config.(*Config), sender)
+	if err != nil {
+		return nil, err
+	}
+	marshaler, err := f.tracesMarshaler(cfg)
+	if err != nil {
+		return nil, err
 	}
-	return exporterhelper.NewTraces(ctx, params, cfg, s.pushTraces)
+	s.registerTracesMarshaler(marshaler)
+	return exporterhelper.NewTraces(ctx, params, cfg, s.pushTraces,
+		exporterhelper.WithStart(s.start),
+		exporterhelper.WithShutdown(s.shutdown))
 }
 
 func (f *emptyExporterFactory) createMetricsExporter(
@@ -99,16 +125,22 @@ func (f *emptyExporterFactory) createMetricsExporter(
 	params exporter.Settings,
 	config component.Config) (exporter.Metrics, error) {
 	cfg := config.(*Config)
-	s, err := newEmptyexporter(params.Logger, config.(*Config))
+	sender, err := f.newSender(params, cfg, SignalMetrics)
 	if err != nil {
 		return nil, err
 	}
-	if marshaler, ok := f.Marshalers.Metrics[cfg.Encoding]; ok {
-		s.registerMetricsMarshaler(marshaler)
-	} else {
-		return nil, fmt.Errorf("marshaler %s not found", cfg.Encoding)
+	s, err := newEmptyexporter(params.Logger, config.(*Config), sender)
+	if err != nil {
+		return nil, err
+	}
+	marshaler, err := f.metricsMarshaler(cfg)
+	if err != nil {
+		return nil, err
 	}
-	return exporterhelper.NewMetrics(ctx, params, cfg, s.pushMetrics)
+	s.registerMetricsMarshaler(marshaler)
+	return exporterhelper.NewMetrics(ctx, params, cfg, s.pushMetrics,
+		exporterhelper.WithStart(s.start),
+		exporterhelper.WithShutdown(s.shutdown))
 }
 
 func (f *emptyExporterFactory) createLogsExporter(
@@ -116,16 +148,175 @@ func (f *emptyExporterFactory) createLogsExporter(
 	params exporter.Settings,
 	config component.Config) (exporter.Logs, error) {
 	cfg := config.(*Config)
-	s, err := newEmptyexporter(params.Logger, config.(*Config))
+	sender, err := f.newSender(params, cfg, SignalLogs)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newEmptyexporter(params.Logger, config.(*Config), sender)
 	if err != nil {
 		return nil, err
 	}
-	if marshaler, ok := f.Marshalers.Logs[cfg.Encoding]; ok {
-		s.registerLogsMarshaler(marshaler)
-	} else {
-		return nil, fmt.Errorf("marshaler %s not found", cfg.Encoding)
+	marshaler, err := f.logsMarshaler(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.registerLogsMarshaler(marshaler)
+	return exporterhelper.NewLogs(ctx, params, cfg, s.pushLogs,
+		exporterhelper.WithStart(s.start),
+		exporterhelper.WithShutdown(s.shutdown))
+}
+
+// newSender creates the sender the config names for a signal, the screen
+// sender when it names none.
+func (f *emptyExporterFactory) newSender(params exporter.Settings, cfg *Config, signal string) (Sender, error) {
+	name := cfg.Sender
+	if name == "" {
+		name = ScreenSender
+	}
+	sender, ok := f.senders[name]
+	if !ok {
+		return nil, fmt.Errorf("sender %s not found", name)
+	}
+	return sender(params, cfg, signal)
+}
+
+// tracesMarshaler returns the traces marshaler of the configured encoding.
+// Marshalers registered with WithTracesMarshalers take precedence, then
+// encodings with options in the config are built from those options. The
+// output is compressed when the encoding names a compression.
+func (f *emptyExporterFactory) tracesMarshaler(cfg *Config) (marshaler.Traces, error) {
+	if m, ok := f.overrides.Traces[cfg.Encoding]; ok {
+		return m, nil
+	}
+	encoding, compression := splitEncoding(cfg.Encoding)
+	m, ok := f.overrides.Traces[encoding]
+	if !ok {
+		m, ok = builtinTracesMarshaler(cfg, encoding)
+	}
+	if !ok {
+		if m, ok = f.Marshalers.Traces[encoding]; !ok {
+			return nil, fmt.Errorf("marshaler %s not found", cfg.Encoding)
+		}
+	}
+	if compression == "" {
+		return m, nil
+	}
+	return marshaler.NewCompressedTraces(m, compression)
+}
+
+// builtinTracesMarshaler builds the traces marshaler of a built-in encoding
+// from the options of the config.
+func builtinTracesMarshaler(cfg *Config, encoding string) (marshaler.Traces, bool) {
+	switch encoding {
+	case OTLPCSV.String():
+		return marshaler.NewOtlpCsvTracesWithConfig(cfg.CSV), true
+	case OTLPParquet.String():
+		return marshaler.NewOtlpParquetTracesWithConfig(cfg.Parquet), true
+	case OTLPArrowIPC.String():
+		return marshaler.NewOtlpArrowIPCTracesWithConfig(cfg.ArrowIPC), true
+	case OTLPProtobuf.String():
+		return marshaler.NewOtlpProtobufTraces(), true
+	case OTLPJSON.String():
+		return marshaler.NewOtlpJSONTraces(), true
+	case ChromeTrace.String():
+		return marshaler.NewChromeTraceTraces(), true
+	case FoldedStacks.String():
+		return marshaler.NewFoldedStacksTraces(), true
+	default:
+		return nil, false
+	}
+}
+
+// metricsMarshaler returns the metrics marshaler of the configured encoding.
+// Marshalers registered with WithMetricsMarshalers take precedence, then
+// encodings with options in the config are built from those options. The
+// output is compressed when the encoding names a compression.
+func (f *emptyExporterFactory) metricsMarshaler(cfg *Config) (marshaler.Metrics, error) {
+	if m, ok := f.overrides.Metrics[cfg.Encoding]; ok {
+		return m, nil
+	}
+	encoding, compression := splitEncoding(cfg.Encoding)
+	m, ok := f.overrides.Metrics[encoding]
+	if !ok {
+		m, ok = builtinMetricsMarshaler(cfg, encoding)
+	}
+	if !ok {
+		if m, ok = f.Marshalers.Metrics[encoding]; !ok {
+			return nil, fmt.Errorf("marshaler %s not found", cfg.Encoding)
+		}
+	}
+	if compression == "" {
+		return m, nil
+	}
+	return marshaler.NewCompressedMetrics(m, compression)
+}
+
+// builtinMetricsMarshaler builds the metrics marshaler of a built-in encoding
+// from the options of the config.
+func builtinMetricsMarshaler(cfg *Config, encoding string) (marshaler.Metrics, bool) {
+	switch encoding {
+	case OTLPCSV.String():
+		return marshaler.NewOtlpCsvMetricsWithConfig(cfg.CSV), true
+	case OTLPParquet.String():
+		return marshaler.NewOtlpParquetMetricsWithConfig(cfg.Parquet), true
+	case OTLPArrowIPC.String():
+		return marshaler.NewOtlpArrowIPCMetricsWithConfig(cfg.ArrowIPC), true
+	case OTLPProtobuf.String():
+		return marshaler.NewOtlpProtobufMetrics(), true
+	case OTLPJSON.String():
+		return marshaler.NewOtlpJSONMetrics(), true
+	case PrometheusText.String():
+		return marshaler.NewPrometheusTextMetricsWithConfig(cfg.Prometheus), true
+	default:
+		return nil, false
+	}
+}
+
+// logsMarshaler returns the logs marshaler of the configured encoding.
+// Marshalers registered with WithLogsMarshalers take precedence, then
+// encodings with options in the config are built from those options. The
+// output is compressed when the encoding names a compression.
+func (f *emptyExporterFactory) logsMarshaler(cfg *Config) (marshaler.Logs, error) {
+	if m, ok := f.overrides.Logs[cfg.Encoding]; ok {
+		return m, nil
+	}
+	encoding, compression := splitEncoding(cfg.Encoding)
+	m, ok := f.overrides.Logs[encoding]
+	if !ok {
+		m, ok = builtinLogsMarshaler(cfg, encoding)
+	}
+	if !ok {
+		if m, ok = f.Marshalers.Logs[encoding]; !ok {
+			return nil, fmt.Errorf("marshaler %s not found", cfg.Encoding)
+		}
+	}
+	if compression == "" {
+		return m, nil
+	}
+	return marshaler.NewCompressedLogs(m, compression)
+}
+
+// builtinLogsMarshaler builds the logs marshaler of a built-in encoding
+// from the options of the config.
+func builtinLogsMarshaler(cfg *Config, encoding string) (marshaler.Logs, bool) {
+	switch encoding {
+	case OTLPCSV.String():
+		return marshaler.NewOtlpCsvLogsWithConfig(cfg.CSV), true
+	case OTLPParquet.String():
+		return marshaler.NewOtlpParquetLogsWithConfig(cfg.Parquet), true
+	case OTLPArrowIPC.String():
+		return marshaler.NewOtlpArrowIPCLogsWithConfig(cfg.ArrowIPC), true
+	case OTLPProtobuf.String():
+		return marshaler.NewOtlpProtobufLogs(), true
+	case OTLPJSON.String():
+		return marshaler.NewOtlpJSONLogs(), true
+	case Logfmt.String():
+		return marshaler.NewLogfmtLogs(), true
+	case Syslog.String():
+		return marshaler.NewSyslogLogsWithConfig(cfg.Syslog), true
+	default:
+		return nil, false
 	}
-	return exporterhelper.NewLogs(ctx, params, cfg, s.pushLogs)
 }
 
 func createDefaultConfig() component.Config {
@@ -0,0 +1,57 @@
+package emptyexporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// fixedTraces marshals every batch into the same payload.
+type fixedTraces struct {
+	encoding string
+	payload  []byte
+}
+
+func (m fixedTraces) Marshal(ptrace.Traces) ([]byte, error) { return m.payload, nil }
+func (m fixedTraces) Encoding() string                      { return m.encoding }
+func (m fixedTraces) ContentType() string                   { return "text/plain" }
+
+func TestFactoryMarshalerOverride(t *testing.T) {
+	override := fixedTraces{encoding: OTLPCSV.String(), payload: []byte("override")}
+	f := newEmptyExporterFactory(WithTracesMarshalers(override))
+
+	m, err := f.tracesMarshaler(&Config{Encoding: "otlp_csv"})
+	if err != nil {
+		t.Fatalf("tracesMarshaler() failed: %v", err)
+	}
+	if got, ok := m.(fixedTraces); !ok || !bytes.Equal(got.payload, override.payload) {
+		t.Fatalf("Expected the registered otlp_csv marshaler, got %T", m)
+	}
+
+	m, err = f.tracesMarshaler(&Config{Encoding: "otlp_csv+gzip"})
+	if err != nil {
+		t.Fatalf("tracesMarshaler() failed: %v", err)
+	}
+	b, err := m.Marshal(ptrace.NewTraces())
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() failed: %v", err)
+	}
+	if got, err := io.ReadAll(r); err != nil || string(got) != "override" {
+		t.Fatalf("Expected the registered marshaler to be compressed, got %q, %v", got, err)
+	}
+
+	m, err = newEmptyExporterFactory().tracesMarshaler(&Config{Encoding: "otlp_csv"})
+	if err != nil {
+		t.Fatalf("tracesMarshaler() failed: %v", err)
+	}
+	if _, ok := m.(fixedTraces); ok || m.Encoding() != "otlp_csv" {
+		t.Fatalf("Expected the built-in otlp_csv marshaler, got %T", m)
+	}
+}
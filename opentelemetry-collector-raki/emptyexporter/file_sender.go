@@ -0,0 +1,392 @@
+package emptyexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter"
+	"go.uber.org/zap"
+)
+
+// FileSender is the name of the sender writing payloads to files in a
+// directory.
+const FileSender = "file"
+
+const (
+	// fileTimeFormat formats the creation time in file names, sorting in
+	// time order.
+	fileTimeFormat = "20060102T150405.000Z"
+
+	// tmpSuffix ends the name of the file being written.
+	tmpSuffix = ".tmp"
+
+	// partialSuffix replaces tmpSuffix on the files left by a previous run,
+	// which may end with a partially written payload.
+	partialSuffix = ".partial"
+)
+
+// FileSenderConfig holds the options of the file sender.
+//
+// Payloads are appended to the current file of a signal, written as
+// <signal>-<time>-<sequence><extension>.tmp, e.g.
+// logs-20241018T120000.000Z-000001.csv.zst.tmp, the extension being the one
+// of the encoding of the payloads. Once the file is
+// rotated it is synced and renamed without the .tmp suffix, so that
+// completed files are never seen partially written. A payload that fails to
+// be written is cut from the file, keeping the payloads before it.
+//
+// A file holding several payloads is only readable as a whole for encodings
+// whose payloads can be concatenated, e.g. otlp_csv with skip_header, logfmt
+// or syslog. When neither MaxBytes nor RotateInterval is set, every payload
+// is written to its own file, as columnar encodings such as otlp_parquet
+// require. Config.Validate rejects rotation with otlp_parquet,
+// otlp_arrow_ipc, prometheus_text, chrome_trace and compressed encodings.
+//
+// When the config has a path template, every payload is written to its own
+// file at the rendered path under Directory, moved from .tmp as well, and
+// neither rotation nor retention applies. An existing file is never
+// replaced: when the path is taken, as happens for templates without {uuid},
+// a sequence number is added before the extension, e.g. part-1.csv.
+type FileSenderConfig struct {
+	// Directory is where files are written. It is created when missing and
+	// should only hold the files of one exporter, as retention and recovery
+	// apply to every file of a signal in it.
+	Directory string `mapstructure:"directory"`
+
+	// MaxBytes rotates a file once it reaches this size. A payload is never
+	// split, so a file exceeds the limit when a single payload does.
+	MaxBytes int64 `mapstructure:"max_bytes"`
+
+	// RotateInterval rotates a file once it has been open this long.
+	RotateInterval time.Duration `mapstructure:"rotate_interval"`
+
+	// MaxFiles is the number of completed files of a signal kept, the
+	// oldest being removed first. Zero keeps every file.
+	MaxFiles int `mapstructure:"max_files"`
+
+	// MaxAge removes completed files last modified longer ago than this.
+	// Zero keeps files whatever their age.
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// Validate checks that the limits are not negative.
+func (c FileSenderConfig) Validate() error {
+	if c.MaxBytes < 0 {
+		return fmt.Errorf("invalid max bytes: %d", c.MaxBytes)
+	}
+	if c.RotateInterval < 0 {
+		return fmt.Errorf("invalid rotate interval: %s", c.RotateInterval)
+	}
+	if c.MaxFiles < 0 {
+		return fmt.Errorf("invalid max files: %d", c.MaxFiles)
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("invalid max age: %s", c.MaxAge)
+	}
+	return nil
+}
+
+// fileSender writes the payloads of a signal to rotated files.
+type fileSender struct {
+	config FileSenderConfig
+	signal string
+	logger *zap.Logger
+
+	mu sync.Mutex
+	// file is the file being written, nil until the next payload once a
+	// file is completed.
+	file *os.File
+	// name is the path of file once completed.
+	name     string
+	size     int64
+	timer    *time.Timer
+	sequence int
+}
+
+func newFileSender(params exporter.Settings, cfg *Config, signal string) (Sender, error) {
+	return &fileSender{
+		config: cfg.File,
+		signal: signal,
+		logger: params.Logger,
+	}, nil
+}
+
+// Start creates the directory and sets aside the files left by a previous
+// run that stopped before rotating them, renaming them to .partial as they
+// may end with a partially written payload.
+func (s *fileSender) Start(context.Context, component.Host) error {
+	if err := os.MkdirAll(s.config.Directory, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", s.config.Directory, err)
+	}
+	stale, err := filepath.Glob(filepath.Join(s.config.Directory, s.signal+"-*"+tmpSuffix))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		partial := strings.TrimSuffix(path, tmpSuffix) + partialSuffix
+		if err := os.Rename(path, partial); err != nil {
+			return fmt.Errorf("failed to set aside %s: %w", path, err)
+		}
+		s.logger.Warn("Set aside unfinished file of a previous run", zap.String("path", partial))
+	}
+	return s.applyRetention()
+}
+
+func (s *fileSender) Send(ctx context.Context, payload []byte, info PayloadInfo) error {
+	return s.send(ctx, info, int64(len(payload)), func(w io.Writer) error {
+		_, err := w.Write(payload)
+		return err
+	})
+}
+
+// SendStream writes the payload to the file as it is marshaled. As its size
+// is not known beforehand, the file is only rotated once it reaches MaxBytes.
+func (s *fileSender) SendStream(ctx context.Context, info PayloadInfo, write func(w io.Writer) error) error {
+	return s.send(ctx, info, 0, write)
+}
+
+// send writes a payload of the given size, zero when unknown, with write.
+func (s *fileSender) send(ctx context.Context, info PayloadInfo, size int64, write func(w io.Writer) error) error {
+	if path := PathFromContext(ctx); path != "" {
+		return s.writeFile(path, write)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil && s.config.MaxBytes > 0 && s.size+size > s.config.MaxBytes {
+		if err := s.complete(); err != nil {
+			return err
+		}
+	}
+	if s.file == nil {
+		if err := s.open(marshaler.FileExtension(info.Encoding)); err != nil {
+			return err
+		}
+	}
+	previous := s.size
+	if err := write(countingWriter{w: s.file, n: &s.size}); err != nil {
+		return errors.Join(fmt.Errorf("failed to write to %s: %w", s.file.Name(), err), s.truncate(previous))
+	}
+	if s.config.MaxBytes == 0 && s.config.RotateInterval == 0 ||
+		s.config.MaxBytes > 0 && s.size >= s.config.MaxBytes {
+		return s.complete()
+	}
+	return nil
+}
+
+// Shutdown completes the file being written.
+func (s *fileSender) Shutdown(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.complete()
+}
+
+// writeFile writes a payload with write to its own file at a path relative
+// to the directory.
+func (s *fileSender) writeFile(path string, write func(w io.Writer) error) error {
+	if !filepath.IsLocal(path) {
+		return fmt.Errorf("path %s leaves directory %s", path, s.config.Directory)
+	}
+	path = filepath.Join(s.config.Directory, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+	// Payloads sharing a path are written to distinct temporary files.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*"+tmpSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0o644); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to chmod %s: %w", f.Name(), err)
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write to %s: %w", f.Name(), err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to sync %s: %w", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", f.Name(), err)
+	}
+	if err := linkNew(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to complete %s: %w", f.Name(), err)
+	}
+	return nil
+}
+
+// linkNew links tmp to path, or to path with the first free sequence number
+// before its extension when path exists. Unlike a rename, a link never
+// replaces an existing file.
+func linkNew(tmp, path string) error {
+	dir, base := filepath.Split(path)
+	dot := strings.IndexByte(base, '.')
+	if dot < 0 {
+		dot = len(base)
+	}
+	for sequence := 1; ; sequence++ {
+		err := os.Link(tmp, path)
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", base[:dot], sequence, base[dot:]))
+	}
+}
+
+// countingWriter adds the number of bytes written to w to n.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// open creates the next file, rotated after RotateInterval when set.
+func (s *fileSender) open(extension string) error {
+	s.sequence++
+	name := fmt.Sprintf("%s-%s-%06d%s", s.signal, time.Now().UTC().Format(fileTimeFormat), s.sequence, extension)
+	path := filepath.Join(s.config.Directory, name)
+	f, err := os.OpenFile(path+tmpSuffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	s.file, s.name, s.size = f, path, 0
+	if s.config.RotateInterval > 0 {
+		s.timer = time.AfterFunc(s.config.RotateInterval, func() {
+			s.rotate(f)
+		})
+	}
+	return nil
+}
+
+// truncate cuts the file being written back to size, dropping the partial
+// payload written after the previous ones. The file is discarded when it
+// holds no other payload or cannot be cut, so that a partial payload is
+// never completed.
+func (s *fileSender) truncate(size int64) error {
+	if size == 0 {
+		return s.discard()
+	}
+	if err := s.file.Truncate(size); err != nil {
+		return errors.Join(fmt.Errorf("failed to truncate %s: %w", s.file.Name(), err), s.discard())
+	}
+	if _, err := s.file.Seek(size, io.SeekStart); err != nil {
+		return errors.Join(fmt.Errorf("failed to seek %s: %w", s.file.Name(), err), s.discard())
+	}
+	s.size = size
+	return nil
+}
+
+// discard closes and removes the file being written.
+func (s *fileSender) discard() error {
+	f := s.file
+	s.file, s.size = nil, 0
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	_ = f.Close()
+	if err := os.Remove(f.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", f.Name(), err)
+	}
+	return nil
+}
+
+// rotate completes f once its rotate interval elapsed, unless it was
+// already completed.
+func (s *fileSender) rotate(f *os.File) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != f {
+		return
+	}
+	if err := s.complete(); err != nil {
+		s.logger.Error("Failed to rotate file", zap.String("path", f.Name()), zap.Error(err))
+	}
+}
+
+// complete syncs and closes the file being written, moves it to its final
+// name and applies retention.
+func (s *fileSender) complete() error {
+	f, name := s.file, s.name
+	s.file, s.size = nil, 0
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to sync %s: %w", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", f.Name(), err)
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		return fmt.Errorf("failed to complete %s: %w", f.Name(), err)
+	}
+	return s.applyRetention()
+}
+
+// applyRetention removes the completed files of the signal over MaxFiles,
+// oldest first, and those older than MaxAge.
+func (s *fileSender) applyRetention() error {
+	if s.config.MaxFiles == 0 && s.config.MaxAge == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(s.config.Directory)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", s.config.Directory, err)
+	}
+	// Entries are sorted by name, which starts with the creation time.
+	var completed []os.DirEntry
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, s.signal+"-") &&
+			!strings.HasSuffix(name, tmpSuffix) && !strings.HasSuffix(name, partialSuffix) {
+			completed = append(completed, entry)
+		}
+	}
+
+	var errs []error
+	remove := func(entry os.DirEntry) {
+		if err := os.Remove(filepath.Join(s.config.Directory, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	if s.config.MaxFiles > 0 && len(completed) > s.config.MaxFiles {
+		for _, entry := range completed[:len(completed)-s.config.MaxFiles] {
+			remove(entry)
+		}
+		completed = completed[len(completed)-s.config.MaxFiles:]
+	}
+	if s.config.MaxAge > 0 {
+		cutoff := time.Now().Add(-s.config.MaxAge)
+		for _, entry := range completed {
+			if info, err := entry.Info(); err == nil && info.ModTime().Before(cutoff) {
+				remove(entry)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,243 @@
+package emptyexporter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newTestFileSender returns a started logs file sender writing to a
+// temporary directory.
+func newTestFileSender(t *testing.T, config FileSenderConfig) *fileSender {
+	t.Helper()
+	if config.Directory == "" {
+		config.Directory = t.TempDir()
+	}
+	s := &fileSender{config: config, signal: SignalLogs, logger: zap.NewNop()}
+	if err := s.Start(context.Background(), nil); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	return s
+}
+
+// send sends the payloads as otlp_csv.
+func send(t *testing.T, s Sender, payloads ...string) {
+	t.Helper()
+	for _, payload := range payloads {
+		if err := s.Send(context.Background(), []byte(payload), PayloadInfo{Signal: SignalLogs, Encoding: "otlp_csv"}); err != nil {
+			t.Fatalf("Send() failed: %v", err)
+		}
+	}
+}
+
+// readFiles returns the content of the files in a directory, in name order,
+// the names being checked against the file name format of the sender.
+func readFiles(t *testing.T, dir string) (completed []string, tmp []string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() failed: %v", err)
+	}
+	for _, entry := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatalf("ReadFile() failed: %v", err)
+		}
+		if strings.HasSuffix(entry.Name(), tmpSuffix) {
+			tmp = append(tmp, string(b))
+			continue
+		}
+		if !strings.HasPrefix(entry.Name(), "logs-") || !strings.HasSuffix(entry.Name(), ".csv") {
+			t.Errorf("Expected a logs-*.csv file, got %s", entry.Name())
+		}
+		completed = append(completed, string(b))
+	}
+	return completed, tmp
+}
+
+func TestFileSenderFilePerPayload(t *testing.T) {
+	s := newTestFileSender(t, FileSenderConfig{})
+	send(t, s, "a\n", "b\n")
+
+	completed, tmp := readFiles(t, s.config.Directory)
+	if want := []string{"a\n", "b\n"}; !reflect.DeepEqual(completed, want) || len(tmp) != 0 {
+		t.Errorf("Expected files %q and no .tmp file, got %q and %q", want, completed, tmp)
+	}
+}
+
+func TestFileSenderSizeRotation(t *testing.T) {
+	s := newTestFileSender(t, FileSenderConfig{MaxBytes: 6})
+	send(t, s, "aa\n", "b\n", "cccc\n", "d\n")
+
+	completed, tmp := readFiles(t, s.config.Directory)
+	if want := []string{"aa\nb\n", "cccc\n"}; !reflect.DeepEqual(completed, want) {
+		t.Errorf("Expected completed files %q, got %q", want, completed)
+	}
+	if want := []string{"d\n"}; !reflect.DeepEqual(tmp, want) {
+		t.Errorf("Expected .tmp files %q, got %q", want, tmp)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() failed: %v", err)
+	}
+	completed, tmp = readFiles(t, s.config.Directory)
+	if want := []string{"aa\nb\n", "cccc\n", "d\n"}; !reflect.DeepEqual(completed, want) || len(tmp) != 0 {
+		t.Errorf("Expected files %q and no .tmp file once shut down, got %q and %q", want, completed, tmp)
+	}
+}
+
+func TestFileSenderTimeRotation(t *testing.T) {
+	s := newTestFileSender(t, FileSenderConfig{RotateInterval: 10 * time.Millisecond})
+	send(t, s, "a\n", "b\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		completed, tmp := readFiles(t, s.config.Directory)
+		if len(tmp) == 0 {
+			if want := []string{"a\nb\n"}; !reflect.DeepEqual(completed, want) {
+				t.Errorf("Expected files %q, got %q", want, completed)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the file to be rotated, got .tmp files %q", tmp)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFileSenderMaxFiles(t *testing.T) {
+	s := newTestFileSender(t, FileSenderConfig{MaxFiles: 2})
+	send(t, s, "a\n", "b\n", "c\n", "d\n")
+
+	completed, _ := readFiles(t, s.config.Directory)
+	if want := []string{"c\n", "d\n"}; !reflect.DeepEqual(completed, want) {
+		t.Errorf("Expected the newest files %q, got %q", want, completed)
+	}
+}
+
+func TestFileSenderMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "logs-20000101T000000.000Z-000001.csv")
+	if err := os.WriteFile(old, []byte("old\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatalf("Chtimes() failed: %v", err)
+	}
+
+	s := newTestFileSender(t, FileSenderConfig{Directory: dir, MaxAge: time.Hour})
+	send(t, s, "new\n")
+
+	completed, _ := readFiles(t, dir)
+	if want := []string{"new\n"}; !reflect.DeepEqual(completed, want) {
+		t.Errorf("Expected files %q, got %q", want, completed)
+	}
+}
+
+func TestFileSenderRecovery(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "logs-20241018T120000.000Z-000001.csv")
+	if err := os.WriteFile(stale+tmpSuffix, []byte("stale\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	other := filepath.Join(dir, "traces-20241018T120000.000Z-000001.csv"+tmpSuffix)
+	if err := os.WriteFile(other, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	newTestFileSender(t, FileSenderConfig{Directory: dir})
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected %s not to be completed, got %v", stale, err)
+	}
+	if b, err := os.ReadFile(stale + partialSuffix); err != nil || string(b) != "stale\n" {
+		t.Errorf("Expected %s to be set aside, got %q, %v", stale+partialSuffix, b, err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("Expected the .tmp file of another signal to be kept, got %v", err)
+	}
+}
+
+func TestFileSenderWriteFailure(t *testing.T) {
+	s := newTestFileSender(t, FileSenderConfig{MaxBytes: 1 << 20})
+	send(t, s, "a\n")
+	tmp := s.file.Name()
+	_ = s.file.Close()
+
+	if err := s.Send(context.Background(), []byte("b\n"), PayloadInfo{Signal: SignalLogs, Encoding: "otlp_csv"}); err == nil {
+		t.Fatalf("Expected Send() to fail on a closed file")
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", tmp, err)
+	}
+	if s.file != nil || s.size != 0 {
+		t.Errorf("Expected no file being written, got %v of %d bytes", s.file, s.size)
+	}
+
+	send(t, s, "c\n")
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() failed: %v", err)
+	}
+	if completed, _ := readFiles(t, s.config.Directory); !reflect.DeepEqual(completed, []string{"c\n"}) {
+		t.Errorf("Expected a new file once the write failed, got %q", completed)
+	}
+}
+
+func TestFileSenderPartialPayload(t *testing.T) {
+	s := newTestFileSender(t, FileSenderConfig{MaxBytes: 1 << 20})
+	send(t, s, "a\n", "b\n")
+	info := PayloadInfo{Signal: SignalLogs, Encoding: "otlp_csv"}
+	if err := s.SendStream(context.Background(), info, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "partial"); err != nil {
+			return err
+		}
+		return errors.New("marshal failed")
+	}); err == nil {
+		t.Fatalf("Expected SendStream() to fail")
+	}
+	if s.size != 4 {
+		t.Errorf("Expected 4 bytes once the partial payload is dropped, got %d", s.size)
+	}
+
+	send(t, s, "c\n")
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() failed: %v", err)
+	}
+	if completed, _ := readFiles(t, s.config.Directory); !reflect.DeepEqual(completed, []string{"a\nb\nc\n"}) {
+		t.Errorf("Expected the payloads before the failure to be kept, got %q", completed)
+	}
+}
+
+func TestFileSenderStreamFailure(t *testing.T) {
+	s := newTestFileSender(t, FileSenderConfig{})
+	info := PayloadInfo{Signal: SignalLogs, Encoding: "otlp_csv"}
+	if err := s.SendStream(context.Background(), info, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "partial"); err != nil {
+			return err
+		}
+		return errors.New("marshal failed")
+	}); err == nil {
+		t.Fatalf("Expected SendStream() to fail")
+	}
+	if err := s.SendStream(context.Background(), info, func(w io.Writer) error {
+		_, err := io.WriteString(w, "a\n")
+		return err
+	}); err != nil {
+		t.Fatalf("SendStream() failed: %v", err)
+	}
+
+	completed, tmp := readFiles(t, s.config.Directory)
+	if want := []string{"a\n"}; !reflect.DeepEqual(completed, want) || len(tmp) != 0 {
+		t.Errorf("Expected files %q and no .tmp file, got %q and %q", want, completed, tmp)
+	}
+}
@@ -0,0 +1,119 @@
+package emptyexporter
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter"
+	"go.uber.org/zap"
+)
+
+// ScreenSender is the name of the sender logging every payload, used when
+// the config names no sender.
+const ScreenSender = "screen"
+
+// Signals a sender is created for, every signal of an exporter having its
+// own sender.
+const (
+	SignalTraces  = "traces"
+	SignalMetrics = "metrics"
+	SignalLogs    = "logs"
+)
+
+// PayloadInfo describes a payload being sent.
+type PayloadInfo struct {
+	// Signal is the signal of the records in the payload, see SignalLogs.
+	Signal string
+
+	// Encoding is the configured encoding name, including its compression,
+	// e.g. otlp_csv+zstd.
+	Encoding string
+
+	// ContentType is the media type of the encoding, e.g. text/csv.
+	ContentType string
+
+	// ContentEncoding names the compression of the payload, empty when it is
+	// not compressed.
+	ContentEncoding string
+}
+
+// Sender delivers the payloads of an exporter to a destination. Start is
+// called before the first payload is sent and Shutdown once the exporter
+// stops, so that a sender can hold connections or files open in between.
+type Sender interface {
+	Start(ctx context.Context, host component.Host) error
+
+	// Send delivers a payload described by info. The payload must not be
+	// retained once Send returns.
+	Send(ctx context.Context, payload []byte, info PayloadInfo) error
+
+	Shutdown(ctx context.Context) error
+}
+
+// StreamSender is implemented by senders that can deliver a payload as it is
+// marshaled, so that the memory used does not grow with the batch size. The
+// exporter streams payloads into it when the marshaler is streaming and the
+// config sets no split limit.
+type StreamSender interface {
+	Sender
+
+	// SendStream delivers the payload described by info that write writes
+	// into w. A payload whose write fails must not be delivered.
+	SendStream(ctx context.Context, info PayloadInfo, write func(w io.Writer) error) error
+}
+
+// SenderFactory creates the sender of an exporter from its settings, config
+// and the signal of the payloads it sends.
+type SenderFactory func(params exporter.Settings, cfg *Config, signal string) (Sender, error)
+
+type pathContextKey struct{}
+
+// ContextWithPath returns a context carrying the path a payload is written
+// to, rendered from the path template of the config.
+func ContextWithPath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, pathContextKey{}, path)
+}
+
+// PathFromContext returns the path of the payload being sent, empty when the
+// config has no path template.
+func PathFromContext(ctx context.Context) string {
+	path, _ := ctx.Value(pathContextKey{}).(string)
+	return path
+}
+
+// screenSender logs every payload when the config sets ShouldLog.
+type screenSender struct {
+	logger    *zap.Logger
+	shouldLog bool
+}
+
+func newScreenSender(params exporter.Settings, cfg *Config, _ string) (Sender, error) {
+	return &screenSender{logger: params.Logger, shouldLog: cfg.ShouldLog}, nil
+}
+
+func (s *screenSender) Start(context.Context, component.Host) error {
+	return nil
+}
+
+func (s *screenSender) Send(ctx context.Context, payload []byte, info PayloadInfo) error {
+	if !s.shouldLog {
+		return nil
+	}
+	fields := []zap.Field{
+		zap.ByteString("content", payload),
+		zap.String("signal", info.Signal),
+		zap.String("encoding", info.Encoding),
+		zap.String("contentType", info.ContentType),
+		zap.String("contentEncoding", info.ContentEncoding),
+	}
+	if path := PathFromContext(ctx); path != "" {
+		fields = append(fields, zap.String("path", path))
+	}
+	s.logger.Info("Empty send ->", fields...)
+	return nil
+}
+
+func (s *screenSender) Shutdown(context.Context) error {
+	return nil
+}
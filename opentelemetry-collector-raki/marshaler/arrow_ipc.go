@@ -0,0 +1,217 @@
+package marshaler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/ipc"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// ArrowIPCFormat selects the Arrow IPC format of a batch.
+type ArrowIPCFormat string
+
+const (
+	// ArrowIPCFormatStream writes the Arrow IPC streaming format, meant to be
+	// read sequentially.
+	ArrowIPCFormatStream ArrowIPCFormat = "stream"
+
+	// ArrowIPCFormatFile writes the Arrow IPC file format, which ends with a
+	// footer so that readers can memory-map it and access batches randomly.
+	ArrowIPCFormatFile ArrowIPCFormat = "file"
+)
+
+// Dictionary encoded columns of each signal when dictionary encoding is
+// enabled. The resource and scope columns repeat for every row of a resource.
+var (
+	logsArrowDictionarySchema = dictionaryEncoded(logsArrowSchema,
+		"severity", "resource_attributes", "scope_name", "scope_version")
+	metricsArrowDictionarySchema = dictionaryEncoded(metricsArrowSchema,
+		"metric_name", "metric_type", "value_type", "aggregation_temporality",
+		"unit", "resource_attributes", "scope_name", "scope_version")
+	tracesArrowDictionarySchema = dictionaryEncoded(tracesArrowSchema,
+		"name", "kind", "status_code", "status_message",
+		"service_name", "resource_attributes", "scope_name", "scope_version")
+)
+
+// ArrowIPCConfig defines the options of the Arrow IPC marshaler.
+type ArrowIPCConfig struct {
+	// Format is the IPC format: stream or file. Defaults to stream.
+	Format ArrowIPCFormat `mapstructure:"format"`
+
+	// Dictionary enables dictionary encoding of the columns holding repeated
+	// strings, such as span names, metric names and resource attributes.
+	// With the file format, a batch is written as a single record batch, as
+	// the format allows one dictionary per column.
+	Dictionary bool `mapstructure:"dictionary"`
+}
+
+// Validate checks that the Arrow IPC options are supported.
+func (c ArrowIPCConfig) Validate() error {
+	switch c.Format {
+	case "", ArrowIPCFormatStream, ArrowIPCFormatFile:
+		return nil
+	default:
+		return fmt.Errorf("unsupported format: %s", c.Format)
+	}
+}
+
+// ArrowIPCMarshaler writes logs, metrics and traces as Arrow record batches
+// in the IPC stream or file format. The columns are those of the
+// Parquet marshaler, see logsArrowSchema, metricsArrowSchema and
+// tracesArrowSchema.
+type ArrowIPCMarshaler struct {
+	config ArrowIPCConfig
+}
+
+func NewArrowIPCMarshaler() ArrowIPCMarshaler {
+	return ArrowIPCMarshaler{}
+}
+
+// NewArrowIPCMarshalerWithConfig creates an Arrow IPC marshaler with the given options.
+func NewArrowIPCMarshalerWithConfig(config ArrowIPCConfig) ArrowIPCMarshaler {
+	return ArrowIPCMarshaler{config: config}
+}
+
+// MarshalLogs converts OpenTelemetry logs into Arrow IPC.
+func (m ArrowIPCMarshaler) MarshalLogs(ld plog.Logs) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteLogs(&buf, ld); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteLogs writes OpenTelemetry logs into w as Arrow IPC, one record
+// batch of at most arrowBatchRows rows at a time, see batchRows.
+func (m ArrowIPCMarshaler) WriteLogs(w io.Writer, ld plog.Logs) error {
+	schema := m.schema(logsArrowSchema, logsArrowDictionarySchema)
+	return m.write(w, schema, func(write func(rec arrow.Record) error) error {
+		return logsToArrowRecords(memory.DefaultAllocator, schema, ld, m.batchRows(), write)
+	})
+}
+
+// MarshalMetrics converts OpenTelemetry metrics into Arrow IPC.
+func (m ArrowIPCMarshaler) MarshalMetrics(md pmetric.Metrics) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteMetrics(&buf, md); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteMetrics writes OpenTelemetry metrics into w as Arrow IPC, one record
+// batch of at most arrowBatchRows rows at a time, see batchRows.
+func (m ArrowIPCMarshaler) WriteMetrics(w io.Writer, md pmetric.Metrics) error {
+	schema := m.schema(metricsArrowSchema, metricsArrowDictionarySchema)
+	return m.write(w, schema, func(write func(rec arrow.Record) error) error {
+		return metricsToArrowRecords(memory.DefaultAllocator, schema, md, m.batchRows(), write)
+	})
+}
+
+// MarshalTraces converts OpenTelemetry traces into Arrow IPC.
+func (m ArrowIPCMarshaler) MarshalTraces(td ptrace.Traces) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteTraces(&buf, td); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTraces writes OpenTelemetry traces into w as Arrow IPC, one record
+// batch of at most arrowBatchRows rows at a time, see batchRows.
+func (m ArrowIPCMarshaler) WriteTraces(w io.Writer, td ptrace.Traces) error {
+	schema := m.schema(tracesArrowSchema, tracesArrowDictionarySchema)
+	return m.write(w, schema, func(write func(rec arrow.Record) error) error {
+		return tracesToArrowRecords(memory.DefaultAllocator, schema, td, m.batchRows(), write)
+	})
+}
+
+// schema picks the plain or dictionary encoded schema of a signal.
+func (m ArrowIPCMarshaler) schema(plain, dictionary *arrow.Schema) *arrow.Schema {
+	if m.config.Dictionary {
+		return dictionary
+	}
+	return plain
+}
+
+// batchRows returns the maximum number of rows of a record batch. The file
+// format only allows a single dictionary per column, neither replaced nor
+// extended by deltas, so a dictionary encoded file is a single batch.
+func (m ArrowIPCMarshaler) batchRows() int {
+	if m.config.Format == ArrowIPCFormatFile && m.config.Dictionary {
+		return 0
+	}
+	return arrowBatchRows
+}
+
+// contentType returns the media type of the configured IPC format.
+func (m ArrowIPCMarshaler) contentType() string {
+	if m.config.Format == ArrowIPCFormatFile {
+		return contentTypeArrowFile
+	}
+	return contentTypeArrowStream
+}
+
+// write writes the records produced by records in the configured IPC format.
+// Dictionaries growing from one batch to the next are written as deltas in
+// the stream format.
+func (m ArrowIPCMarshaler) write(w io.Writer, schema *arrow.Schema, records func(write func(rec arrow.Record) error) error) error {
+	opts := []ipc.Option{ipc.WithSchema(schema), ipc.WithAllocator(memory.DefaultAllocator)}
+
+	var writer interface {
+		Write(arrow.Record) error
+		Close() error
+	}
+	if m.config.Format == ArrowIPCFormatFile {
+		fw, err := ipc.NewFileWriter(&offsetWriter{w: w}, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to create arrow writer: %w", err)
+		}
+		writer = fw
+	} else {
+		writer = ipc.NewWriter(noCloseWriter{w}, append(opts, ipc.WithDictionaryDeltas(true))...)
+	}
+
+	err := records(func(rec arrow.Record) error {
+		if err := writer.Write(rec); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+	return nil
+}
+
+// offsetWriter tracks the number of bytes written to a writer, so that the
+// Arrow IPC file writer, which only seeks to learn its current offset, can
+// stream into any writer. It hides the Close method of the writer.
+type offsetWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+// Seek returns the current offset and fails for any other seek.
+func (o *offsetWriter) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekCurrent {
+		return 0, fmt.Errorf("cannot seek arrow output to %d from %d", offset, whence)
+	}
+	return o.offset, nil
+}
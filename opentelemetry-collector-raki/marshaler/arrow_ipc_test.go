@@ -0,0 +1,185 @@
+package marshaler_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/ipc"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// readArrowIPC reads the record batches of an Arrow IPC stream or file back
+// into a table.
+func readArrowIPC(t *testing.T, format marshaler.ArrowIPCFormat, b []byte) arrow.Table {
+	t.Helper()
+	var schema *arrow.Schema
+	var records []arrow.Record
+	if format == marshaler.ArrowIPCFormatFile {
+		r, err := ipc.NewFileReader(bytes.NewReader(b), ipc.WithAllocator(memory.DefaultAllocator))
+		if err != nil {
+			t.Fatalf("NewFileReader() failed: %v", err)
+		}
+		defer r.Close()
+		schema = r.Schema()
+		for i := 0; i < r.NumRecords(); i++ {
+			rec, err := r.Record(i)
+			if err != nil {
+				t.Fatalf("Record() failed: %v", err)
+			}
+			rec.Retain()
+			records = append(records, rec)
+		}
+	} else {
+		r, err := ipc.NewReader(bytes.NewReader(b), ipc.WithAllocator(memory.DefaultAllocator))
+		if err != nil {
+			t.Fatalf("NewReader() failed: %v", err)
+		}
+		defer r.Release()
+		schema = r.Schema()
+		for r.Next() {
+			rec := r.Record()
+			rec.Retain()
+			records = append(records, rec)
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("Next() failed: %v", err)
+		}
+	}
+
+	table := array.NewTableFromRecords(schema, records)
+	for _, rec := range records {
+		rec.Release()
+	}
+	t.Cleanup(table.Release)
+	return table
+}
+
+func TestArrowIPCRoundTrip(t *testing.T) {
+	for name, config := range map[string]marshaler.ArrowIPCConfig{
+		"stream":            {},
+		"file":              {Format: marshaler.ArrowIPCFormatFile},
+		"stream/dictionary": {Format: marshaler.ArrowIPCFormatStream, Dictionary: true},
+		"file/dictionary":   {Format: marshaler.ArrowIPCFormatFile, Dictionary: true},
+	} {
+		m := marshaler.NewArrowIPCMarshalerWithConfig(config)
+		t.Run(name+"/logs", func(t *testing.T) {
+			b, err := m.MarshalLogs(nestedAttributesLogs())
+			if err != nil {
+				t.Fatalf("MarshalLogs() failed: %v", err)
+			}
+			checkLogsTable(t, readArrowIPC(t, config.Format, b))
+		})
+		t.Run(name+"/metrics", func(t *testing.T) {
+			b, err := m.MarshalMetrics(testMetrics())
+			if err != nil {
+				t.Fatalf("MarshalMetrics() failed: %v", err)
+			}
+			checkMetricsTable(t, readArrowIPC(t, config.Format, b))
+		})
+		t.Run(name+"/traces", func(t *testing.T) {
+			b, err := m.MarshalTraces(testTraces())
+			if err != nil {
+				t.Fatalf("MarshalTraces() failed: %v", err)
+			}
+			checkTracesTable(t, readArrowIPC(t, config.Format, b))
+		})
+	}
+}
+
+// manyLogs returns more log records than fit in a single record batch, with
+// a new severity text every batch so that dictionaries grow.
+func manyLogs() plog.Logs {
+	ld := plog.NewLogs()
+	lrs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < 150_000; i++ {
+		lr := lrs.AppendEmpty()
+		lr.SetSeverityText(fmt.Sprintf("level-%d", i/50_000))
+		lr.Body().SetStr("hello")
+	}
+	return ld
+}
+
+func TestArrowIPCDictionaryAcrossBatches(t *testing.T) {
+	for _, format := range []marshaler.ArrowIPCFormat{marshaler.ArrowIPCFormatStream, marshaler.ArrowIPCFormatFile} {
+		t.Run(string(format), func(t *testing.T) {
+			m := marshaler.NewArrowIPCMarshalerWithConfig(marshaler.ArrowIPCConfig{Format: format, Dictionary: true})
+			b, err := m.MarshalLogs(manyLogs())
+			if err != nil {
+				t.Fatalf("MarshalLogs() failed: %v", err)
+			}
+			table := readArrowIPC(t, format, b)
+			severities := columnStrings(t, table, "severity")
+			if len(severities) != 150_000 {
+				t.Fatalf("Expected 150000 rows, got %d", len(severities))
+			}
+			for _, i := range []int{0, 49_999, 50_000, 149_999} {
+				if want := fmt.Sprintf("level-%d", i/50_000); severities[i] != want {
+					t.Errorf("Expected severity %s at row %d, got %s", want, i, severities[i])
+				}
+			}
+		})
+	}
+}
+
+func TestArrowIPCUnmarshal(t *testing.T) {
+	for name, config := range map[string]marshaler.ArrowIPCConfig{
+		"stream":            {},
+		"file":              {Format: marshaler.ArrowIPCFormatFile},
+		"stream/dictionary": {Format: marshaler.ArrowIPCFormatStream, Dictionary: true},
+		"file/dictionary":   {Format: marshaler.ArrowIPCFormatFile, Dictionary: true},
+	} {
+		m := marshaler.NewArrowIPCMarshalerWithConfig(config)
+		t.Run(name+"/logs", func(t *testing.T) {
+			b, err := m.MarshalLogs(nestedAttributesLogs())
+			if err != nil {
+				t.Fatalf("MarshalLogs() failed: %v", err)
+			}
+			ld, err := m.UnmarshalLogs(b)
+			if err != nil {
+				t.Fatalf("UnmarshalLogs() failed: %v", err)
+			}
+			want, _ := (&plog.JSONMarshaler{}).MarshalLogs(nestedAttributesLogs())
+			got, _ := (&plog.JSONMarshaler{}).MarshalLogs(ld)
+			if string(got) != string(want) {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		})
+		t.Run(name+"/metrics", func(t *testing.T) {
+			b, err := m.MarshalMetrics(testMetrics())
+			if err != nil {
+				t.Fatalf("MarshalMetrics() failed: %v", err)
+			}
+			md, err := m.UnmarshalMetrics(b)
+			if err != nil {
+				t.Fatalf("UnmarshalMetrics() failed: %v", err)
+			}
+			want, _ := (&pmetric.JSONMarshaler{}).MarshalMetrics(testMetrics())
+			got, _ := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+			if string(got) != string(want) {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		})
+		t.Run(name+"/traces", func(t *testing.T) {
+			b, err := m.MarshalTraces(testTraces())
+			if err != nil {
+				t.Fatalf("MarshalTraces() failed: %v", err)
+			}
+			td, err := m.UnmarshalTraces(b)
+			if err != nil {
+				t.Fatalf("UnmarshalTraces() failed: %v", err)
+			}
+			want, _ := (&ptrace.JSONMarshaler{}).MarshalTraces(testTraces())
+			got, _ := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+			if string(got) != string(want) {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,607 @@
+package marshaler
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// The columnar schemas follow the column names and order of the CSV layouts,
+// so the logCol*, metricCol* and spanCol* indexes address the same columns,
+// but every column is typed: timestamps are nanosecond timestamps in UTC, list
+// columns are Arrow lists and columns that do not apply to a row are null.
+// Attribute and body columns hold the typed OTLP/JSON encoding, see
+// AttributeEncodingTyped, as do the events, links and exemplars columns.
+
+// logsArrowSchema is the columnar schema of logs, one row per log record.
+var logsArrowSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ns, Nullable: true},
+	{Name: "severity", Type: arrow.BinaryTypes.String},
+	{Name: "body", Type: arrow.BinaryTypes.String},
+	{Name: "attributes", Type: arrow.BinaryTypes.String},
+	{Name: "severity_number", Type: arrow.PrimitiveTypes.Int32},
+	{Name: "trace_id", Type: arrow.BinaryTypes.String},
+	{Name: "span_id", Type: arrow.BinaryTypes.String},
+	{Name: "flags", Type: arrow.PrimitiveTypes.Uint32},
+	{Name: "resource_attributes", Type: arrow.BinaryTypes.String},
+	{Name: "scope_name", Type: arrow.BinaryTypes.String},
+	{Name: "scope_version", Type: arrow.BinaryTypes.String},
+}, nil)
+
+// quantileType is the element type of the quantiles column.
+var quantileType = arrow.StructOf(
+	arrow.Field{Name: "quantile", Type: arrow.PrimitiveTypes.Float64},
+	arrow.Field{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+)
+
+// metricsArrowSchema is the columnar schema of metrics, one row per data
+// point. Int values are stored in the double value column, value_type keeps
+// the original type.
+var metricsArrowSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ns, Nullable: true},
+	{Name: "metric_name", Type: arrow.BinaryTypes.String},
+	{Name: "metric_type", Type: arrow.BinaryTypes.String},
+	{Name: "value_type", Type: arrow.BinaryTypes.String, Nullable: true},
+	{Name: "value", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	{Name: "count", Type: arrow.PrimitiveTypes.Uint64, Nullable: true},
+	{Name: "sum", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	{Name: "min", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	{Name: "max", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	{Name: "bucket_counts", Type: arrow.ListOf(arrow.PrimitiveTypes.Uint64), Nullable: true},
+	{Name: "explicit_bounds", Type: arrow.ListOf(arrow.PrimitiveTypes.Float64), Nullable: true},
+	{Name: "scale", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+	{Name: "zero_count", Type: arrow.PrimitiveTypes.Uint64, Nullable: true},
+	{Name: "positive_offset", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+	{Name: "positive_bucket_counts", Type: arrow.ListOf(arrow.PrimitiveTypes.Uint64), Nullable: true},
+	{Name: "negative_offset", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+	{Name: "negative_bucket_counts", Type: arrow.ListOf(arrow.PrimitiveTypes.Uint64), Nullable: true},
+	{Name: "quantiles", Type: arrow.ListOf(quantileType), Nullable: true},
+	{Name: "aggregation_temporality", Type: arrow.BinaryTypes.String, Nullable: true},
+	{Name: "is_monotonic", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+	{Name: "start_timestamp", Type: arrow.FixedWidthTypes.Timestamp_ns, Nullable: true},
+	{Name: "unit", Type: arrow.BinaryTypes.String},
+	{Name: "description", Type: arrow.BinaryTypes.String},
+	{Name: "attributes", Type: arrow.BinaryTypes.String},
+	{Name: "resource_attributes", Type: arrow.BinaryTypes.String},
+	{Name: "scope_name", Type: arrow.BinaryTypes.String},
+	{Name: "scope_version", Type: arrow.BinaryTypes.String},
+	{Name: "flags", Type: arrow.PrimitiveTypes.Uint32},
+	{Name: "exemplars", Type: arrow.BinaryTypes.String},
+}, nil)
+
+// tracesArrowSchema is the columnar schema of traces, one row per span.
+var tracesArrowSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "trace_id", Type: arrow.BinaryTypes.String},
+	{Name: "span_id", Type: arrow.BinaryTypes.String},
+	{Name: "parent_span_id", Type: arrow.BinaryTypes.String},
+	{Name: "name", Type: arrow.BinaryTypes.String},
+	{Name: "kind", Type: arrow.BinaryTypes.String},
+	{Name: "start_timestamp", Type: arrow.FixedWidthTypes.Timestamp_ns, Nullable: true},
+	{Name: "end_timestamp", Type: arrow.FixedWidthTypes.Timestamp_ns, Nullable: true},
+	{Name: "status_code", Type: arrow.BinaryTypes.String},
+	{Name: "status_message", Type: arrow.BinaryTypes.String},
+	{Name: "duration", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	{Name: "trace_state", Type: arrow.BinaryTypes.String},
+	{Name: "attributes", Type: arrow.BinaryTypes.String},
+	{Name: "service_name", Type: arrow.BinaryTypes.String},
+	{Name: "resource_attributes", Type: arrow.BinaryTypes.String},
+	{Name: "scope_name", Type: arrow.BinaryTypes.String},
+	{Name: "scope_version", Type: arrow.BinaryTypes.String},
+	{Name: "events", Type: arrow.BinaryTypes.String},
+	{Name: "links", Type: arrow.BinaryTypes.String},
+}, nil)
+
+// dictionaryType is the type of dictionary encoded string columns.
+var dictionaryType = &arrow.DictionaryType{
+	IndexType: arrow.PrimitiveTypes.Int32,
+	ValueType: arrow.BinaryTypes.String,
+}
+
+// dictionaryEncoded returns a copy of a schema where the named string columns
+// are dictionary encoded, so that repeated values are stored once per batch.
+func dictionaryEncoded(schema *arrow.Schema, names ...string) *arrow.Schema {
+	fields := append([]arrow.Field(nil), schema.Fields()...)
+	for _, name := range names {
+		for _, i := range schema.FieldIndices(name) {
+			fields[i].Type = dictionaryType
+		}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// arrowBatchRows bounds the number of rows buffered before a record is
+// handed to the writer, so that memory use does not grow with the batch.
+const arrowBatchRows = 64 * 1024
+
+// recordBatcher builds records of at most maxRows rows, or a single record
+// when maxRows is zero.
+type recordBatcher struct {
+	rb      *array.RecordBuilder
+	rows    int
+	maxRows int
+	write   func(rec arrow.Record) error
+}
+
+func newRecordBatcher(mem memory.Allocator, schema *arrow.Schema, maxRows int, write func(rec arrow.Record) error) *recordBatcher {
+	return &recordBatcher{rb: array.NewRecordBuilder(mem, schema), maxRows: maxRows, write: write}
+}
+
+// next records that a row was appended to every column, writing a record
+// once maxRows rows are buffered.
+func (b *recordBatcher) next() error {
+	b.rows++
+	if b.maxRows == 0 || b.rows < b.maxRows {
+		return nil
+	}
+	return b.flush()
+}
+
+// flush writes the buffered rows as a record, if any.
+func (b *recordBatcher) flush() error {
+	if b.rows == 0 {
+		return nil
+	}
+	rec := b.rb.NewRecord()
+	defer rec.Release()
+	b.rows = 0
+	return b.write(rec)
+}
+
+// release releases the builders.
+func (b *recordBatcher) release() {
+	b.rb.Release()
+}
+
+// noCloseWriter hides the Close method of a writer, so that closing a Parquet
+// or Arrow IPC writer does not close the writer it streams to.
+type noCloseWriter struct {
+	io.Writer
+}
+
+// logsToArrowRecords converts logs into records of logsArrowSchema, or of a
+// dictionary encoded variant of it, of at most maxRows rows, and hands them
+// to write. A zero maxRows converts all logs into a single record.
+func logsToArrowRecords(mem memory.Allocator, schema *arrow.Schema, ld plog.Logs, maxRows int, write func(rec arrow.Record) error) error {
+	b := newRecordBatcher(mem, schema, maxRows, write)
+	defer b.release()
+	rb := b.rb
+
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		resourceAttributes, err := attributesToJSONString(rl.Resource().Attributes())
+		if err != nil {
+			return fmt.Errorf("failed to serialize resource attributes: %w", err)
+		}
+		ills := rl.ScopeLogs()
+		for j := 0; j < ills.Len(); j++ {
+			ils := ills.At(j)
+			logs := ils.LogRecords()
+			for k := 0; k < logs.Len(); k++ {
+				lr := logs.At(k)
+				attributes, err := attributesToJSONString(lr.Attributes())
+				if err != nil {
+					return fmt.Errorf("failed to serialize attributes: %w", err)
+				}
+				appendTimestamp(rb.Field(logColTimestamp), lr.Timestamp())
+				appendString(rb.Field(logColSeverity), lr.SeverityText())
+				appendString(rb.Field(logColBody), bodyToJSONString(lr.Body()))
+				appendString(rb.Field(logColAttributes), attributes)
+				rb.Field(logColSeverityNumber).(*array.Int32Builder).Append(int32(lr.SeverityNumber()))
+				appendString(rb.Field(logColTraceID), lr.TraceID().String())
+				appendString(rb.Field(logColSpanID), lr.SpanID().String())
+				rb.Field(logColFlags).(*array.Uint32Builder).Append(uint32(lr.Flags()))
+				appendString(rb.Field(logColResourceAttributes), resourceAttributes)
+				appendString(rb.Field(logColScopeName), ils.Scope().Name())
+				appendString(rb.Field(logColScopeVersion), ils.Scope().Version())
+				if err := b.next(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return b.flush()
+}
+
+// tracesToArrowRecords converts traces into records of tracesArrowSchema, or
+// of a dictionary encoded variant of it, see logsToArrowRecords.
+func tracesToArrowRecords(mem memory.Allocator, schema *arrow.Schema, td ptrace.Traces, maxRows int, write func(rec arrow.Record) error) error {
+	b := newRecordBatcher(mem, schema, maxRows, write)
+	defer b.release()
+	rb := b.rb
+
+	traces := td.ResourceSpans()
+	for i := 0; i < traces.Len(); i++ {
+		rspan := traces.At(i)
+		resourceAttributes, err := attributesToJSONString(rspan.Resource().Attributes())
+		if err != nil {
+			return fmt.Errorf("failed to serialize resource attributes: %w", err)
+		}
+		service := serviceName(rspan.Resource().Attributes())
+		illSpans := rspan.ScopeSpans()
+		for j := 0; j < illSpans.Len(); j++ {
+			ils := illSpans.At(j)
+			spans := ils.Spans()
+			for k := 0; k < spans.Len(); k++ {
+				s := spans.At(k)
+				attributes, err := attributesToJSONString(s.Attributes())
+				if err != nil {
+					return fmt.Errorf("failed to serialize attributes: %w", err)
+				}
+				events, err := spanEventsToJSONString(s.Events())
+				if err != nil {
+					return fmt.Errorf("failed to serialize events: %w", err)
+				}
+				links, err := spanLinksToJSONString(s.Links())
+				if err != nil {
+					return fmt.Errorf("failed to serialize links: %w", err)
+				}
+				appendString(rb.Field(spanColTraceID), s.TraceID().String())
+				appendString(rb.Field(spanColSpanID), s.SpanID().String())
+				appendString(rb.Field(spanColParentSpanID), s.ParentSpanID().String())
+				appendString(rb.Field(spanColName), s.Name())
+				appendString(rb.Field(spanColKind), s.Kind().String())
+				appendTimestamp(rb.Field(spanColStartTimestamp), s.StartTimestamp())
+				appendTimestamp(rb.Field(spanColEndTimestamp), s.EndTimestamp())
+				appendString(rb.Field(spanColStatusCode), s.Status().Code().String())
+				appendString(rb.Field(spanColStatusMessage), s.Status().Message())
+				if duration, ok := spanDuration(s); ok {
+					rb.Field(spanColDuration).(*array.Int64Builder).Append(duration)
+				} else {
+					rb.Field(spanColDuration).AppendNull()
+				}
+				appendString(rb.Field(spanColTraceState), s.TraceState().AsRaw())
+				appendString(rb.Field(spanColAttributes), attributes)
+				appendString(rb.Field(spanColServiceName), service)
+				appendString(rb.Field(spanColResourceAttributes), resourceAttributes)
+				appendString(rb.Field(spanColScopeName), ils.Scope().Name())
+				appendString(rb.Field(spanColScopeVersion), ils.Scope().Version())
+				appendString(rb.Field(spanColEvents), events)
+				appendString(rb.Field(spanColLinks), links)
+				if err := b.next(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return b.flush()
+}
+
+// metricRow holds the columns of a data point in metricsArrowSchema. Nil
+// fields are written as nulls.
+type metricRow struct {
+	timestamp              pcommon.Timestamp
+	name                   string
+	metricType             string
+	valueType              *string
+	value                  *float64
+	count                  *uint64
+	sum                    *float64
+	min                    *float64
+	max                    *float64
+	bucketCounts           []uint64
+	explicitBounds         []float64
+	scale                  *int32
+	zeroCount              *uint64
+	positiveOffset         *int32
+	positiveBucketCounts   []uint64
+	negativeOffset         *int32
+	negativeBucketCounts   []uint64
+	quantiles              pmetric.SummaryDataPointValueAtQuantileSlice
+	hasQuantiles           bool
+	aggregationTemporality *string
+	isMonotonic            *bool
+	startTimestamp         pcommon.Timestamp
+	unit                   string
+	description            string
+	attributes             string
+	resourceAttributes     string
+	scopeName              string
+	scopeVersion           string
+	flags                  uint32
+	exemplars              string
+}
+
+// metricsToArrowRecords converts metrics into records of metricsArrowSchema,
+// or of a dictionary encoded variant of it, see logsToArrowRecords.
+func metricsToArrowRecords(mem memory.Allocator, schema *arrow.Schema, md pmetric.Metrics, maxRows int, write func(rec arrow.Record) error) error {
+	b := newRecordBatcher(mem, schema, maxRows, write)
+	defer b.release()
+	rb := b.rb
+
+	metrics := md.ResourceMetrics()
+	for i := 0; i < metrics.Len(); i++ {
+		resourceAttributes, err := attributesToJSONString(metrics.At(i).Resource().Attributes())
+		if err != nil {
+			return fmt.Errorf("failed to serialize resource attributes: %w", err)
+		}
+		ilms := metrics.At(i).ScopeMetrics()
+		for j := 0; j < ilms.Len(); j++ {
+			scope := ilms.At(j).Scope()
+			metrics := ilms.At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				rows, err := metricToArrowRows(metrics.At(k))
+				if err != nil {
+					return err
+				}
+				for _, row := range rows {
+					row.resourceAttributes = resourceAttributes
+					row.scopeName = scope.Name()
+					row.scopeVersion = scope.Version()
+					appendMetricRow(rb, row)
+					if err := b.next(); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return b.flush()
+}
+
+// metricToArrowRows converts every data point of a metric into a metricRow.
+// The resource and scope columns are left for the caller to fill.
+func metricToArrowRows(metric pmetric.Metric) ([]metricRow, error) {
+	var rows []metricRow
+	newRow := func(dp dataPoint) (metricRow, error) {
+		attributes, err := attributesToJSONString(dp.Attributes())
+		if err != nil {
+			return metricRow{}, fmt.Errorf("failed to serialize attributes: %w", err)
+		}
+		return metricRow{
+			timestamp:      dp.Timestamp(),
+			name:           metric.Name(),
+			metricType:     metric.Type().String(),
+			startTimestamp: dp.StartTimestamp(),
+			unit:           metric.Unit(),
+			description:    metric.Description(),
+			attributes:     attributes,
+			flags:          uint32(dp.Flags()),
+		}, nil
+	}
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := metric.Gauge().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			row, err := newRow(dps.At(i))
+			if err != nil {
+				return nil, err
+			}
+			setNumberValue(&row, dps.At(i))
+			row.exemplars = string(appendExemplarsJSON(nil, dps.At(i).Exemplars()))
+			rows = append(rows, row)
+		}
+	case pmetric.MetricTypeSum:
+		sum := metric.Sum()
+		dps := sum.DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			row, err := newRow(dps.At(i))
+			if err != nil {
+				return nil, err
+			}
+			setNumberValue(&row, dps.At(i))
+			row.exemplars = string(appendExemplarsJSON(nil, dps.At(i).Exemplars()))
+			row.aggregationTemporality = ptr(sum.AggregationTemporality().String())
+			row.isMonotonic = ptr(sum.IsMonotonic())
+			rows = append(rows, row)
+		}
+	case pmetric.MetricTypeHistogram:
+		histogram := metric.Histogram()
+		dps := histogram.DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			row, err := newRow(dp)
+			if err != nil {
+				return nil, err
+			}
+			row.count = ptr(dp.Count())
+			if dp.HasSum() {
+				row.sum = ptr(dp.Sum())
+			}
+			if dp.HasMin() {
+				row.min = ptr(dp.Min())
+			}
+			if dp.HasMax() {
+				row.max = ptr(dp.Max())
+			}
+			row.bucketCounts = dp.BucketCounts().AsRaw()
+			row.explicitBounds = dp.ExplicitBounds().AsRaw()
+			row.exemplars = string(appendExemplarsJSON(nil, dp.Exemplars()))
+			row.aggregationTemporality = ptr(histogram.AggregationTemporality().String())
+			rows = append(rows, row)
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		histogram := metric.ExponentialHistogram()
+		dps := histogram.DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			row, err := newRow(dp)
+			if err != nil {
+				return nil, err
+			}
+			row.count = ptr(dp.Count())
+			if dp.HasSum() {
+				row.sum = ptr(dp.Sum())
+			}
+			if dp.HasMin() {
+				row.min = ptr(dp.Min())
+			}
+			if dp.HasMax() {
+				row.max = ptr(dp.Max())
+			}
+			row.scale = ptr(dp.Scale())
+			row.zeroCount = ptr(dp.ZeroCount())
+			row.positiveOffset = ptr(dp.Positive().Offset())
+			row.positiveBucketCounts = dp.Positive().BucketCounts().AsRaw()
+			row.negativeOffset = ptr(dp.Negative().Offset())
+			row.negativeBucketCounts = dp.Negative().BucketCounts().AsRaw()
+			row.exemplars = string(appendExemplarsJSON(nil, dp.Exemplars()))
+			row.aggregationTemporality = ptr(histogram.AggregationTemporality().String())
+			rows = append(rows, row)
+		}
+	case pmetric.MetricTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			row, err := newRow(dp)
+			if err != nil {
+				return nil, err
+			}
+			row.count = ptr(dp.Count())
+			row.sum = ptr(dp.Sum())
+			row.quantiles = dp.QuantileValues()
+			row.hasQuantiles = true
+			rows = append(rows, row)
+		}
+	case pmetric.MetricTypeEmpty:
+		// A metric without data has no data points to write.
+	default:
+		return nil, fmt.Errorf("unsupported metric type: %s", metric.Type())
+	}
+	return rows, nil
+}
+
+// setNumberValue sets the value columns of a Gauge or Sum data point.
+func setNumberValue(row *metricRow, dp pmetric.NumberDataPoint) {
+	switch dp.ValueType() {
+	case pmetric.NumberDataPointValueTypeInt:
+		row.valueType = ptr(dp.ValueType().String())
+		row.value = ptr(float64(dp.IntValue()))
+	case pmetric.NumberDataPointValueTypeDouble:
+		row.valueType = ptr(dp.ValueType().String())
+		row.value = ptr(dp.DoubleValue())
+	}
+}
+
+// appendMetricRow appends a row to every column of metricsArrowSchema.
+func appendMetricRow(rb *array.RecordBuilder, row metricRow) {
+	appendTimestamp(rb.Field(metricColTimestamp), row.timestamp)
+	appendString(rb.Field(metricColName), row.name)
+	appendString(rb.Field(metricColType), row.metricType)
+	appendOptionalString(rb.Field(metricColValueType), row.valueType)
+	appendOptional(rb.Field(metricColValue).(*array.Float64Builder), row.value)
+	appendOptional(rb.Field(metricColCount).(*array.Uint64Builder), row.count)
+	appendOptional(rb.Field(metricColSum).(*array.Float64Builder), row.sum)
+	appendOptional(rb.Field(metricColMin).(*array.Float64Builder), row.min)
+	appendOptional(rb.Field(metricColMax).(*array.Float64Builder), row.max)
+	appendUint64List(rb.Field(metricColBucketCounts), row.bucketCounts)
+	appendFloat64List(rb.Field(metricColExplicitBounds), row.explicitBounds)
+	appendOptional(rb.Field(metricColScale).(*array.Int32Builder), row.scale)
+	appendOptional(rb.Field(metricColZeroCount).(*array.Uint64Builder), row.zeroCount)
+	appendOptional(rb.Field(metricColPositiveOffset).(*array.Int32Builder), row.positiveOffset)
+	appendUint64List(rb.Field(metricColPositiveBucketCounts), row.positiveBucketCounts)
+	appendOptional(rb.Field(metricColNegativeOffset).(*array.Int32Builder), row.negativeOffset)
+	appendUint64List(rb.Field(metricColNegativeBucketCounts), row.negativeBucketCounts)
+	appendQuantiles(rb.Field(metricColQuantiles), row.quantiles, row.hasQuantiles)
+	appendOptionalString(rb.Field(metricColAggregationTemporality), row.aggregationTemporality)
+	appendOptional(rb.Field(metricColIsMonotonic).(*array.BooleanBuilder), row.isMonotonic)
+	appendTimestamp(rb.Field(metricColStartTimestamp), row.startTimestamp)
+	appendString(rb.Field(metricColUnit), row.unit)
+	appendString(rb.Field(metricColDescription), row.description)
+	appendString(rb.Field(metricColAttributes), row.attributes)
+	appendString(rb.Field(metricColResourceAttributes), row.resourceAttributes)
+	appendString(rb.Field(metricColScopeName), row.scopeName)
+	appendString(rb.Field(metricColScopeVersion), row.scopeVersion)
+	rb.Field(metricColFlags).(*array.Uint32Builder).Append(row.flags)
+	appendString(rb.Field(metricColExemplars), row.exemplars)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// appendTimestamp appends a timestamp, unset timestamps being null.
+func appendTimestamp(b array.Builder, ts pcommon.Timestamp) {
+	tb := b.(*array.TimestampBuilder)
+	if ts == 0 {
+		tb.AppendNull()
+		return
+	}
+	tb.Append(arrow.Timestamp(ts))
+}
+
+// appendString appends a string to a plain or dictionary encoded string column.
+// bodyToJSONString returns the typed OTLP/JSON encoding of a log body, e.g.
+// {"intValue":"42"}, or an empty string for an empty body.
+func bodyToJSONString(body pcommon.Value) string {
+	if body.Type() == pcommon.ValueTypeEmpty {
+		return ""
+	}
+	return string(appendValueJSON(nil, body))
+}
+
+func appendString(b array.Builder, s string) {
+	switch b := b.(type) {
+	case *array.BinaryDictionaryBuilder:
+		// Inserting into the dictionary only fails for non-binary value types.
+		_ = b.AppendString(s)
+	default:
+		b.(*array.StringBuilder).Append(s)
+	}
+}
+
+func appendOptionalString(b array.Builder, s *string) {
+	if s == nil {
+		b.AppendNull()
+		return
+	}
+	appendString(b, *s)
+}
+
+// appendOptional appends a value to a primitive builder, nil being null.
+func appendOptional[T any](b interface {
+	Append(T)
+	AppendNull()
+}, v *T) {
+	if v == nil {
+		b.AppendNull()
+		return
+	}
+	b.Append(*v)
+}
+
+func appendUint64List(b array.Builder, values []uint64) {
+	lb := b.(*array.ListBuilder)
+	if values == nil {
+		lb.AppendNull()
+		return
+	}
+	lb.Append(true)
+	lb.ValueBuilder().(*array.Uint64Builder).AppendValues(values, nil)
+}
+
+func appendFloat64List(b array.Builder, values []float64) {
+	lb := b.(*array.ListBuilder)
+	if values == nil {
+		lb.AppendNull()
+		return
+	}
+	lb.Append(true)
+	lb.ValueBuilder().(*array.Float64Builder).AppendValues(values, nil)
+}
+
+func appendQuantiles(b array.Builder, quantiles pmetric.SummaryDataPointValueAtQuantileSlice, ok bool) {
+	lb := b.(*array.ListBuilder)
+	if !ok {
+		lb.AppendNull()
+		return
+	}
+	lb.Append(true)
+	sb := lb.ValueBuilder().(*array.StructBuilder)
+	for i := 0; i < quantiles.Len(); i++ {
+		q := quantiles.At(i)
+		sb.Append(true)
+		sb.FieldBuilder(0).(*array.Float64Builder).Append(q.Quantile())
+		sb.FieldBuilder(1).(*array.Float64Builder).Append(q.Value())
+	}
+}
@@ -0,0 +1,253 @@
+package marshaler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/ipc"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/apache/arrow/go/v13/parquet"
+	"github.com/apache/arrow/go/v13/parquet/pqarrow"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Columnar batches are read back by converting every row into a record of
+// the matching CSV layout, which is then grouped into resources, scopes and
+// metrics as the CSV unmarshaler does. columnarCSV parses those records:
+// timestamps are converted to nanoseconds since the epoch, and the attribute
+// and body columns already hold the typed OTLP/JSON encoding.
+var columnarCSV = NewCSVMarshalerWithConfig(CSVConfig{TimestampFormat: TimestampFormatUnixNano})
+
+// arrowRecords hands every record of a batch to a callback. The records are
+// only valid during the callback.
+type arrowRecords func(yield func(rec arrow.Record) error) error
+
+// UnmarshalLogs converts a Parquet file written by MarshalLogs back into logs.
+func (m ParquetMarshaler) UnmarshalLogs(buf []byte) (plog.Logs, error) {
+	records, err := arrowRecordsToCSV(parquetRecords(buf), logsCSVHeader)
+	if err != nil {
+		return plog.NewLogs(), err
+	}
+	return columnarCSV.logsFromCSVRecords(records)
+}
+
+// UnmarshalMetrics converts a Parquet file written by MarshalMetrics back
+// into metrics.
+func (m ParquetMarshaler) UnmarshalMetrics(buf []byte) (pmetric.Metrics, error) {
+	records, err := arrowRecordsToCSV(parquetRecords(buf), metricsCSVHeader)
+	if err != nil {
+		return pmetric.NewMetrics(), err
+	}
+	return columnarCSV.metricsFromCSVRecords(intMetricValues(records))
+}
+
+// UnmarshalTraces converts a Parquet file written by MarshalTraces back into
+// traces.
+func (m ParquetMarshaler) UnmarshalTraces(buf []byte) (ptrace.Traces, error) {
+	records, err := arrowRecordsToCSV(parquetRecords(buf), tracesCSVHeader)
+	if err != nil {
+		return ptrace.NewTraces(), err
+	}
+	return columnarCSV.tracesFromCSVRecords(records)
+}
+
+// UnmarshalLogs converts an Arrow IPC batch written by MarshalLogs back into
+// logs. Both IPC formats are read, whatever the configured format.
+func (m ArrowIPCMarshaler) UnmarshalLogs(buf []byte) (plog.Logs, error) {
+	records, err := arrowRecordsToCSV(arrowIPCRecords(buf), logsCSVHeader)
+	if err != nil {
+		return plog.NewLogs(), err
+	}
+	return columnarCSV.logsFromCSVRecords(records)
+}
+
+// UnmarshalMetrics converts an Arrow IPC batch written by MarshalMetrics back
+// into metrics, see UnmarshalLogs.
+func (m ArrowIPCMarshaler) UnmarshalMetrics(buf []byte) (pmetric.Metrics, error) {
+	records, err := arrowRecordsToCSV(arrowIPCRecords(buf), metricsCSVHeader)
+	if err != nil {
+		return pmetric.NewMetrics(), err
+	}
+	return columnarCSV.metricsFromCSVRecords(intMetricValues(records))
+}
+
+// UnmarshalTraces converts an Arrow IPC batch written by MarshalTraces back
+// into traces, see UnmarshalLogs.
+func (m ArrowIPCMarshaler) UnmarshalTraces(buf []byte) (ptrace.Traces, error) {
+	records, err := arrowRecordsToCSV(arrowIPCRecords(buf), tracesCSVHeader)
+	if err != nil {
+		return ptrace.NewTraces(), err
+	}
+	return columnarCSV.tracesFromCSVRecords(records)
+}
+
+// parquetRecords reads the records of a Parquet file.
+func parquetRecords(buf []byte) arrowRecords {
+	return func(yield func(rec arrow.Record) error) error {
+		table, err := pqarrow.ReadTable(context.Background(), bytes.NewReader(buf),
+			parquet.NewReaderProperties(memory.DefaultAllocator), pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+		if err != nil {
+			return fmt.Errorf("failed to read parquet: %w", err)
+		}
+		defer table.Release()
+
+		reader := array.NewTableReader(table, table.NumRows())
+		defer reader.Release()
+		for reader.Next() {
+			if err := yield(reader.Record()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// arrowIPCRecords reads the records of an Arrow IPC batch, telling the file
+// format from the stream format by its leading magic bytes.
+func arrowIPCRecords(buf []byte) arrowRecords {
+	return func(yield func(rec arrow.Record) error) error {
+		if bytes.HasPrefix(buf, ipc.Magic) {
+			reader, err := ipc.NewFileReader(bytes.NewReader(buf))
+			if err != nil {
+				return fmt.Errorf("failed to read arrow ipc file: %w", err)
+			}
+			defer reader.Close()
+			for i := 0; i < reader.NumRecords(); i++ {
+				rec, err := reader.Record(i)
+				if err != nil {
+					return fmt.Errorf("failed to read record: %w", err)
+				}
+				if err := yield(rec); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		reader, err := ipc.NewReader(bytes.NewReader(buf))
+		if err != nil {
+			return fmt.Errorf("failed to read arrow ipc stream: %w", err)
+		}
+		defer reader.Release()
+		for reader.Next() {
+			if err := yield(reader.Record()); err != nil {
+				return err
+			}
+		}
+		if err := reader.Err(); err != nil {
+			return fmt.Errorf("failed to read record: %w", err)
+		}
+		return nil
+	}
+}
+
+// arrowRecordsToCSV converts every row of the records into a record of the
+// given CSV layout, matching columns by name. Nulls become empty strings and
+// columns missing from the records are left empty.
+func arrowRecordsToCSV(records arrowRecords, layout []string) ([][]string, error) {
+	var lines [][]string
+	err := records(func(rec arrow.Record) error {
+		columns, err := columnIndexes(layout, fieldNames(rec.Schema()))
+		if err != nil {
+			return err
+		}
+		first := len(lines)
+		for i := 0; i < int(rec.NumRows()); i++ {
+			lines = append(lines, make([]string, len(layout)))
+		}
+		for j, col := range columns {
+			values := rec.Column(j)
+			for i := 0; i < values.Len(); i++ {
+				s, err := arrowValueToCSV(values, i)
+				if err != nil {
+					return fmt.Errorf("failed to read column %s: %w", layout[col], err)
+				}
+				lines[first+i][col] = s
+			}
+		}
+		return nil
+	})
+	return lines, err
+}
+
+func fieldNames(schema *arrow.Schema) []string {
+	names := make([]string, schema.NumFields())
+	for i, field := range schema.Fields() {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// arrowValueToCSV formats a value as the CSV layouts write it: lists are
+// separated by listSeparator and the fields of a struct, such as a quantile
+// and its value, by ':'.
+func arrowValueToCSV(values arrow.Array, i int) (string, error) {
+	if values.IsNull(i) {
+		return "", nil
+	}
+	switch values := values.(type) {
+	case *array.String:
+		return values.Value(i), nil
+	case *array.Dictionary:
+		return arrowValueToCSV(values.Dictionary(), values.GetValueIndex(i))
+	case *array.Timestamp:
+		unit := values.DataType().(*arrow.TimestampType).Unit
+		return strconv.FormatInt(values.Value(i).ToTime(unit).UnixNano(), 10), nil
+	case *array.Int32:
+		return strconv.FormatInt(int64(values.Value(i)), 10), nil
+	case *array.Int64:
+		return strconv.FormatInt(values.Value(i), 10), nil
+	case *array.Uint32:
+		return strconv.FormatUint(uint64(values.Value(i)), 10), nil
+	case *array.Uint64:
+		return strconv.FormatUint(values.Value(i), 10), nil
+	case *array.Float64:
+		return formatFloat(values.Value(i)), nil
+	case *array.Boolean:
+		return strconv.FormatBool(values.Value(i)), nil
+	case *array.List:
+		start, end := values.ValueOffsets(i)
+		elements := make([]string, 0, end-start)
+		for k := start; k < end; k++ {
+			s, err := arrowValueToCSV(values.ListValues(), int(k))
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, s)
+		}
+		return strings.Join(elements, listSeparator), nil
+	case *array.Struct:
+		fields := make([]string, values.NumField())
+		for k := range fields {
+			s, err := arrowValueToCSV(values.Field(k), i)
+			if err != nil {
+				return "", err
+			}
+			fields[k] = s
+		}
+		return strings.Join(fields, ":"), nil
+	default:
+		return "", fmt.Errorf("unsupported type: %s", values.DataType())
+	}
+}
+
+// intMetricValues rewrites the value of Int data points, stored in the double
+// value column, as the integer the metrics CSV layout expects.
+func intMetricValues(records [][]string) [][]string {
+	for _, record := range records {
+		if record[metricColValueType] != pmetric.NumberDataPointValueTypeInt.String() || record[metricColValue] == "" {
+			continue
+		}
+		if f, err := strconv.ParseFloat(record[metricColValue], 64); err == nil {
+			record[metricColValue] = strconv.FormatInt(int64(f), 10)
+		}
+	}
+	return records
+}
@@ -0,0 +1,400 @@
+package marshaler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// AttributeEncoding selects how an attribute map, or a log body, is written
+// into a single column.
+type AttributeEncoding string
+
+const (
+	// AttributeEncodingTyped writes attributes as the OTLP/JSON representation
+	// of a list of key-values, e.g. [{"key":"k","value":{"intValue":"1"}}].
+	// The type of every value is kept, including nested maps, arrays and
+	// bytes, so the column can always be read back.
+	AttributeEncodingTyped AttributeEncoding = "typed"
+
+	// AttributeEncodingFlat writes attributes as 'k=v; k=v' pairs. Every value
+	// is read back as a string, and keys or values containing ';' or '=' do
+	// not survive a round-trip.
+	AttributeEncodingFlat AttributeEncoding = "flat"
+)
+
+// encodeAttributes serializes attributes into a single string for CSV.
+func (m CSVMarshaler) encodeAttributes(attrs pcommon.Map) (string, error) {
+	b, err := m.appendAttributes(nil, attrs)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// appendAttributes appends the encoding of attributes to dst, nothing for an
+// empty map.
+func (m CSVMarshaler) appendAttributes(dst []byte, attrs pcommon.Map) ([]byte, error) {
+	if m.config.AttributeEncoding == AttributeEncodingFlat {
+		return appendFlatAttributes(dst, attrs)
+	}
+	return appendAttributesJSON(dst, attrs), nil
+}
+
+// decodeAttributes parses a string written by encodeAttributes into dest.
+func (m CSVMarshaler) decodeAttributes(s string, dest pcommon.Map) error {
+	if m.config.AttributeEncoding == AttributeEncodingFlat {
+		attributesFromCSVString(s).CopyTo(dest)
+		return nil
+	}
+	return attributesFromJSONString(s, dest)
+}
+
+// appendBody appends the encoding of a log body to dst, nothing for an empty
+// body. The typed encoding writes the OTLP/JSON representation of the value,
+// e.g. {"stringValue":"hello"}, so that map, array and bytes bodies are read
+// back with their type. The flat encoding writes the body as a string.
+func (m CSVMarshaler) appendBody(dst []byte, body pcommon.Value) []byte {
+	switch {
+	case body.Type() == pcommon.ValueTypeEmpty:
+		return dst
+	case m.config.AttributeEncoding == AttributeEncodingFlat:
+		return append(dst, body.AsString()...)
+	}
+	return appendValueJSON(dst, body)
+}
+
+// decodeBody parses a body written by appendBody into dest.
+func (m CSVMarshaler) decodeBody(s string, dest pcommon.Value) error {
+	switch {
+	case s == "":
+		return nil
+	case m.config.AttributeEncoding == AttributeEncodingFlat:
+		dest.SetStr(s)
+		return nil
+	}
+	var jv jsonAnyValue
+	if err := json.Unmarshal([]byte(s), &jv); err != nil {
+		return err
+	}
+	return valueFromJSON(jv, dest)
+}
+
+// jsonKeyValue is the OTLP/JSON representation of a key-value pair.
+type jsonKeyValue struct {
+	Key   string       `json:"key"`
+	Value jsonAnyValue `json:"value"`
+}
+
+// jsonAnyValue is the OTLP/JSON representation of a value. Exactly one
+// field is set, none for an empty value.
+type jsonAnyValue struct {
+	StringValue *string          `json:"stringValue,omitempty"`
+	BoolValue   *bool            `json:"boolValue,omitempty"`
+	IntValue    *string          `json:"intValue,omitempty"`
+	DoubleValue json.RawMessage  `json:"doubleValue,omitempty"`
+	BytesValue  *string          `json:"bytesValue,omitempty"`
+	ArrayValue  *jsonArrayValue  `json:"arrayValue,omitempty"`
+	KvlistValue *jsonKvlistValue `json:"kvlistValue,omitempty"`
+}
+
+type jsonArrayValue struct {
+	Values []jsonAnyValue `json:"values"`
+}
+
+type jsonKvlistValue struct {
+	Values []jsonKeyValue `json:"values"`
+}
+
+// attributesToJSONString serializes attributes as an OTLP/JSON key-value
+// list. An empty map is written as an empty string.
+func attributesToJSONString(attrs pcommon.Map) (string, error) {
+	return string(appendAttributesJSON(nil, attrs)), nil
+}
+
+// attributesFromJSONString parses an OTLP/JSON key-value list into dest.
+func attributesFromJSONString(s string, dest pcommon.Map) error {
+	if s == "" {
+		return nil
+	}
+	var kvs []jsonKeyValue
+	if err := json.Unmarshal([]byte(s), &kvs); err != nil {
+		return err
+	}
+	return mapFromJSON(kvs, dest)
+}
+
+// appendAttributesJSON appends attributes as an OTLP/JSON key-value list,
+// nothing for an empty map. The output is the one encoding/json writes for
+// the jsonKeyValue representation, without building it.
+func appendAttributesJSON(dst []byte, attrs pcommon.Map) []byte {
+	if attrs.Len() == 0 {
+		return dst
+	}
+	return appendKeyValuesJSON(dst, attrs)
+}
+
+// appendKeyValuesJSON appends a map as a JSON array of jsonKeyValue.
+func appendKeyValuesJSON(dst []byte, attrs pcommon.Map) []byte {
+	dst = append(dst, '[')
+	first := true
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		if !first {
+			dst = append(dst, ',')
+		}
+		first = false
+		dst = append(dst, `{"key":`...)
+		dst = appendJSONString(dst, k)
+		dst = append(dst, `,"value":`...)
+		dst = appendValueJSON(dst, v)
+		dst = append(dst, '}')
+		return true
+	})
+	return append(dst, ']')
+}
+
+// appendValueJSON appends a value as a JSON jsonAnyValue.
+func appendValueJSON(dst []byte, v pcommon.Value) []byte {
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		dst = append(dst, `{"stringValue":`...)
+		dst = appendJSONString(dst, v.Str())
+	case pcommon.ValueTypeBool:
+		dst = append(dst, `{"boolValue":`...)
+		dst = strconv.AppendBool(dst, v.Bool())
+	case pcommon.ValueTypeInt:
+		dst = append(dst, `{"intValue":"`...)
+		dst = strconv.AppendInt(dst, v.Int(), 10)
+		dst = append(dst, '"')
+	case pcommon.ValueTypeDouble:
+		dst = append(dst, `{"doubleValue":`...)
+		dst = appendDoubleJSON(dst, v.Double())
+	case pcommon.ValueTypeBytes:
+		dst = append(dst, `{"bytesValue":"`...)
+		dst = base64.StdEncoding.AppendEncode(dst, v.Bytes().AsRaw())
+		dst = append(dst, '"')
+	case pcommon.ValueTypeSlice:
+		dst = append(dst, `{"arrayValue":{"values":[`...)
+		slice := v.Slice()
+		for i := 0; i < slice.Len(); i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendValueJSON(dst, slice.At(i))
+		}
+		dst = append(dst, "]}"...)
+	case pcommon.ValueTypeMap:
+		dst = append(dst, `{"kvlistValue":{"values":`...)
+		dst = appendKeyValuesJSON(dst, v.Map())
+		dst = append(dst, '}')
+	default:
+		dst = append(dst, '{')
+	}
+	return append(dst, '}')
+}
+
+// appendDoubleJSON appends a double as a JSON number, or as the "NaN",
+// "Infinity" and "-Infinity" strings OTLP/JSON uses for special values.
+func appendDoubleJSON(dst []byte, f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(dst, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(dst, `"Infinity"`...)
+	case math.IsInf(f, -1):
+		return append(dst, `"-Infinity"`...)
+	default:
+		return strconv.AppendFloat(dst, f, 'g', -1, 64)
+	}
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends a JSON string escaped as encoding/json does:
+// HTML characters, U+2028 and U+2029 are escaped and invalid UTF-8 is
+// replaced by U+FFFD.
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+func doubleFromJSON(raw json.RawMessage) (float64, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		switch s {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		default:
+			return strconv.ParseFloat(s, 64)
+		}
+	}
+	var f float64
+	err := json.Unmarshal(raw, &f)
+	return f, err
+}
+
+func mapFromJSON(kvs []jsonKeyValue, dest pcommon.Map) error {
+	dest.EnsureCapacity(len(kvs))
+	for _, kv := range kvs {
+		if err := valueFromJSON(kv.Value, dest.PutEmpty(kv.Key)); err != nil {
+			return fmt.Errorf("attribute %q: %w", kv.Key, err)
+		}
+	}
+	return nil
+}
+
+func valueFromJSON(jv jsonAnyValue, dest pcommon.Value) error {
+	switch {
+	case jv.StringValue != nil:
+		dest.SetStr(*jv.StringValue)
+	case jv.BoolValue != nil:
+		dest.SetBool(*jv.BoolValue)
+	case jv.IntValue != nil:
+		i, err := strconv.ParseInt(*jv.IntValue, 10, 64)
+		if err != nil {
+			return err
+		}
+		dest.SetInt(i)
+	case jv.DoubleValue != nil:
+		f, err := doubleFromJSON(jv.DoubleValue)
+		if err != nil {
+			return err
+		}
+		dest.SetDouble(f)
+	case jv.BytesValue != nil:
+		b, err := base64.StdEncoding.DecodeString(*jv.BytesValue)
+		if err != nil {
+			return err
+		}
+		dest.SetEmptyBytes().FromRaw(b)
+	case jv.ArrayValue != nil:
+		slice := dest.SetEmptySlice()
+		slice.EnsureCapacity(len(jv.ArrayValue.Values))
+		for _, v := range jv.ArrayValue.Values {
+			if err := valueFromJSON(v, slice.AppendEmpty()); err != nil {
+				return err
+			}
+		}
+	case jv.KvlistValue != nil:
+		return mapFromJSON(jv.KvlistValue.Values, dest.SetEmptyMap())
+	}
+	return nil
+}
+
+// attributesFromCSVString parses a semicolon-separated key-value string and returns a map.
+func attributesFromCSVString(csvStr string) pcommon.Map {
+	attrs := pcommon.NewMap()
+	if csvStr == "" {
+		return attrs
+	}
+
+	pairs := strings.Split(csvStr, ";")
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue // Skip malformed pairs
+		}
+		key := strings.TrimSpace(kv[0])
+		value := strings.TrimSpace(kv[1])
+		attrs.PutStr(key, value)
+	}
+
+	return attrs
+}
+
+// appendFlatAttributes appends attributes as 'k=v; k=v' pairs.
+func appendFlatAttributes(dst []byte, attrs pcommon.Map) ([]byte, error) {
+	var err error
+	first := true
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		if !first {
+			dst = append(dst, "; "...)
+		}
+		first = false
+		dst = append(dst, k...)
+		dst = append(dst, '=')
+		dst, err = appendFlatValue(dst, v)
+		if err != nil {
+			err = fmt.Errorf("attribute %q: %w", k, err)
+			return false
+		}
+		return true
+	})
+	return dst, err
+}
+
+// appendFlatValue appends the string representation of a value. Doubles
+// have six decimals, maps and slices are written as JSON and bytes as base64.
+func appendFlatValue(dst []byte, v pcommon.Value) ([]byte, error) {
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		return append(dst, v.Str()...), nil
+	case pcommon.ValueTypeBool:
+		return strconv.AppendBool(dst, v.Bool()), nil
+	case pcommon.ValueTypeInt:
+		return strconv.AppendInt(dst, v.Int(), 10), nil
+	case pcommon.ValueTypeDouble:
+		return strconv.AppendFloat(dst, v.Double(), 'f', 6, 64), nil
+	case pcommon.ValueTypeMap, pcommon.ValueTypeSlice, pcommon.ValueTypeBytes, pcommon.ValueTypeEmpty:
+		return append(dst, v.AsString()...), nil
+	default:
+		return dst, errors.New("unsupported attribute type")
+	}
+}
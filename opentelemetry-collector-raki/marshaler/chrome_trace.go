@@ -0,0 +1,331 @@
+package marshaler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// ChromeTraceMarshaler writes traces in the Chrome Trace Event JSON format,
+// which Perfetto and chrome://tracing open directly:
+//
+//	{"traceEvents":[...],"displayTimeUnit":"ms"}
+//
+// Every service becomes a process and every resource of a service one of its
+// threads, named by process_name and thread_name metadata events. Spans are
+// complete events (ph X) on the thread of their resource, span events are
+// instant events (ph i), and every span whose parent is in the same batch is
+// linked to it by a flow event (ph s on the parent, ph f on the child).
+// Timestamps and durations are in microseconds.
+type ChromeTraceMarshaler struct{}
+
+func NewChromeTraceMarshaler() ChromeTraceMarshaler {
+	return ChromeTraceMarshaler{}
+}
+
+// chromeTraceEvent is a single entry of the traceEvents array.
+type chromeTraceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase string         `json:"ph"`
+	Ts    json.Number    `json:"ts,omitempty"`
+	Dur   json.Number    `json:"dur,omitempty"`
+	Pid   int            `json:"pid"`
+	Tid   int            `json:"tid"`
+	ID    string         `json:"id,omitempty"`
+	Bind  string         `json:"bp,omitempty"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// chromeThread identifies the thread a resource is drawn on.
+type chromeThread struct {
+	pid, tid int
+}
+
+// chromeSpan locates a span written by the marshaler, so its children can
+// point flow events to it.
+type chromeSpan struct {
+	chromeThread
+	start, end pcommon.Timestamp
+}
+
+// spanKey identifies a span in a batch.
+type spanKey struct {
+	traceID pcommon.TraceID
+	spanID  pcommon.SpanID
+}
+
+// MarshalTraces converts OpenTelemetry traces into Chrome Trace Event JSON.
+func (m ChromeTraceMarshaler) MarshalTraces(td ptrace.Traces) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteTraces(&buf, td); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTraces writes OpenTelemetry traces into w as Chrome Trace Event JSON,
+// one event at a time.
+func (m ChromeTraceMarshaler) WriteTraces(w io.Writer, td ptrace.Traces) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`{"traceEvents":[`)
+	first := true
+	write := func(event chromeTraceEvent) error {
+		b, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal trace event %s: %w", event.Name, err)
+		}
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		bw.WriteByte('\n')
+		_, err = bw.Write(b)
+		return err
+	}
+
+	// The threads and spans are assigned up front, so that metadata events
+	// come first and children can find parents written after them.
+	threads := make([]chromeThread, td.ResourceSpans().Len())
+	spans := map[spanKey]chromeSpan{}
+	pids := map[string]int{}
+	tids := map[string]chromeThread{}
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		resource := rss.At(i).Resource().Attributes()
+		process := serviceName(resource)
+		if process == "" {
+			process = "unknown_service"
+		}
+		pid, ok := pids[process]
+		if !ok {
+			pid = len(pids) + 1
+			pids[process] = pid
+			if err := write(chromeMetadataEvent("process_name", pid, 0, process)); err != nil {
+				return err
+			}
+		}
+		key, err := attributesToJSONString(resource)
+		if err != nil {
+			return fmt.Errorf("failed to marshal resource attributes: %w", err)
+		}
+		thread, ok := tids[process+key]
+		if !ok {
+			thread = chromeThread{pid: pid, tid: countThreads(tids, pid) + 1}
+			tids[process+key] = thread
+			if err := write(chromeMetadataEvent("thread_name", pid, thread.tid, chromeThreadName(resource, thread.tid))); err != nil {
+				return err
+			}
+		}
+		threads[i] = thread
+
+		ilss := rss.At(i).ScopeSpans()
+		for j := 0; j < ilss.Len(); j++ {
+			ss := ilss.At(j).Spans()
+			for k := 0; k < ss.Len(); k++ {
+				s := ss.At(k)
+				spans[spanKey{s.TraceID(), s.SpanID()}] = chromeSpan{thread, s.StartTimestamp(), s.EndTimestamp()}
+			}
+		}
+	}
+
+	for i := 0; i < rss.Len(); i++ {
+		thread := threads[i]
+		ilss := rss.At(i).ScopeSpans()
+		for j := 0; j < ilss.Len(); j++ {
+			scope := ilss.At(j).Scope().Name()
+			ss := ilss.At(j).Spans()
+			for k := 0; k < ss.Len(); k++ {
+				s := ss.At(k)
+				duration, _ := spanDuration(s)
+				if err := write(chromeTraceEvent{
+					Name:  s.Name(),
+					Cat:   scope,
+					Phase: "X",
+					Ts:    chromeMicroseconds(uint64(s.StartTimestamp())),
+					Dur:   chromeMicroseconds(uint64(duration)),
+					Pid:   thread.pid,
+					Tid:   thread.tid,
+					Args:  chromeSpanArgs(s),
+				}); err != nil {
+					return err
+				}
+
+				events := s.Events()
+				for l := 0; l < events.Len(); l++ {
+					event := events.At(l)
+					if err := write(chromeTraceEvent{
+						Name:  event.Name(),
+						Cat:   scope,
+						Phase: "i",
+						Ts:    chromeMicroseconds(uint64(event.Timestamp())),
+						Pid:   thread.pid,
+						Tid:   thread.tid,
+						Scope: "t",
+						Args:  chromeArgs(event.Attributes()),
+					}); err != nil {
+						return err
+					}
+				}
+
+				parent, ok := spans[spanKey{s.TraceID(), s.ParentSpanID()}]
+				if s.ParentSpanID().IsEmpty() || !ok {
+					continue
+				}
+				// The flow starts inside the parent slice, at the time the
+				// child started, and binds to the enclosing child slice.
+				start := s.StartTimestamp()
+				if start < parent.start {
+					start = parent.start
+				} else if parent.end > parent.start && start > parent.end {
+					start = parent.end
+				}
+				id := s.TraceID().String() + s.SpanID().String()
+				if err := write(chromeTraceEvent{
+					Name:  "parent",
+					Cat:   "flow",
+					Phase: "s",
+					Ts:    chromeMicroseconds(uint64(start)),
+					Pid:   parent.pid,
+					Tid:   parent.tid,
+					ID:    id,
+				}); err != nil {
+					return err
+				}
+				if err := write(chromeTraceEvent{
+					Name:  "parent",
+					Cat:   "flow",
+					Phase: "f",
+					Ts:    chromeMicroseconds(uint64(s.StartTimestamp())),
+					Pid:   thread.pid,
+					Tid:   thread.tid,
+					ID:    id,
+					Bind:  "e",
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	bw.WriteString("\n],\"displayTimeUnit\":\"ms\"}\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write trace events: %w", err)
+	}
+	return nil
+}
+
+// chromeMetadataEvent names a process or a thread.
+func chromeMetadataEvent(name string, pid, tid int, value string) chromeTraceEvent {
+	return chromeTraceEvent{
+		Name:  name,
+		Phase: "M",
+		Pid:   pid,
+		Tid:   tid,
+		Args:  map[string]any{"name": value},
+	}
+}
+
+// countThreads returns the number of threads assigned to a process.
+func countThreads(threads map[string]chromeThread, pid int) int {
+	n := 0
+	for _, thread := range threads {
+		if thread.pid == pid {
+			n++
+		}
+	}
+	return n
+}
+
+// chromeThreadName names the thread of a resource after its service instance
+// or host, falling back to its number.
+func chromeThreadName(resource pcommon.Map, tid int) string {
+	for _, key := range []string{"service.instance.id", "host.name"} {
+		if v, ok := resource.Get(key); ok && v.AsString() != "" {
+			return v.AsString()
+		}
+	}
+	return "resource " + strconv.Itoa(tid)
+}
+
+// chromeMicroseconds formats nanoseconds as microseconds without losing
+// precision, e.g. 1500 as 1.5.
+func chromeMicroseconds(ns uint64) json.Number {
+	us := strconv.FormatUint(ns/1000, 10)
+	if rem := ns % 1000; rem != 0 {
+		us += "." + strings.TrimRight(fmt.Sprintf("%03d", rem), "0")
+	}
+	return json.Number(us)
+}
+
+// chromeSpanArgs returns the identifiers, kind, status and attributes of a
+// span, shown when the span is selected.
+func chromeSpanArgs(s ptrace.Span) map[string]any {
+	args := map[string]any{
+		"trace_id": s.TraceID().String(),
+		"span_id":  s.SpanID().String(),
+		"kind":     s.Kind().String(),
+	}
+	if !s.ParentSpanID().IsEmpty() {
+		args["parent_span_id"] = s.ParentSpanID().String()
+	}
+	if s.Status().Code() != ptrace.StatusCodeUnset {
+		args["status_code"] = s.Status().Code().String()
+	}
+	if s.Status().Message() != "" {
+		args["status_message"] = s.Status().Message()
+	}
+	if s.Attributes().Len() > 0 {
+		args["attributes"] = chromeArgs(s.Attributes())
+	}
+	return args
+}
+
+// chromeArgs converts attributes into event arguments, nil when there are
+// none.
+func chromeArgs(attrs pcommon.Map) map[string]any {
+	if attrs.Len() == 0 {
+		return nil
+	}
+	args := make(map[string]any, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		args[k] = chromeArg(v)
+		return true
+	})
+	return args
+}
+
+// chromeArg converts a value into an argument. Doubles that JSON cannot
+// represent, such as NaN, are written as strings.
+func chromeArg(v pcommon.Value) any {
+	switch v.Type() {
+	case pcommon.ValueTypeDouble:
+		if math.IsNaN(v.Double()) || math.IsInf(v.Double(), 0) {
+			return v.AsString()
+		}
+		return v.Double()
+	case pcommon.ValueTypeMap:
+		args := chromeArgs(v.Map())
+		if args == nil {
+			return map[string]any{}
+		}
+		return args
+	case pcommon.ValueTypeSlice:
+		values := make([]any, v.Slice().Len())
+		for i := range values {
+			values[i] = chromeArg(v.Slice().At(i))
+		}
+		return values
+	default:
+		return v.AsRaw()
+	}
+}
@@ -0,0 +1,90 @@
+package marshaler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler/marshalertest"
+)
+
+func TestChromeTrace(t *testing.T) {
+	m := marshaler.NewChromeTraceTraces()
+	if _, ok := marshaler.BaseTracesMarshalers()[m.Encoding()]; !ok {
+		t.Errorf("Expected %s to be registered", m.Encoding())
+	}
+	td := testTraces()
+	child := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(1)
+	child.SetStartTimestamp(testTimestamp + 1500)
+	child.SetEndTimestamp(testTimestamp + 2e8)
+	backend := td.ResourceSpans().AppendEmpty()
+	backend.Resource().Attributes().PutStr("service.name", "atm")
+	backend.Resource().Attributes().PutStr("service.instance.id", "atm-222")
+	backend.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("orphan")
+
+	buf, err := m.Marshal(td)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	var trace struct {
+		TraceEvents []struct {
+			Name  string         `json:"name"`
+			Cat   string         `json:"cat"`
+			Phase string         `json:"ph"`
+			Ts    json.Number    `json:"ts"`
+			Dur   json.Number    `json:"dur"`
+			Pid   int            `json:"pid"`
+			Tid   int            `json:"tid"`
+			ID    string         `json:"id"`
+			Bind  string         `json:"bp"`
+			Args  map[string]any `json:"args"`
+		} `json:"traceEvents"`
+		DisplayTimeUnit string `json:"displayTimeUnit"`
+	}
+	if err := json.Unmarshal(buf, &trace); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, buf)
+	}
+
+	var got []string
+	for _, e := range trace.TraceEvents {
+		got = append(got, fmt.Sprintf("%s %s %s %s %d/%d", e.Phase, e.Name, e.Ts, e.Dur, e.Pid, e.Tid))
+	}
+	want := []string{
+		"M process_name   1/0",
+		"M thread_name   1/1",
+		"M thread_name   1/2",
+		"X GET /balance 1735787045000000 1000000 1/1",
+		"i exception 1735787045500000  1/1",
+		"X SELECT accounts 1735787045000001.5 199998.5 1/1",
+		"s parent 1735787045000001.5  1/1",
+		"f parent 1735787045000001.5  1/1",
+		"X orphan 0 0 1/2",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected events:\n%q\ngot:\n%q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected event %d to be %q, got %q", i, want[i], got[i])
+		}
+	}
+
+	events := trace.TraceEvents
+	if events[0].Args["name"] != "atm" || events[1].Args["name"] != "resource 1" || events[2].Args["name"] != "atm-222" {
+		t.Errorf("Expected process and thread names, got %v %v %v", events[0].Args, events[1].Args, events[2].Args)
+	}
+	if events[3].Cat != "tailtracer" || events[3].Args["status_code"] != "Error" || events[3].Args["attributes"].(map[string]any)["atm.id"] != float64(111) {
+		t.Errorf("Expected span category and args, got %s %v", events[3].Cat, events[3].Args)
+	}
+	if events[6].ID == "" || events[6].ID != events[7].ID || events[7].Bind != "e" {
+		t.Errorf("Expected matching flow events, got %+v %+v", events[6], events[7])
+	}
+	if trace.DisplayTimeUnit != "ms" {
+		t.Errorf("Expected displayTimeUnit ms, got %s", trace.DisplayTimeUnit)
+	}
+}
+
+func TestChromeTraceConformance(t *testing.T) {
+	marshalertest.TestTraces(t, marshaler.NewChromeTraceTraces(), nil)
+}
@@ -0,0 +1,364 @@
+package marshaler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// ColumnType is the type a mapped column is converted to.
+type ColumnType string
+
+const (
+	ColumnTypeString ColumnType = "string"
+	ColumnTypeInt    ColumnType = "int"
+	ColumnTypeDouble ColumnType = "double"
+	ColumnTypeBool   ColumnType = "bool"
+)
+
+// Kinds of column sources, see ColumnMapping.Source.
+const (
+	sourceField     = "field"
+	sourceResource  = "resource"
+	sourceAttribute = "attribute"
+	sourceBody      = "body"
+	sourceComputed  = "computed"
+)
+
+// computedDuration is the span duration in nanoseconds.
+const computedDuration = "duration"
+
+// mappingSignal describes what the columns of a signal can be mapped from.
+type mappingSignal struct {
+	// layout is the fixed layout of the signal, for field sources.
+	layout []string
+
+	// computed are the names of the computed fields of the signal.
+	computed []string
+
+	// body reports whether rows of the signal have a body.
+	body bool
+}
+
+var (
+	logsMappingSignal    = mappingSignal{layout: logsCSVHeader, body: true}
+	metricsMappingSignal = mappingSignal{layout: metricsCSVHeader}
+	tracesMappingSignal  = mappingSignal{layout: tracesCSVHeader, computed: []string{computedDuration}}
+)
+
+// ColumnMapping defines an output column and where its value comes from.
+type ColumnMapping struct {
+	// Name is the name of the column in the header.
+	Name string `mapstructure:"name"`
+
+	// Source is where the value comes from, written as kind:reference:
+	//
+	//	field:<column>     a column of the fixed layout, e.g. field:severity
+	//	resource:<key>     a resource attribute, e.g. resource:service.name
+	//	attribute:<key>    a log record, span or data point attribute, e.g. attribute:atm.id
+	//	body:<path>        a dot separated path in a map body or a JSON string
+	//	                   body, e.g. body:user.id or body:items.0.id
+	//	computed:<name>    a computed field, duration being the span duration
+	//	                   in nanoseconds
+	Source string `mapstructure:"source"`
+
+	// Type is the type the value is converted to: string, int, double or
+	// bool. Defaults to string. A value that cannot be converted is written
+	// as a null value and counted, see CSVMarshaler.ConversionFailures.
+	Type ColumnType `mapstructure:"type"`
+
+	// Default is written when the source has no value.
+	Default string `mapstructure:"default"`
+}
+
+// ColumnMappings defines the output columns of each signal. A signal without
+// mapping keeps its fixed layout.
+type ColumnMappings struct {
+	Logs    []ColumnMapping `mapstructure:"logs"`
+	Metrics []ColumnMapping `mapstructure:"metrics"`
+	Traces  []ColumnMapping `mapstructure:"traces"`
+}
+
+// Validate checks that every mapping refers to existing sources.
+func (c ColumnMappings) Validate() error {
+	if _, err := newColumnMapper(logsMappingSignal, c.Logs); err != nil {
+		return fmt.Errorf("logs: %w", err)
+	}
+	if _, err := newColumnMapper(metricsMappingSignal, c.Metrics); err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+	if _, err := newColumnMapper(tracesMappingSignal, c.Traces); err != nil {
+		return fmt.Errorf("traces: %w", err)
+	}
+	return nil
+}
+
+// mappedColumn is a ColumnMapping resolved against the layout of a signal.
+type mappedColumn struct {
+	name  string
+	kind  string
+	ref   string
+	field int
+	path  []string
+	typ   ColumnType
+	def   string
+}
+
+// columnMapper builds rows from a column mapping.
+type columnMapper struct {
+	columns []mappedColumn
+	header  []string
+
+	// parseBody reports whether a column reads a path in the body.
+	parseBody bool
+
+	// conversionFailures counts the values that could not be converted to
+	// the type of their column.
+	conversionFailures atomic.Int64
+}
+
+// newColumnMapper resolves a column mapping against a signal. An empty
+// mapping resolves to nil.
+func newColumnMapper(signal mappingSignal, mappings []ColumnMapping) (*columnMapper, error) {
+	if len(mappings) == 0 {
+		return nil, nil
+	}
+	mapper := &columnMapper{}
+	names := make(map[string]bool, len(mappings))
+	for _, mapping := range mappings {
+		if mapping.Name == "" {
+			return nil, fmt.Errorf("column without name for source %q", mapping.Source)
+		}
+		if names[mapping.Name] {
+			return nil, fmt.Errorf("duplicate column: %s", mapping.Name)
+		}
+		names[mapping.Name] = true
+
+		kind, ref, ok := strings.Cut(mapping.Source, ":")
+		if !ok && kind != sourceBody {
+			return nil, fmt.Errorf("column %s: invalid source: %q", mapping.Name, mapping.Source)
+		}
+		column := mappedColumn{name: mapping.Name, kind: kind, ref: ref, field: -1, typ: mapping.Type, def: mapping.Default}
+		switch kind {
+		case sourceField:
+			for i, name := range signal.layout {
+				if name == ref {
+					column.field = i
+				}
+			}
+			if column.field < 0 {
+				return nil, fmt.Errorf("column %s: unknown field: %s", mapping.Name, ref)
+			}
+		case sourceResource, sourceAttribute:
+			if ref == "" {
+				return nil, fmt.Errorf("column %s: missing attribute key", mapping.Name)
+			}
+		case sourceBody:
+			if !signal.body {
+				return nil, fmt.Errorf("column %s: only logs have a body", mapping.Name)
+			}
+			ref = strings.TrimPrefix(strings.TrimPrefix(ref, "$"), ".")
+			if ref != "" {
+				column.path = strings.Split(ref, ".")
+				mapper.parseBody = true
+			}
+		case sourceComputed:
+			found := false
+			for _, name := range signal.computed {
+				found = found || name == ref
+			}
+			if !found {
+				return nil, fmt.Errorf("column %s: unknown computed field: %s", mapping.Name, ref)
+			}
+		default:
+			return nil, fmt.Errorf("column %s: unknown source: %s", mapping.Name, kind)
+		}
+		switch column.typ {
+		case "":
+			column.typ = ColumnTypeString
+		case ColumnTypeString, ColumnTypeInt, ColumnTypeDouble, ColumnTypeBool:
+		default:
+			return nil, fmt.Errorf("column %s: unknown type: %s", mapping.Name, column.typ)
+		}
+		mapper.columns = append(mapper.columns, column)
+		mapper.header = append(mapper.header, column.name)
+	}
+	return mapper, nil
+}
+
+// mappingRow holds what the sources of a row are read from.
+type mappingRow struct {
+	// record is the row in the fixed layout of the signal.
+	record []string
+
+	resource   pcommon.Map
+	attributes pcommon.Map
+
+	// body is the log record body, nil for other signals.
+	body *pcommon.Value
+
+	// parsedBody is the body with a JSON string parsed, set by row when a
+	// column reads a path in the body.
+	parsedBody *pcommon.Value
+
+	// computed holds the computed fields of the row.
+	computed map[string]pcommon.Value
+}
+
+// row builds the mapped row. A JSON string body is parsed once for every
+// column reading a path in it. Values that cannot be converted to the type of
+// their column are left empty, written as the null value, and counted.
+func (c *columnMapper) row(r mappingRow) []string {
+	if c.parseBody && r.body != nil {
+		parsed := *r.body
+		if parsed.Type() == pcommon.ValueTypeStr {
+			parsed, _ = parseJSONValue(parsed.Str())
+		}
+		r.parsedBody = &parsed
+	}
+	row := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		v, ok := column.lookup(r)
+		if !ok {
+			row[i] = column.def
+			continue
+		}
+		s, err := formatColumnValue(v, column.typ)
+		if err != nil {
+			c.conversionFailures.Add(1)
+			continue
+		}
+		row[i] = s
+	}
+	return row
+}
+
+// lookup reads the value of a column, reporting false when it is absent.
+func (c mappedColumn) lookup(r mappingRow) (pcommon.Value, bool) {
+	switch c.kind {
+	case sourceField:
+		if r.record[c.field] == "" {
+			return pcommon.Value{}, false
+		}
+		return pcommon.NewValueStr(r.record[c.field]), true
+	case sourceResource:
+		return r.resource.Get(c.ref)
+	case sourceAttribute:
+		return r.attributes.Get(c.ref)
+	case sourceBody:
+		body := r.body
+		if len(c.path) > 0 {
+			body = r.parsedBody
+		}
+		if body == nil {
+			return pcommon.Value{}, false
+		}
+		return lookupPath(*body, c.path)
+	case sourceComputed:
+		v, ok := r.computed[c.ref]
+		return v, ok
+	}
+	return pcommon.Value{}, false
+}
+
+// lookupPath follows a path of map keys and slice indexes from a value. A
+// string value holding a JSON object or array is parsed before following the
+// path.
+func lookupPath(v pcommon.Value, path []string) (pcommon.Value, bool) {
+	for _, key := range path {
+		if v.Type() == pcommon.ValueTypeStr {
+			var ok bool
+			if v, ok = parseJSONValue(v.Str()); !ok {
+				return pcommon.Value{}, false
+			}
+		}
+		switch v.Type() {
+		case pcommon.ValueTypeMap:
+			next, ok := v.Map().Get(key)
+			if !ok {
+				return pcommon.Value{}, false
+			}
+			v = next
+		case pcommon.ValueTypeSlice:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= v.Slice().Len() {
+				return pcommon.Value{}, false
+			}
+			v = v.Slice().At(i)
+		default:
+			return pcommon.Value{}, false
+		}
+	}
+	if v.Type() == pcommon.ValueTypeEmpty {
+		return pcommon.Value{}, false
+	}
+	return v, true
+}
+
+// parseJSONValue parses a JSON document into a value, an empty value when it
+// is not valid JSON.
+func parseJSONValue(s string) (pcommon.Value, bool) {
+	var raw any
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return pcommon.NewValueEmpty(), false
+	}
+	v := pcommon.NewValueEmpty()
+	if err := v.FromRaw(raw); err != nil {
+		return pcommon.NewValueEmpty(), false
+	}
+	return v, true
+}
+
+// formatColumnValue converts a value to a column type and formats it.
+func formatColumnValue(v pcommon.Value, typ ColumnType) (string, error) {
+	switch typ {
+	case ColumnTypeInt:
+		switch v.Type() {
+		case pcommon.ValueTypeInt:
+			return strconv.FormatInt(v.Int(), 10), nil
+		case pcommon.ValueTypeDouble:
+			if f := v.Double(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+				return strconv.FormatInt(int64(f), 10), nil
+			}
+		case pcommon.ValueTypeBool:
+			if v.Bool() {
+				return "1", nil
+			}
+			return "0", nil
+		case pcommon.ValueTypeStr:
+			if i, err := strconv.ParseInt(v.Str(), 10, 64); err == nil {
+				return strconv.FormatInt(i, 10), nil
+			}
+		}
+	case ColumnTypeDouble:
+		switch v.Type() {
+		case pcommon.ValueTypeInt:
+			return formatFloat(float64(v.Int())), nil
+		case pcommon.ValueTypeDouble:
+			return formatFloat(v.Double()), nil
+		case pcommon.ValueTypeStr:
+			if f, err := strconv.ParseFloat(v.Str(), 64); err == nil {
+				return formatFloat(f), nil
+			}
+		}
+	case ColumnTypeBool:
+		switch v.Type() {
+		case pcommon.ValueTypeBool:
+			return strconv.FormatBool(v.Bool()), nil
+		case pcommon.ValueTypeInt:
+			return strconv.FormatBool(v.Int() != 0), nil
+		case pcommon.ValueTypeStr:
+			if b, err := strconv.ParseBool(v.Str()); err == nil {
+				return strconv.FormatBool(b), nil
+			}
+		}
+	default:
+		return v.AsString(), nil
+	}
+	return "", fmt.Errorf("cannot convert %q to %s", v.AsString(), typ)
+}
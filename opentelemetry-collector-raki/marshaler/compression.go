@@ -0,0 +1,445 @@
+package marshaler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	// Content encodings of the compressed marshalers
+	CompressionGzip   = "gzip"
+	CompressionZstd   = "zstd"
+	CompressionSnappy = "snappy"
+	CompressionLz4    = "lz4"
+)
+
+// DefaultMaxDecompressedBytes is the size the input of the compressed
+// unmarshalers may decompress to, unless another limit is given.
+const DefaultMaxDecompressedBytes = 64 << 20
+
+// ContentEncoder is implemented by marshalers whose output is compressed.
+type ContentEncoder interface {
+	// ContentEncoding is the compression applied on top of the content type,
+	// e.g. gzip.
+	ContentEncoding() string
+}
+
+// ContentEncodingOf returns the content encoding of a marshaler, empty when
+// its output is not compressed.
+func ContentEncodingOf(m any) string {
+	if ce, ok := m.(ContentEncoder); ok {
+		return ce.ContentEncoding()
+	}
+	return ""
+}
+
+// compressor compresses marshaled bytes with a single codec.
+type compressor struct {
+	// name is the content encoding of the codec.
+	name string
+
+	// extension is the file name extension of the codec, e.g. .gz.
+	extension string
+
+	// newWriter wraps a writer so that what is written to it is compressed.
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+
+	// compress compresses marshaled bytes.
+	compress func(b []byte) ([]byte, error)
+
+	// decompress reverses compress, failing when the output exceeds limit
+	// bytes.
+	decompress func(b []byte, limit int64) ([]byte, error)
+}
+
+// zstdEncoder is shared by every zstd compression, EncodeAll being safe for
+// concurrent use.
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+// compressors are the supported codecs, keyed by content encoding. Snappy
+// uses the framing format so that the output can be decompressed as a stream.
+var compressors = map[string]compressor{
+	CompressionGzip: newStreamCompressor(CompressionGzip, ".gz",
+		func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		},
+		func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		}),
+	CompressionZstd: {
+		name:      CompressionZstd,
+		extension: ".zst",
+		newWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w)
+		},
+		compress: func(b []byte) ([]byte, error) {
+			return zstdEncoder.EncodeAll(b, nil), nil
+		},
+		decompress: func(b []byte, limit int64) ([]byte, error) {
+			d, err := zstd.NewReader(bytes.NewReader(b), zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			defer d.Close()
+			return readAllLimited(d, limit)
+		},
+	},
+	CompressionSnappy: newStreamCompressor(CompressionSnappy, ".sz",
+		func(w io.Writer) (io.WriteCloser, error) {
+			return snappy.NewBufferedWriter(w), nil
+		},
+		func(r io.Reader) (io.Reader, error) {
+			return snappy.NewReader(r), nil
+		}),
+	CompressionLz4: newStreamCompressor(CompressionLz4, ".lz4",
+		func(w io.Writer) (io.WriteCloser, error) {
+			return lz4.NewWriter(w), nil
+		},
+		func(r io.Reader) (io.Reader, error) {
+			return lz4.NewReader(r), nil
+		}),
+}
+
+// newStreamCompressor creates a compressor that compresses and decompresses
+// bytes through a streaming writer and reader.
+func newStreamCompressor(name, extension string, newWriter func(w io.Writer) (io.WriteCloser, error), newReader func(r io.Reader) (io.Reader, error)) compressor {
+	return compressor{
+		name:      name,
+		extension: extension,
+		newWriter: newWriter,
+		compress: func(b []byte) ([]byte, error) {
+			buf := bytes.Buffer{}
+			w, err := newWriter(&buf)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(b); err != nil {
+				_ = w.Close()
+				return nil, err
+			}
+			if err := w.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		},
+		decompress: func(b []byte, limit int64) ([]byte, error) {
+			r, err := newReader(bytes.NewReader(b))
+			if err != nil {
+				return nil, err
+			}
+			return readAllLimited(r, limit)
+		},
+	}
+}
+
+// readAllLimited reads r until EOF, failing once more than limit bytes are
+// read so that a small input cannot decompress to an unbounded output.
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("decompressed size exceeds %d bytes", limit)
+	}
+	return b, nil
+}
+
+// compressTo compresses what marshal writes into w.
+func (c compressor) compressTo(w io.Writer, marshal func(w io.Writer) error) error {
+	cw, err := c.newWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := marshal(cw); err != nil {
+		_ = cw.Close()
+		return err
+	}
+	return cw.Close()
+}
+
+// Compressions returns the names of the supported compressions, sorted.
+func Compressions() []string {
+	names := make([]string, 0, len(compressors))
+	for name := range compressors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateCompression checks that a compression is supported, an empty name
+// meaning no compression.
+func ValidateCompression(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := compressors[name]; !ok {
+		return fmt.Errorf("unsupported compression: %s", name)
+	}
+	return nil
+}
+
+// CompressionExtension returns the file name extension of a content encoding,
+// e.g. .zst for zstd, or an empty string when there is no compression.
+func CompressionExtension(contentEncoding string) string {
+	return compressors[contentEncoding].extension
+}
+
+// compressedEncoding is the name of a compressed encoding, e.g. otlp_csv+zstd.
+func compressedEncoding(encoding, compression string) string {
+	return encoding + "+" + compression
+}
+
+// compressedLogs compresses the output of a logs marshaler.
+type compressedLogs struct {
+	Logs
+	compressor compressor
+}
+
+// NewCompressedLogs wraps a logs marshaler so that its output is compressed.
+// The encoding of the returned marshaler is the combined encoding, e.g.
+// otlp_csv+zstd, and the content type is left unchanged.
+func NewCompressedLogs(m Logs, compression string) (Logs, error) {
+	c, ok := compressors[compression]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+	return &compressedLogs{Logs: m, compressor: c}, nil
+}
+
+// Marshal serializes and compresses logs into bytes.
+func (c *compressedLogs) Marshal(logs plog.Logs) ([]byte, error) {
+	b, err := c.Logs.Marshal(logs)
+	if err != nil {
+		return nil, err
+	}
+	return c.compressor.compress(b)
+}
+
+// MarshalTo serializes and compresses logs into w.
+func (c *compressedLogs) MarshalTo(w io.Writer, logs plog.Logs) error {
+	return c.compressor.compressTo(w, func(w io.Writer) error {
+		return MarshalLogsTo(w, c.Logs, logs)
+	})
+}
+
+// Encoding is the name of the encoding that this marshaler supports.
+func (c *compressedLogs) Encoding() string {
+	return compressedEncoding(c.Logs.Encoding(), c.compressor.name)
+}
+
+// ContentEncoding is the compression applied on top of the content type.
+func (c *compressedLogs) ContentEncoding() string {
+	return c.compressor.name
+}
+
+// compressedMetrics compresses the output of a metrics marshaler.
+type compressedMetrics struct {
+	Metrics
+	compressor compressor
+}
+
+// NewCompressedMetrics wraps a metrics marshaler so that its output is
+// compressed. The encoding of the returned marshaler is the combined
+// encoding, e.g. otlp_csv+zstd, and the content type is left unchanged.
+func NewCompressedMetrics(m Metrics, compression string) (Metrics, error) {
+	c, ok := compressors[compression]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+	return &compressedMetrics{Metrics: m, compressor: c}, nil
+}
+
+// Marshal serializes and compresses metrics into bytes.
+func (c *compressedMetrics) Marshal(metrics pmetric.Metrics) ([]byte, error) {
+	b, err := c.Metrics.Marshal(metrics)
+	if err != nil {
+		return nil, err
+	}
+	return c.compressor.compress(b)
+}
+
+// MarshalTo serializes and compresses metrics into w.
+func (c *compressedMetrics) MarshalTo(w io.Writer, metrics pmetric.Metrics) error {
+	return c.compressor.compressTo(w, func(w io.Writer) error {
+		return MarshalMetricsTo(w, c.Metrics, metrics)
+	})
+}
+
+// Encoding is the name of the encoding that this marshaler supports.
+func (c *compressedMetrics) Encoding() string {
+	return compressedEncoding(c.Metrics.Encoding(), c.compressor.name)
+}
+
+// ContentEncoding is the compression applied on top of the content type.
+func (c *compressedMetrics) ContentEncoding() string {
+	return c.compressor.name
+}
+
+// compressedTraces compresses the output of a traces marshaler.
+type compressedTraces struct {
+	Traces
+	compressor compressor
+}
+
+// NewCompressedTraces wraps a traces marshaler so that its output is
+// compressed. The encoding of the returned marshaler is the combined
+// encoding, e.g. otlp_csv+zstd, and the content type is left unchanged.
+func NewCompressedTraces(m Traces, compression string) (Traces, error) {
+	c, ok := compressors[compression]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+	return &compressedTraces{Traces: m, compressor: c}, nil
+}
+
+// Marshal serializes and compresses traces into bytes.
+func (c *compressedTraces) Marshal(traces ptrace.Traces) ([]byte, error) {
+	b, err := c.Traces.Marshal(traces)
+	if err != nil {
+		return nil, err
+	}
+	return c.compressor.compress(b)
+}
+
+// MarshalTo serializes and compresses traces into w.
+func (c *compressedTraces) MarshalTo(w io.Writer, traces ptrace.Traces) error {
+	return c.compressor.compressTo(w, func(w io.Writer) error {
+		return MarshalTracesTo(w, c.Traces, traces)
+	})
+}
+
+// Encoding is the name of the encoding that this marshaler supports.
+func (c *compressedTraces) Encoding() string {
+	return compressedEncoding(c.Traces.Encoding(), c.compressor.name)
+}
+
+// ContentEncoding is the compression applied on top of the content type.
+func (c *compressedTraces) ContentEncoding() string {
+	return c.compressor.name
+}
+
+// compressedLogsUnmarshaler decompresses the input of a logs unmarshaler.
+type compressedLogsUnmarshaler struct {
+	LogsUnmarshaler
+	compressor compressor
+	limit      int64
+}
+
+// NewCompressedLogsUnmarshaler wraps a logs unmarshaler so that it reads the
+// output of NewCompressedLogs with the same compression. Inputs decompressing
+// to more than DefaultMaxDecompressedBytes fail to unmarshal.
+func NewCompressedLogsUnmarshaler(u LogsUnmarshaler, compression string) (LogsUnmarshaler, error) {
+	return NewCompressedLogsUnmarshalerWithLimit(u, compression, DefaultMaxDecompressedBytes)
+}
+
+// NewCompressedLogsUnmarshalerWithLimit is NewCompressedLogsUnmarshaler with
+// the size the input may decompress to, larger inputs failing to unmarshal.
+func NewCompressedLogsUnmarshalerWithLimit(u LogsUnmarshaler, compression string, maxBytes int64) (LogsUnmarshaler, error) {
+	c, ok := compressors[compression]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+	return &compressedLogsUnmarshaler{LogsUnmarshaler: u, compressor: c, limit: maxBytes}, nil
+}
+
+// Unmarshal decompresses and deserializes bytes into logs.
+func (c *compressedLogsUnmarshaler) Unmarshal(buf []byte) (plog.Logs, error) {
+	b, err := c.compressor.decompress(buf, c.limit)
+	if err != nil {
+		return plog.NewLogs(), fmt.Errorf("failed to decompress %s: %w", c.compressor.name, err)
+	}
+	return c.LogsUnmarshaler.Unmarshal(b)
+}
+
+// Encoding is the name of the encoding that this unmarshaler supports.
+func (c *compressedLogsUnmarshaler) Encoding() string {
+	return compressedEncoding(c.LogsUnmarshaler.Encoding(), c.compressor.name)
+}
+
+// compressedMetricsUnmarshaler decompresses the input of a metrics unmarshaler.
+type compressedMetricsUnmarshaler struct {
+	MetricsUnmarshaler
+	compressor compressor
+	limit      int64
+}
+
+// NewCompressedMetricsUnmarshaler wraps a metrics unmarshaler so that it
+// reads the output of NewCompressedMetrics with the same compression. Inputs
+// decompressing to more than DefaultMaxDecompressedBytes fail to unmarshal.
+func NewCompressedMetricsUnmarshaler(u MetricsUnmarshaler, compression string) (MetricsUnmarshaler, error) {
+	return NewCompressedMetricsUnmarshalerWithLimit(u, compression, DefaultMaxDecompressedBytes)
+}
+
+// NewCompressedMetricsUnmarshalerWithLimit is NewCompressedMetricsUnmarshaler with
+// the size the input may decompress to, larger inputs failing to unmarshal.
+func NewCompressedMetricsUnmarshalerWithLimit(u MetricsUnmarshaler, compression string, maxBytes int64) (MetricsUnmarshaler, error) {
+	c, ok := compressors[compression]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+	return &compressedMetricsUnmarshaler{MetricsUnmarshaler: u, compressor: c, limit: maxBytes}, nil
+}
+
+// Unmarshal decompresses and deserializes bytes into metrics.
+func (c *compressedMetricsUnmarshaler) Unmarshal(buf []byte) (pmetric.Metrics, error) {
+	b, err := c.compressor.decompress(buf, c.limit)
+	if err != nil {
+		return pmetric.NewMetrics(), fmt.Errorf("failed to decompress %s: %w", c.compressor.name, err)
+	}
+	return c.MetricsUnmarshaler.Unmarshal(b)
+}
+
+// Encoding is the name of the encoding that this unmarshaler supports.
+func (c *compressedMetricsUnmarshaler) Encoding() string {
+	return compressedEncoding(c.MetricsUnmarshaler.Encoding(), c.compressor.name)
+}
+
+// compressedTracesUnmarshaler decompresses the input of a traces unmarshaler.
+type compressedTracesUnmarshaler struct {
+	TracesUnmarshaler
+	compressor compressor
+	limit      int64
+}
+
+// NewCompressedTracesUnmarshaler wraps a traces unmarshaler so that it reads
+// the output of NewCompressedTraces with the same compression. Inputs
+// decompressing to more than DefaultMaxDecompressedBytes fail to unmarshal.
+func NewCompressedTracesUnmarshaler(u TracesUnmarshaler, compression string) (TracesUnmarshaler, error) {
+	return NewCompressedTracesUnmarshalerWithLimit(u, compression, DefaultMaxDecompressedBytes)
+}
+
+// NewCompressedTracesUnmarshalerWithLimit is NewCompressedTracesUnmarshaler with
+// the size the input may decompress to, larger inputs failing to unmarshal.
+func NewCompressedTracesUnmarshalerWithLimit(u TracesUnmarshaler, compression string, maxBytes int64) (TracesUnmarshaler, error) {
+	c, ok := compressors[compression]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+	return &compressedTracesUnmarshaler{TracesUnmarshaler: u, compressor: c, limit: maxBytes}, nil
+}
+
+// Unmarshal decompresses and deserializes bytes into traces.
+func (c *compressedTracesUnmarshaler) Unmarshal(buf []byte) (ptrace.Traces, error) {
+	b, err := c.compressor.decompress(buf, c.limit)
+	if err != nil {
+		return ptrace.NewTraces(), fmt.Errorf("failed to decompress %s: %w", c.compressor.name, err)
+	}
+	return c.TracesUnmarshaler.Unmarshal(b)
+}
+
+// Encoding is the name of the encoding that this unmarshaler supports.
+func (c *compressedTracesUnmarshaler) Encoding() string {
+	return compressedEncoding(c.TracesUnmarshaler.Encoding(), c.compressor.name)
+}
@@ -0,0 +1,116 @@
+package marshaler_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"github.com/pierrec/lz4/v4"
+)
+
+func TestCompressedLogs(t *testing.T) {
+	readers := map[string]func(r io.Reader) (io.Reader, error){
+		marshaler.CompressionGzip: func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+		marshaler.CompressionZstd: func(r io.Reader) (io.Reader, error) {
+			return zstd.NewReader(r)
+		},
+		marshaler.CompressionSnappy: func(r io.Reader) (io.Reader, error) {
+			return snappy.NewReader(r), nil
+		},
+		marshaler.CompressionLz4: func(r io.Reader) (io.Reader, error) {
+			return lz4.NewReader(r), nil
+		},
+	}
+
+	base := marshaler.NewOtlpCsvLogs()
+	want, err := base.Marshal(testLogs())
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	for _, compression := range marshaler.Compressions() {
+		t.Run(compression, func(t *testing.T) {
+			m, err := marshaler.NewCompressedLogs(base, compression)
+			if err != nil {
+				t.Fatalf("NewCompressedLogs() failed: %v", err)
+			}
+			if got := m.Encoding(); got != "otlp_csv+"+compression {
+				t.Errorf("Expected encoding otlp_csv+%s, got %s", compression, got)
+			}
+			if got := marshaler.ContentEncodingOf(m); got != compression {
+				t.Errorf("Expected content encoding %s, got %s", compression, got)
+			}
+			if got := m.ContentType(); got != base.ContentType() {
+				t.Errorf("Expected content type %s, got %s", base.ContentType(), got)
+			}
+			if _, ok := marshaler.BaseLogsMarshalers()[m.Encoding()]; !ok {
+				t.Errorf("Expected %s to be registered", m.Encoding())
+			}
+
+			b, err := m.Marshal(testLogs())
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			r, err := readers[compression](bytes.NewReader(b))
+			if err != nil {
+				t.Fatalf("Failed to open decompressor: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("Failed to decompress: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("Expected decompressed output to match:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+
+	if _, err := marshaler.NewCompressedLogs(base, "brotli"); err == nil {
+		t.Fatal("Expected an error for an unsupported compression")
+	}
+}
+
+func TestCompressedUnmarshalerLimit(t *testing.T) {
+	base := marshaler.NewOtlpCsvLogs()
+	b, err := base.Marshal(testLogs())
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	for _, compression := range marshaler.Compressions() {
+		t.Run(compression, func(t *testing.T) {
+			m, err := marshaler.NewCompressedLogs(base, compression)
+			if err != nil {
+				t.Fatalf("NewCompressedLogs() failed: %v", err)
+			}
+			compressed, err := m.Marshal(testLogs())
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+
+			u, err := marshaler.NewCompressedLogsUnmarshalerWithLimit(marshaler.NewOtlpCsvLogsUnmarshaler(), compression, int64(len(b)))
+			if err != nil {
+				t.Fatalf("NewCompressedLogsUnmarshalerWithLimit() failed: %v", err)
+			}
+			if _, err := u.Unmarshal(compressed); err != nil {
+				t.Fatalf("Expected input decompressing to the limit to unmarshal, got %v", err)
+			}
+
+			u, err = marshaler.NewCompressedLogsUnmarshalerWithLimit(marshaler.NewOtlpCsvLogsUnmarshaler(), compression, int64(len(b)-1))
+			if err != nil {
+				t.Fatalf("NewCompressedLogsUnmarshalerWithLimit() failed: %v", err)
+			}
+			ld, err := u.Unmarshal(compressed)
+			if err == nil {
+				t.Fatal("Expected input decompressing over the limit to fail")
+			}
+			if ld.LogRecordCount() != 0 {
+				t.Errorf("Expected empty logs on failure, got %d records", ld.LogRecordCount())
+			}
+		})
+	}
+}
@@ -2,11 +2,9 @@ package marshaler // import "github.com/open-telemetry/opentelemetry-collector-c
 
 import (
 	"bytes"
-	"encoding/csv"
 	"errors"
 	"fmt"
-	"strings"
-	"time"
+	"io"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
@@ -14,238 +12,516 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
-type CSVMarshaler struct{}
+// CSVConfig defines the options of the CSV marshaler. The zero value writes
+// comma separated values with a header, minimal quoting and RFC 3339
+// timestamps with nanosecond precision.
+type CSVConfig struct {
+	// Delimiter is the single character separating fields, defaults to ','.
+	Delimiter string `mapstructure:"delimiter"`
+
+	// Quoting selects which fields are quoted, defaults to QuoteModeMinimal.
+	Quoting QuoteMode `mapstructure:"quoting"`
+
+	// SkipHeader disables the header line. Unmarshaling then expects the
+	// configured columns in order.
+	SkipHeader bool `mapstructure:"skip_header"`
+
+	// Columns selects the columns written for each signal, in order.
+	Columns CSVColumns `mapstructure:"columns"`
+
+	// TimestampFormat selects how timestamps are written, defaults to
+	// TimestampFormatRFC3339Nano.
+	TimestampFormat TimestampFormat `mapstructure:"timestamp_format"`
+
+	// NullValue is written in place of absent values, defaults to an empty field.
+	NullValue string `mapstructure:"null_value"`
+
+	// AttributeEncoding selects how attribute maps and log bodies are written
+	// into a single column, defaults to AttributeEncodingTyped.
+	AttributeEncoding AttributeEncoding `mapstructure:"attribute_encoding"`
+
+	// Mapping replaces the fixed layout of a signal by mapped columns. Mapped
+	// CSV cannot be unmarshaled, and Columns is ignored for a mapped signal.
+	Mapping ColumnMappings `mapstructure:"mapping"`
+
+	// MetricsLayout selects the layout of metrics, defaults to
+	// MetricsLayoutLong. The wide layout cannot be unmarshaled and does not
+	// support selecting or mapping the metrics columns.
+	MetricsLayout MetricsLayout `mapstructure:"metrics_layout"`
+}
+
+// Validate checks that the CSV options are supported.
+func (c CSVConfig) Validate() error {
+	if err := validateDelimiter(c.Delimiter); err != nil {
+		return err
+	}
+	switch c.Quoting {
+	case "", QuoteModeMinimal, QuoteModeAll:
+	default:
+		return fmt.Errorf("invalid quoting: %s", c.Quoting)
+	}
+	if _, err := columnIndexes(logsCSVHeader, c.Columns.Logs); err != nil {
+		return fmt.Errorf("invalid logs columns: %w", err)
+	}
+	if _, err := columnIndexes(metricsCSVHeader, c.Columns.Metrics); err != nil {
+		return fmt.Errorf("invalid metrics columns: %w", err)
+	}
+	if _, err := columnIndexes(tracesCSVHeader, c.Columns.Traces); err != nil {
+		return fmt.Errorf("invalid traces columns: %w", err)
+	}
+	switch c.TimestampFormat {
+	case "", TimestampFormatRFC3339Nano, TimestampFormatUnixNano, TimestampFormatUnixMilli:
+	default:
+		return fmt.Errorf("invalid timestamp format: %s", c.TimestampFormat)
+	}
+	switch c.AttributeEncoding {
+	case "", AttributeEncodingTyped, AttributeEncodingFlat:
+	default:
+		return fmt.Errorf("invalid attribute encoding: %s", c.AttributeEncoding)
+	}
+	if err := c.Mapping.Validate(); err != nil {
+		return fmt.Errorf("invalid mapping: %w", err)
+	}
+	switch c.MetricsLayout {
+	case "", MetricsLayoutLong:
+	case MetricsLayoutWide:
+		if len(c.Columns.Metrics) > 0 || len(c.Mapping.Metrics) > 0 {
+			return errors.New("the wide metrics layout does not support metrics columns or mapping")
+		}
+	default:
+		return fmt.Errorf("invalid metrics layout: %s", c.MetricsLayout)
+	}
+	return nil
+}
+
+// errUnmarshalMapped is returned when unmarshaling a signal written with a
+// column mapping.
+var errUnmarshalMapped = errors.New("unmarshaling is not supported with a column mapping")
+
+// errUnmarshalWide is returned when unmarshaling metrics written with the wide
+// layout.
+var errUnmarshalWide = errors.New("unmarshaling is not supported with the wide metrics layout")
+
+type CSVMarshaler struct {
+	config CSVConfig
+
+	// logColumns, metricColumns and spanColumns are the indexes of the
+	// selected columns in each layout, nil for the full layout.
+	logColumns    []int
+	metricColumns []int
+	spanColumns   []int
+
+	// logMapper, metricMapper and spanMapper build the rows of each signal
+	// from its column mapping, nil to write the layout.
+	logMapper    *columnMapper
+	metricMapper *columnMapper
+	spanMapper   *columnMapper
+}
 
 func NewCSVMarshaler() CSVMarshaler {
 	return CSVMarshaler{}
 }
 
+// NewCSVMarshalerWithConfig creates a CSV marshaler with the given options.
+// Unknown columns and invalid mappings are ignored, use CSVConfig.Validate
+// to report them.
+func NewCSVMarshalerWithConfig(config CSVConfig) CSVMarshaler {
+	logColumns, _ := columnIndexes(logsCSVHeader, config.Columns.Logs)
+	metricColumns, _ := columnIndexes(metricsCSVHeader, config.Columns.Metrics)
+	spanColumns, _ := columnIndexes(tracesCSVHeader, config.Columns.Traces)
+	logMapper, _ := newColumnMapper(logsMappingSignal, config.Mapping.Logs)
+	metricMapper, _ := newColumnMapper(metricsMappingSignal, config.Mapping.Metrics)
+	spanMapper, _ := newColumnMapper(tracesMappingSignal, config.Mapping.Traces)
+	return CSVMarshaler{
+		config:        config,
+		logColumns:    logColumns,
+		metricColumns: metricColumns,
+		spanColumns:   spanColumns,
+		logMapper:     logMapper,
+		metricMapper:  metricMapper,
+		spanMapper:    spanMapper,
+	}
+}
+
+// ConversionFailures returns the number of mapped values written as null
+// because they could not be converted to the type of their column, since the
+// marshaler was created.
+func (m CSVMarshaler) ConversionFailures() int64 {
+	var n int64
+	for _, mapper := range []*columnMapper{m.logMapper, m.metricMapper, m.spanMapper} {
+		if mapper != nil {
+			n += mapper.conversionFailures.Load()
+		}
+	}
+	return n
+}
+
 // MarshalLogs converts OpenTelemetry logs into a CSV format.
-func (CSVMarshaler) MarshalLogs(ld plog.Logs) ([]byte, error) {
+//
+// Every log record is written as a single row following the layout described
+// by logsCSVHeader, repeating the attributes of its resource and scope.
+func (m CSVMarshaler) MarshalLogs(ld plog.Logs) ([]byte, error) {
 	buf := bytes.Buffer{}
-	writer := csv.NewWriter(&buf)
+	if err := m.WriteLogs(&buf, ld); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-	// Write CSV header
-	header := []string{"timestamp", "severity", "body", "attributes"}
-	if err := writer.Write(header); err != nil {
-		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+// WriteLogs writes OpenTelemetry logs into w in the CSV format of MarshalLogs,
+// one row at a time.
+func (m CSVMarshaler) WriteLogs(w io.Writer, ld plog.Logs) error {
+	writer, err := m.startCSV(w, logsCSVHeader, m.logColumns, m.logMapper)
+	if err != nil {
+		return err
 	}
+	defer writer.release()
 
 	rls := ld.ResourceLogs()
 	for i := 0; i < rls.Len(); i++ {
 		rl := rls.At(i)
+		writer.resource, err = m.appendAttributes(writer.resource[:0], rl.Resource().Attributes())
+		if err != nil {
+			return fmt.Errorf("failed to serialize resource attributes: %w", err)
+		}
 		ills := rl.ScopeLogs()
 		for j := 0; j < ills.Len(); j++ {
 			ils := ills.At(j)
 			logs := ils.LogRecords()
 			for k := 0; k < logs.Len(); k++ {
 				lr := logs.At(k)
-
-				// Extract fields for CSV
-				timestamp := lr.Timestamp().AsTime().Format("2006-01-02T15:04:05Z")
-				severity := lr.SeverityText()
-				body := lr.Body().AsString()
-				attributes, err := attributesToCSVString(lr.Attributes())
-				if err != nil {
-					return nil, fmt.Errorf("failed to serialize attributes: %w", err)
+				row := &writer.row
+				if err := m.logToCSVRow(row, lr); err != nil {
+					return err
+				}
+				row.setBytes(logColResourceAttributes, writer.resource)
+				row.setString(logColScopeName, ils.Scope().Name())
+				row.setString(logColScopeVersion, ils.Scope().Version())
+				if m.logMapper != nil {
+					body := lr.Body()
+					record := m.logMapper.row(mappingRow{
+						record:     row.strings(),
+						resource:   rl.Resource().Attributes(),
+						attributes: lr.Attributes(),
+						body:       &body,
+					})
+					if err := writer.Write(record); err != nil {
+						return fmt.Errorf("failed to write CSV record: %w", err)
+					}
+					continue
 				}
 
 				// Write log entry as a CSV row
-				record := []string{timestamp, severity, body, attributes}
-				if err := writer.Write(record); err != nil {
-					return nil, fmt.Errorf("failed to write CSV record: %w", err)
+				if err := writer.WriteRow(row); err != nil {
+					return fmt.Errorf("failed to write CSV record: %w", err)
 				}
 			}
 		}
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, fmt.Errorf("error during CSV writing: %w", err)
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error during CSV writing: %w", err)
 	}
-
-	return buf.Bytes(), nil
+	return nil
 }
 
 // UnmarshalLogs converts a CSV byte array into OpenTelemetry logs.
-func (CSVMarshaler) UnmarshalLogs(buf []byte) (plog.Logs, error) {
-	reader := csv.NewReader(bytes.NewReader(buf))
-	lines, err := reader.ReadAll()
-	if err != nil {
-		return plog.NewLogs(), fmt.Errorf("failed to read CSV: %w", err)
+//
+// Consecutive rows that share the resource attributes are grouped back into a
+// single ResourceLogs, and consecutive rows of that resource sharing the scope
+// name and version into a single ScopeLogs.
+func (m CSVMarshaler) UnmarshalLogs(buf []byte) (plog.Logs, error) {
+	if m.logMapper != nil {
+		return plog.NewLogs(), errUnmarshalMapped
 	}
-
-	if len(lines) < 2 {
-		return plog.NewLogs(), errors.New("no log records found")
+	lines, err := m.readCSV(buf, logsCSVHeader, m.logColumns)
+	if err != nil {
+		return plog.NewLogs(), err
 	}
+	return m.logsFromCSVRecords(lines)
+}
 
+// logsFromCSVRecords groups records of the logs CSV layout back into logs, as
+// described by UnmarshalLogs.
+func (m CSVMarshaler) logsFromCSVRecords(lines [][]string) (plog.Logs, error) {
 	ld := plog.NewLogs()
-	for _, line := range lines[1:] {
-		if len(line) != 4 {
-			return plog.NewLogs(), errors.New("invalid CSV format")
+	var rl plog.ResourceLogs
+	var sl plog.ScopeLogs
+	var previous []string
+	for _, line := range lines {
+		newResource := previous == nil || previous[logColResourceAttributes] != line[logColResourceAttributes]
+		if newResource {
+			rl = ld.ResourceLogs().AppendEmpty()
+			if err := m.decodeAttributes(line[logColResourceAttributes], rl.Resource().Attributes()); err != nil {
+				return plog.NewLogs(), fmt.Errorf("failed to parse resource attributes: %w", err)
+			}
 		}
-		logRecord := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
-		timestamp, err := time.Parse("2006-01-02T15:04:05Z", line[0])
-		if err != nil {
-			return plog.NewLogs(), fmt.Errorf("failed to parse timestamp: %w", err)
+		if newResource || previous[logColScopeName] != line[logColScopeName] || previous[logColScopeVersion] != line[logColScopeVersion] {
+			sl = rl.ScopeLogs().AppendEmpty()
+			sl.Scope().SetName(line[logColScopeName])
+			sl.Scope().SetVersion(line[logColScopeVersion])
 		}
-		logRecord.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
-		logRecord.SetSeverityText(line[1])
-		logRecord.Body().SetStr(line[2])
-		logRecord.Attributes().FromRaw(attributesFromCSVString(line[3]).AsRaw())
+		if err := m.logFromCSVRecord(sl.LogRecords().AppendEmpty(), line); err != nil {
+			return plog.NewLogs(), err
+		}
+		previous = line
 	}
 
 	return ld, nil
 }
 
 // MarshalMetrics converts OpenTelemetry metrics into a CSV format.
-func (CSVMarshaler) MarshalMetrics(md pmetric.Metrics) ([]byte, error) {
+//
+// Every data point is written as a single row following the layout described
+// by metricsCSVHeader, repeating the attributes of its resource and scope.
+// Columns that do not apply to a metric type are left empty, e.g.
+// bucket_counts for a gauge or value for a histogram.
+//
+// With MetricsLayoutWide, data points are pivoted as described by
+// writeWideMetrics instead.
+func (m CSVMarshaler) MarshalMetrics(md pmetric.Metrics) ([]byte, error) {
 	buf := bytes.Buffer{}
-	writer := csv.NewWriter(&buf)
-	header := []string{"timestamp", "metric_name", "value"}
-	if err := writer.Write(header); err != nil {
-		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	if err := m.WriteMetrics(&buf, md); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteMetrics writes OpenTelemetry metrics into w in the CSV format of MarshalMetrics,
+// one row at a time.
+func (m CSVMarshaler) WriteMetrics(w io.Writer, md pmetric.Metrics) error {
+	if m.config.MetricsLayout == MetricsLayoutWide {
+		return m.writeWideMetrics(w, md)
+	}
+	writer, err := m.startCSV(w, metricsCSVHeader, m.metricColumns, m.metricMapper)
+	if err != nil {
+		return err
 	}
+	defer writer.release()
 
-	metrics := md.ResourceMetrics()
-	for i := 0; i < metrics.Len(); i++ {
-		rm := metrics.At(i)
+	// The row writer is built once rather than per metric, as a closure
+	// capturing the loop variables would be allocated for every metric.
+	var (
+		rm  pmetric.ResourceMetrics
+		ilm pmetric.ScopeMetrics
+	)
+	row := &writer.row
+	writeRow := func(attributes pcommon.Map) error {
+		row.setBytes(metricColResourceAttributes, writer.resource)
+		row.setString(metricColScopeName, ilm.Scope().Name())
+		row.setString(metricColScopeVersion, ilm.Scope().Version())
+		if m.metricMapper != nil {
+			record := m.metricMapper.row(mappingRow{
+				record:     row.strings(),
+				resource:   rm.Resource().Attributes(),
+				attributes: attributes,
+			})
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write CSV record: %w", err)
+			}
+			return nil
+		}
+		if err := writer.WriteRow(row); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+		return nil
+	}
+
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm = rms.At(i)
+		writer.resource, err = m.appendAttributes(writer.resource[:0], rm.Resource().Attributes())
+		if err != nil {
+			return fmt.Errorf("failed to serialize resource attributes: %w", err)
+		}
 		ilms := rm.ScopeMetrics()
 		for j := 0; j < ilms.Len(); j++ {
-			ilm := ilms.At(j)
+			ilm = ilms.At(j)
 			metrics := ilm.Metrics()
 			for k := 0; k < metrics.Len(); k++ {
-				m := metrics.At(k)
-				switch m.Type() {
-				case pmetric.MetricTypeGauge:
-					gauge := m.Gauge().DataPoints()
-					for l := 0; l < gauge.Len(); l++ {
-						dp := gauge.At(l)
-						timestamp := dp.Timestamp().AsTime().Format("2006-01-02T15:04:05Z")
-						metricName := m.Name()
-						value := fmt.Sprintf("%f", dp.DoubleValue())
-						record := []string{timestamp, metricName, value}
-						if err := writer.Write(record); err != nil {
-							return nil, fmt.Errorf("failed to write CSV record: %w", err)
-						}
-					}
+				if err := m.metricToCSVRows(row, metrics.At(k), writeRow); err != nil {
+					return err
 				}
 			}
 		}
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, fmt.Errorf("error during CSV writing: %w", err)
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error during CSV writing: %w", err)
 	}
-
-	return buf.Bytes(), nil
+	return nil
 }
 
 // UnmarshalMetrics converts a CSV byte array into OpenTelemetry metrics.
-func (CSVMarshaler) UnmarshalMetrics(buf []byte) (pmetric.Metrics, error) {
-	return pmetric.NewMetrics(), errors.New("unmarshaling metrics is not implemented")
+//
+// Data points are grouped back by resource and scope as UnmarshalLogs does,
+// and consecutive rows of a scope that share the metric name, type, unit,
+// description, aggregation temporality and monotonicity into a single metric.
+func (m CSVMarshaler) UnmarshalMetrics(buf []byte) (pmetric.Metrics, error) {
+	if m.metricMapper != nil {
+		return pmetric.NewMetrics(), errUnmarshalMapped
+	}
+	if m.config.MetricsLayout == MetricsLayoutWide {
+		return pmetric.NewMetrics(), errUnmarshalWide
+	}
+	lines, err := m.readCSV(buf, metricsCSVHeader, m.metricColumns)
+	if err != nil {
+		return pmetric.NewMetrics(), err
+	}
+	return m.metricsFromCSVRecords(lines)
+}
+
+// metricsFromCSVRecords groups records of the metrics CSV layout back into
+// metrics, as described by UnmarshalMetrics.
+func (m CSVMarshaler) metricsFromCSVRecords(lines [][]string) (pmetric.Metrics, error) {
+	md := pmetric.NewMetrics()
+	var rm pmetric.ResourceMetrics
+	var sm pmetric.ScopeMetrics
+	var current pmetric.Metric
+	var previous []string
+	for _, line := range lines {
+		newResource := previous == nil || previous[metricColResourceAttributes] != line[metricColResourceAttributes]
+		if newResource {
+			rm = md.ResourceMetrics().AppendEmpty()
+			if err := m.decodeAttributes(line[metricColResourceAttributes], rm.Resource().Attributes()); err != nil {
+				return pmetric.NewMetrics(), fmt.Errorf("failed to parse resource attributes: %w", err)
+			}
+		}
+		newScope := newResource || previous[metricColScopeName] != line[metricColScopeName] || previous[metricColScopeVersion] != line[metricColScopeVersion]
+		if newScope {
+			sm = rm.ScopeMetrics().AppendEmpty()
+			sm.Scope().SetName(line[metricColScopeName])
+			sm.Scope().SetVersion(line[metricColScopeVersion])
+		}
+		if newScope || !sameMetric(previous, line) {
+			current = sm.Metrics().AppendEmpty()
+		}
+		if err := m.metricFromCSVRecord(current, line); err != nil {
+			return pmetric.NewMetrics(), err
+		}
+		previous = line
+	}
+
+	return md, nil
 }
 
 // MarshalTraces converts OpenTelemetry traces into a CSV format.
-func (CSVMarshaler) MarshalTraces(td ptrace.Traces) ([]byte, error) {
+//
+// Every span is written as a single row following the layout described by
+// tracesCSVHeader, repeating the attributes of its resource and scope, so
+// that the call tree and the latency of each operation can be rebuilt from
+// the rows alone.
+func (m CSVMarshaler) MarshalTraces(td ptrace.Traces) ([]byte, error) {
 	buf := bytes.Buffer{}
-	writer := csv.NewWriter(&buf)
-	header := []string{"trace_id", "span_id", "name", "status"}
-	if err := writer.Write(header); err != nil {
-		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	if err := m.WriteTraces(&buf, td); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTraces writes OpenTelemetry traces into w in the CSV format of MarshalTraces,
+// one row at a time.
+func (m CSVMarshaler) WriteTraces(w io.Writer, td ptrace.Traces) error {
+	writer, err := m.startCSV(w, tracesCSVHeader, m.spanColumns, m.spanMapper)
+	if err != nil {
+		return err
 	}
+	defer writer.release()
 
 	traces := td.ResourceSpans()
 	for i := 0; i < traces.Len(); i++ {
 		rspan := traces.At(i)
+		writer.resource, err = m.appendAttributes(writer.resource[:0], rspan.Resource().Attributes())
+		if err != nil {
+			return fmt.Errorf("failed to serialize resource attributes: %w", err)
+		}
+		service := serviceName(rspan.Resource().Attributes())
 		illSpans := rspan.ScopeSpans()
 		for j := 0; j < illSpans.Len(); j++ {
-			span := illSpans.At(j).Spans()
+			ils := illSpans.At(j)
+			span := ils.Spans()
 			for k := 0; k < span.Len(); k++ {
 				s := span.At(k)
-				record := []string{
-					s.TraceID().String(),
-					s.SpanID().String(),
-					s.Name(),
-					s.Status().Message(),
+				row := &writer.row
+				if err := m.spanToCSVRow(row, s); err != nil {
+					return err
+				}
+				row.setString(spanColServiceName, service)
+				row.setBytes(spanColResourceAttributes, writer.resource)
+				row.setString(spanColScopeName, ils.Scope().Name())
+				row.setString(spanColScopeVersion, ils.Scope().Version())
+				if m.spanMapper != nil {
+					record := m.spanMapper.row(mappingRow{
+						record:     row.strings(),
+						resource:   rspan.Resource().Attributes(),
+						attributes: s.Attributes(),
+						computed:   spanComputedFields(s),
+					})
+					if err := writer.Write(record); err != nil {
+						return fmt.Errorf("failed to write CSV record: %w", err)
+					}
+					continue
 				}
-				if err := writer.Write(record); err != nil {
-					return nil, fmt.Errorf("failed to write CSV record: %w", err)
+				if err := writer.WriteRow(row); err != nil {
+					return fmt.Errorf("failed to write CSV record: %w", err)
 				}
 			}
 		}
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, fmt.Errorf("error during CSV writing: %w", err)
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error during CSV writing: %w", err)
 	}
-
-	return buf.Bytes(), nil
+	return nil
 }
 
 // UnmarshalTraces converts a CSV byte array into OpenTelemetry traces.
-func (CSVMarshaler) UnmarshalTraces(buf []byte) (ptrace.Traces, error) {
-	return ptrace.NewTraces(), errors.New("unmarshaling traces is not implemented")
-}
-
-// attributesFromCSVString parses a semicolon-separated key-value string and returns a map.
-func attributesFromCSVString(csvStr string) pcommon.Map {
-	attrs := pcommon.NewMap()
-	if csvStr == "" {
-		return attrs
+//
+// Spans are grouped back by resource and scope as UnmarshalLogs does. When
+// the resource_attributes column is not selected, the service_name column
+// is restored as the service.name resource attribute.
+func (m CSVMarshaler) UnmarshalTraces(buf []byte) (ptrace.Traces, error) {
+	if m.spanMapper != nil {
+		return ptrace.NewTraces(), errUnmarshalMapped
 	}
-
-	pairs := strings.Split(csvStr, ";")
-	for _, pair := range pairs {
-		kv := strings.SplitN(pair, "=", 2)
-		if len(kv) != 2 {
-			continue // Skip malformed pairs
-		}
-		key := strings.TrimSpace(kv[0])
-		value := strings.TrimSpace(kv[1])
-		attrs.PutStr(key, value)
+	lines, err := m.readCSV(buf, tracesCSVHeader, m.spanColumns)
+	if err != nil {
+		return ptrace.NewTraces(), err
 	}
-
-	return attrs
+	return m.tracesFromCSVRecords(lines)
 }
 
-// attributesToCSVString serializes attributes into a single string for CSV.
-func attributesToCSVString(attrs pcommon.Map) (string, error) {
-	var sb strings.Builder
-	first := true
-	attrs.Range(func(k string, v pcommon.Value) bool {
-		if !first {
-			sb.WriteString("; ")
+// tracesFromCSVRecords groups records of the traces CSV layout back into
+// traces, as described by UnmarshalTraces.
+func (m CSVMarshaler) tracesFromCSVRecords(lines [][]string) (ptrace.Traces, error) {
+	td := ptrace.NewTraces()
+	var rs ptrace.ResourceSpans
+	var ss ptrace.ScopeSpans
+	var previous []string
+	for _, line := range lines {
+		newResource := previous == nil ||
+			previous[spanColResourceAttributes] != line[spanColResourceAttributes] ||
+			previous[spanColServiceName] != line[spanColServiceName]
+		if newResource {
+			rs = td.ResourceSpans().AppendEmpty()
+			resource := rs.Resource().Attributes()
+			if err := m.decodeAttributes(line[spanColResourceAttributes], resource); err != nil {
+				return ptrace.NewTraces(), fmt.Errorf("failed to parse resource attributes: %w", err)
+			}
+			if _, ok := resource.Get(serviceNameKey); !ok && line[spanColServiceName] != "" {
+				resource.PutStr(serviceNameKey, line[spanColServiceName])
+			}
 		}
-		first = false
-		attrValue, err := attributeValueToString(v)
-		if err != nil {
-			return false
+		if newResource || previous[spanColScopeName] != line[spanColScopeName] || previous[spanColScopeVersion] != line[spanColScopeVersion] {
+			ss = rs.ScopeSpans().AppendEmpty()
+			ss.Scope().SetName(line[spanColScopeName])
+			ss.Scope().SetVersion(line[spanColScopeVersion])
 		}
-		sb.WriteString(fmt.Sprintf("%s=%s", k, attrValue))
-		return true
-	})
-
-	if !first {
-		return sb.String(), nil
+		if err := m.spanFromCSVRecord(ss.Spans().AppendEmpty(), line); err != nil {
+			return ptrace.NewTraces(), err
+		}
+		previous = line
 	}
-	return "", nil
-}
 
-// attributeValueToString converts a pcommon.Value to a string representation.
-func attributeValueToString(v pcommon.Value) (string, error) {
-	switch v.Type() {
-	case pcommon.ValueTypeStr:
-		return v.Str(), nil
-	case pcommon.ValueTypeBool:
-		return fmt.Sprintf("%t", v.Bool()), nil
-	case pcommon.ValueTypeInt:
-		return fmt.Sprintf("%d", v.Int()), nil
-	case pcommon.ValueTypeDouble:
-		return fmt.Sprintf("%f", v.Double()), nil
-	default:
-		return "", errors.New("unsupported attribute type")
-	}
+	return td, nil
 }
@@ -0,0 +1,177 @@
+package marshaler_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// benchmarkBatchSizes are the numbers of records of the benchmarked batches.
+var benchmarkBatchSizes = []int{10, 1000, 10000}
+
+// benchmarkResources is the number of ATMs the records of a batch come from.
+const benchmarkResources = 10
+
+func benchmarkLogs(n int) plog.Logs {
+	ld := plog.NewLogs()
+	for i := 0; i < n; i++ {
+		if i%(n/benchmarkResources+1) == 0 {
+			rl := ld.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutStr("service.name", "atm")
+			rl.Resource().Attributes().PutStr("service.instance.id", fmt.Sprintf("atm-%d", i))
+			rl.ScopeLogs().AppendEmpty().Scope().SetName("filelog")
+		}
+		rl := ld.ResourceLogs().At(ld.ResourceLogs().Len() - 1)
+		lr := rl.ScopeLogs().At(0).LogRecords().AppendEmpty()
+		lr.SetTimestamp(testTimestamp + pcommon.Timestamp(i)*1e6)
+		lr.SetSeverityText("INFO")
+		lr.SetSeverityNumber(plog.SeverityNumberInfo)
+		lr.Body().SetStr("withdrawal completed, \"cash dispensed\"")
+		lr.Attributes().PutStr("account", "checking")
+		lr.Attributes().PutInt("amount", int64(i))
+		lr.Attributes().PutDouble("balance", 1234.5)
+		lr.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, byte(i)})
+		lr.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, byte(i)})
+	}
+	return ld
+}
+
+func benchmarkMetrics(n int) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	for i := 0; i < n; i++ {
+		if i%(n/benchmarkResources+1) == 0 {
+			rm := md.ResourceMetrics().AppendEmpty()
+			rm.Resource().Attributes().PutStr("service.instance.id", fmt.Sprintf("atm-%d", i))
+			rm.ScopeMetrics().AppendEmpty().Scope().SetName("hostmetrics")
+		}
+		rm := md.ResourceMetrics().At(md.ResourceMetrics().Len() - 1)
+		metric := rm.ScopeMetrics().At(0).Metrics().AppendEmpty()
+		switch i % 3 {
+		case 0:
+			metric.SetName("atm.cash")
+			metric.SetUnit("{bills}")
+			dp := metric.SetEmptyGauge().DataPoints().AppendEmpty()
+			dp.SetTimestamp(testTimestamp)
+			dp.SetIntValue(int64(i))
+			dp.Attributes().PutStr("cassette", "a")
+		case 1:
+			metric.SetName("atm.withdrawals")
+			sum := metric.SetEmptySum()
+			sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+			sum.SetIsMonotonic(true)
+			dp := sum.DataPoints().AppendEmpty()
+			dp.SetStartTimestamp(testTimestamp - 60e9)
+			dp.SetTimestamp(testTimestamp)
+			dp.SetDoubleValue(float64(i) / 3)
+		default:
+			metric.SetName("atm.latency")
+			metric.SetUnit("ms")
+			histogram := metric.SetEmptyHistogram()
+			histogram.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+			dp := histogram.DataPoints().AppendEmpty()
+			dp.SetTimestamp(testTimestamp)
+			dp.SetCount(6)
+			dp.SetSum(12.5)
+			dp.BucketCounts().FromRaw([]uint64{1, 2, 3})
+			dp.ExplicitBounds().FromRaw([]float64{1, 5})
+		}
+	}
+	return md
+}
+
+func benchmarkTraces(n int) ptrace.Traces {
+	td := ptrace.NewTraces()
+	for i := 0; i < n; i++ {
+		if i%(n/benchmarkResources+1) == 0 {
+			rs := td.ResourceSpans().AppendEmpty()
+			rs.Resource().Attributes().PutStr("service.name", "atm")
+			rs.Resource().Attributes().PutStr("service.instance.id", fmt.Sprintf("atm-%d", i))
+			rs.ScopeSpans().AppendEmpty().Scope().SetName("tailtracer")
+		}
+		rs := td.ResourceSpans().At(td.ResourceSpans().Len() - 1)
+		s := rs.ScopeSpans().At(0).Spans().AppendEmpty()
+		s.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, byte(i / 4)})
+		s.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, byte(i)})
+		if i%4 != 0 {
+			s.SetParentSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, byte(i - i%4)})
+		}
+		s.SetName("GET /balance")
+		s.SetKind(ptrace.SpanKindServer)
+		s.SetStartTimestamp(testTimestamp)
+		s.SetEndTimestamp(testTimestamp + 1e6)
+		s.Attributes().PutStr("http.method", "GET")
+		s.Attributes().PutInt("http.status_code", 200)
+		event := s.Events().AppendEmpty()
+		event.SetName("cache miss")
+		event.SetTimestamp(testTimestamp + 5e5)
+	}
+	return td
+}
+
+// BenchmarkCSVMarshaler reports the throughput and the allocations of the
+// CSV marshaler for every signal and batch size, e.g.
+//
+//	go test -run '^$' -bench CSVMarshaler -benchmem
+func BenchmarkCSVMarshaler(b *testing.B) {
+	m := marshaler.NewCSVMarshaler()
+	for _, n := range benchmarkBatchSizes {
+		ld, md, td := benchmarkLogs(n), benchmarkMetrics(n), benchmarkTraces(n)
+		for _, bb := range []struct {
+			name    string
+			marshal func() ([]byte, error)
+		}{
+			{"logs", func() ([]byte, error) { return m.MarshalLogs(ld) }},
+			{"metrics", func() ([]byte, error) { return m.MarshalMetrics(md) }},
+			{"traces", func() ([]byte, error) { return m.MarshalTraces(td) }},
+		} {
+			b.Run(fmt.Sprintf("%s/%d", bb.name, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					buf, err := bb.marshal()
+					if err != nil {
+						b.Fatalf("Marshal() failed: %v", err)
+					}
+					b.SetBytes(int64(len(buf)))
+				}
+				b.ReportMetric(float64(n)*float64(b.N)/b.Elapsed().Seconds(), "records/s")
+			})
+		}
+	}
+}
+
+// TestCSVMarshalerAllocations guards the hot path against regressions:
+// writing a batch allocates a bounded number of times whatever its size, as
+// rows are built in pooled buffers.
+func TestCSVMarshalerAllocations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("allocations are measured over many runs")
+	}
+	// Pooled buffers can be dropped by the garbage collector, or by the race
+	// detector, between runs, so a few allocations are tolerated.
+	const maxAllocs = 10
+	m := marshaler.NewCSVMarshaler()
+	ld, md, td := benchmarkLogs(1000), benchmarkMetrics(1000), benchmarkTraces(1000)
+	for name, write := range map[string]func() error{
+		"logs":    func() error { return m.WriteLogs(io.Discard, ld) },
+		"metrics": func() error { return m.WriteMetrics(io.Discard, md) },
+		"traces":  func() error { return m.WriteTraces(io.Discard, td) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := write(); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			allocs := testing.AllocsPerRun(20, func() {
+				_ = write()
+			})
+			if allocs > maxAllocs {
+				t.Errorf("Expected at most %d allocations per batch of 1000 records, got %.1f", maxAllocs, allocs)
+			}
+		})
+	}
+}
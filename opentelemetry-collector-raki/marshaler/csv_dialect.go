@@ -0,0 +1,391 @@
+package marshaler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// TimestampFormat selects how timestamp columns are written.
+type TimestampFormat string
+
+const (
+	// TimestampFormatRFC3339Nano writes timestamps as RFC 3339 in UTC with
+	// nanosecond precision, e.g. 2006-01-02T15:04:05.999999999Z.
+	TimestampFormatRFC3339Nano TimestampFormat = "rfc3339_nano"
+
+	// TimestampFormatUnixNano writes timestamps as nanoseconds since the Unix epoch.
+	TimestampFormatUnixNano TimestampFormat = "unix_nano"
+
+	// TimestampFormatUnixMilli writes timestamps as milliseconds since the
+	// Unix epoch, dropping sub-millisecond precision.
+	TimestampFormatUnixMilli TimestampFormat = "unix_milli"
+)
+
+// QuoteMode selects which fields are enclosed in double quotes.
+type QuoteMode string
+
+const (
+	// QuoteModeMinimal only quotes fields that contain the delimiter, a
+	// quote, a line break or leading spaces.
+	QuoteModeMinimal QuoteMode = "minimal"
+
+	// QuoteModeAll quotes every field.
+	QuoteModeAll QuoteMode = "all"
+)
+
+// MetricsLayout selects how metrics are laid out in rows and columns.
+type MetricsLayout string
+
+const (
+	// MetricsLayoutLong writes one row per data point following
+	// metricsCSVHeader.
+	MetricsLayoutLong MetricsLayout = "long"
+
+	// MetricsLayoutWide pivots the data points into one row per timestamp,
+	// start timestamp and series, with one column per metric, see
+	// CSVMarshaler.WriteMetrics.
+	MetricsLayoutWide MetricsLayout = "wide"
+)
+
+// CSVColumns selects the columns written for each signal, in order. An empty
+// list keeps the full layout of the signal, see logsCSVHeader,
+// metricsCSVHeader and tracesCSVHeader.
+type CSVColumns struct {
+	Logs    []string `mapstructure:"logs"`
+	Metrics []string `mapstructure:"metrics"`
+	Traces  []string `mapstructure:"traces"`
+}
+
+// validateDelimiter checks that a delimiter is a single rune usable by encoding/csv.
+func validateDelimiter(delimiter string) error {
+	if delimiter == "" {
+		return nil
+	}
+	r, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return fmt.Errorf("invalid delimiter: %q", delimiter)
+	}
+	return nil
+}
+
+// columnIndexes resolves column names into their index in a layout. An empty
+// list of names resolves to nil, meaning the full layout.
+func columnIndexes(layout []string, names []string) ([]int, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	indexes := make([]int, len(names))
+	for i, name := range names {
+		indexes[i] = -1
+		for j, column := range layout {
+			if column == name {
+				indexes[i] = j
+				break
+			}
+		}
+		if indexes[i] < 0 {
+			return nil, fmt.Errorf("unknown column: %s", name)
+		}
+	}
+	return indexes, nil
+}
+
+// delimiter returns the configured delimiter, defaulting to a comma.
+func (m CSVMarshaler) delimiter() rune {
+	if m.config.Delimiter == "" {
+		return ','
+	}
+	r, _ := utf8.DecodeRuneInString(m.config.Delimiter)
+	return r
+}
+
+// formatTimestamp formats a timestamp for a CSV column. Unset timestamps are
+// written as empty columns.
+func (m CSVMarshaler) formatTimestamp(ts pcommon.Timestamp) string {
+	return string(m.appendTimestamp(nil, ts))
+}
+
+// appendTimestamp appends a timestamp as formatTimestamp formats it.
+func (m CSVMarshaler) appendTimestamp(dst []byte, ts pcommon.Timestamp) []byte {
+	if ts == 0 {
+		return dst
+	}
+	switch m.config.TimestampFormat {
+	case TimestampFormatUnixNano:
+		return strconv.AppendUint(dst, uint64(ts), 10)
+	case TimestampFormatUnixMilli:
+		return strconv.AppendInt(dst, ts.AsTime().UnixMilli(), 10)
+	default:
+		return ts.AsTime().UTC().AppendFormat(dst, time.RFC3339Nano)
+	}
+}
+
+// parseTimestamp parses a timestamp written by formatTimestamp.
+func (m CSVMarshaler) parseTimestamp(s string) (pcommon.Timestamp, error) {
+	if s == "" {
+		return 0, nil
+	}
+	switch m.config.TimestampFormat {
+	case TimestampFormatUnixNano:
+		ns, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		return pcommon.Timestamp(ns), nil
+	case TimestampFormatUnixMilli:
+		ms, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		return pcommon.NewTimestampFromTime(time.UnixMilli(ms)), nil
+	default:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		return pcommon.NewTimestampFromTime(t), nil
+	}
+}
+
+// csvWriterBufferSize is the initial size of the output buffer of a
+// csvWriter. Rows are written to the underlying writer whenever it is full.
+const csvWriterBufferSize = 64 * 1024
+
+// maxPooledBufferSize bounds the buffers of the writers kept in
+// csvWriterPool, so that a single huge row does not stay in memory.
+const maxPooledBufferSize = 1 << 20
+
+// csvWriterPool holds the writers, and their buffers, of completed calls.
+var csvWriterPool = sync.Pool{
+	New: func() any {
+		return &csvWriter{buf: make([]byte, 0, csvWriterBufferSize)}
+	},
+}
+
+// csvWriter writes records of a layout following the configured dialect:
+// only the selected columns are written, empty fields are replaced by the
+// null representation and fields are quoted according to the quote mode.
+//
+// Writers are pooled along with their buffers: rows are built in row, the
+// attributes of the current resource are encoded once into resource, and the
+// output is buffered in buf.
+type csvWriter struct {
+	w        io.Writer
+	buf      []byte
+	columns  []int
+	comma    rune
+	quoteAll bool
+	null     []byte
+	header   bool
+	row      csvRow
+	resource []byte
+}
+
+// newWriter takes a csvWriter for the selected columns of a layout from the
+// pool. It must be released once done.
+func (m CSVMarshaler) newWriter(w io.Writer, columns []int) *csvWriter {
+	writer := csvWriterPool.Get().(*csvWriter)
+	writer.w = w
+	writer.buf = writer.buf[:0]
+	writer.columns = columns
+	writer.comma = m.delimiter()
+	writer.quoteAll = m.config.Quoting == QuoteModeAll
+	writer.null = append(writer.null[:0], m.config.NullValue...)
+	writer.header = !m.config.SkipHeader
+	return writer
+}
+
+// release returns the writer to the pool, unless its buffers grew too large.
+// The writer must not be used afterwards.
+func (w *csvWriter) release() {
+	w.w = nil
+	if cap(w.buf) > maxPooledBufferSize || cap(w.row.buf) > maxPooledBufferSize || cap(w.resource) > maxPooledBufferSize {
+		return
+	}
+	csvWriterPool.Put(w)
+}
+
+// startCSV creates the writer of a signal and writes its header: the mapped
+// columns when the signal has a column mapping, the selected columns of its
+// layout otherwise.
+func (m CSVMarshaler) startCSV(w io.Writer, layout []string, columns []int, mapper *columnMapper) (*csvWriter, error) {
+	if mapper != nil {
+		layout, columns = mapper.header, nil
+	}
+	writer := m.newWriter(w, columns)
+	if err := writer.WriteHeader(layout); err != nil {
+		writer.release()
+		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return writer, nil
+}
+
+// newRow resets the row of the writer for a layout of n columns.
+func (w *csvWriter) newRow(n int) *csvRow {
+	w.row.reset(n)
+	return &w.row
+}
+
+// WriteHeader writes the header of a layout unless headers are disabled.
+func (w *csvWriter) WriteHeader(layout []string) error {
+	if !w.header {
+		return nil
+	}
+	return w.writeStrings(layout, false)
+}
+
+// Write writes a record of the layout.
+func (w *csvWriter) Write(record []string) error {
+	return w.writeStrings(record, true)
+}
+
+func (w *csvWriter) writeStrings(record []string, nullable bool) error {
+	row := w.newRow(len(record))
+	for col, field := range record {
+		row.setString(col, field)
+	}
+	return w.writeRow(row, nullable)
+}
+
+// WriteRow writes a row of the layout.
+func (w *csvWriter) WriteRow(row *csvRow) error {
+	return w.writeRow(row, true)
+}
+
+func (w *csvWriter) writeRow(row *csvRow, nullable bool) error {
+	if w.columns == nil {
+		for col := range row.fields {
+			w.appendField(col > 0, row.field(col), nullable)
+		}
+	} else {
+		for i, col := range w.columns {
+			w.appendField(i > 0, row.field(col), nullable)
+		}
+	}
+	w.buf = append(w.buf, '\n')
+	if len(w.buf) >= csvWriterBufferSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// appendField appends a field to the output, preceded by the delimiter.
+func (w *csvWriter) appendField(delimited bool, field []byte, nullable bool) {
+	if delimited {
+		w.buf = utf8.AppendRune(w.buf, w.comma)
+	}
+	if nullable && len(field) == 0 {
+		field = w.null
+	}
+	if !w.quoteAll && !w.fieldNeedsQuotes(field) {
+		w.buf = append(w.buf, field...)
+		return
+	}
+	w.buf = append(w.buf, '"')
+	for {
+		i := bytes.IndexByte(field, '"')
+		if i < 0 {
+			break
+		}
+		w.buf = append(w.buf, field[:i+1]...)
+		w.buf = append(w.buf, '"')
+		field = field[i+1:]
+	}
+	w.buf = append(w.buf, field...)
+	w.buf = append(w.buf, '"')
+}
+
+// fieldNeedsQuotes follows the quoting rules of encoding/csv.Writer.
+func (w *csvWriter) fieldNeedsQuotes(field []byte) bool {
+	if len(field) == 0 {
+		return false
+	}
+	if string(field) == `\.` {
+		return true
+	}
+	if bytes.ContainsRune(field, w.comma) || bytes.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRune(field)
+	return r == ' ' || r == '\t'
+}
+
+// Flush writes the buffered rows to the underlying io.Writer.
+func (w *csvWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+// readCSV reads the records of a CSV byte array and maps them back onto the
+// full layout of a signal. With headers enabled the columns are taken from
+// the header, otherwise they are the configured columns. Columns absent from
+// the CSV are left empty.
+func (m CSVMarshaler) readCSV(buf []byte, layout []string, columns []int) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(buf))
+	reader.Comma = m.delimiter()
+	reader.FieldsPerRecord = -1
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	if !m.config.SkipHeader {
+		if len(lines) < 1 {
+			return nil, errors.New("missing CSV header")
+		}
+		if columns, err = columnIndexes(layout, lines[0]); err != nil {
+			return nil, err
+		}
+		lines = lines[1:]
+	}
+	if columns == nil {
+		columns = make([]int, len(layout))
+		for i := range columns {
+			columns[i] = i
+		}
+	}
+
+	records := make([][]string, len(lines))
+	for i, line := range lines {
+		if len(line) != len(columns) {
+			return nil, errors.New("invalid CSV format")
+		}
+		record := make([]string, len(layout))
+		for j, col := range columns {
+			if line[j] != m.config.NullValue {
+				record[col] = line[j]
+			}
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// parseOptionalInt parses an integer column, an absent value being zero.
+func parseOptionalInt(s string, bitSize int) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, bitSize)
+}
+
+// parseOptionalUint parses an unsigned integer column, an absent value being zero.
+func parseOptionalUint(s string, bitSize int) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 10, bitSize)
+}
@@ -0,0 +1,106 @@
+package marshaler
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// Column indexes of the logs CSV layout, see logsCSVHeader.
+const (
+	logColTimestamp = iota
+	logColSeverity
+	logColBody
+	logColAttributes
+	logColSeverityNumber
+	logColTraceID
+	logColSpanID
+	logColFlags
+	logColResourceAttributes
+	logColScopeName
+	logColScopeVersion
+	numLogColumns
+)
+
+// logsCSVHeader is the column layout of the logs CSV, one row per log record:
+//
+//	timestamp             log record timestamp
+//	severity              severity text
+//	body                  body, encoded as attributes are
+//	attributes            log record attributes
+//	severity_number       numeric severity, 0 when unspecified
+//	trace_id              hex encoded trace ID of the correlated span
+//	span_id               hex encoded span ID of the correlated span
+//	flags                 trace flags
+//	resource_attributes   attributes of the resource that emitted the record
+//	scope_name            instrumentation scope name
+//	scope_version         instrumentation scope version
+var logsCSVHeader = []string{
+	"timestamp",
+	"severity",
+	"body",
+	"attributes",
+	"severity_number",
+	"trace_id",
+	"span_id",
+	"flags",
+	"resource_attributes",
+	"scope_name",
+	"scope_version",
+}
+
+// logToCSVRow builds the row of a log record. The resource and scope columns
+// are left for the caller to fill.
+func (m CSVMarshaler) logToCSVRow(row *csvRow, lr plog.LogRecord) error {
+	row.reset(numLogColumns)
+	m.setTimestamp(row, logColTimestamp, lr.Timestamp())
+	row.setString(logColSeverity, lr.SeverityText())
+	m.setBody(row, logColBody, lr.Body())
+	if err := m.setAttributes(row, logColAttributes, lr.Attributes()); err != nil {
+		return err
+	}
+	row.setInt(logColSeverityNumber, int64(lr.SeverityNumber()))
+	traceID, spanID := lr.TraceID(), lr.SpanID()
+	row.setID(logColTraceID, traceID[:])
+	row.setID(logColSpanID, spanID[:])
+	row.setUint(logColFlags, uint64(lr.Flags()))
+	return nil
+}
+
+// logFromCSVRecord fills a log record from a CSV record.
+func (m CSVMarshaler) logFromCSVRecord(lr plog.LogRecord, record []string) error {
+	timestamp, err := m.parseTimestamp(record[logColTimestamp])
+	if err != nil {
+		return err
+	}
+	severityNumber, err := parseOptionalInt(record[logColSeverityNumber], 32)
+	if err != nil {
+		return fmt.Errorf("failed to parse severity number: %w", err)
+	}
+	traceID, err := parseTraceID(record[logColTraceID])
+	if err != nil {
+		return err
+	}
+	spanID, err := parseSpanID(record[logColSpanID])
+	if err != nil {
+		return err
+	}
+	flags, err := parseOptionalUint(record[logColFlags], 32)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	lr.SetTimestamp(timestamp)
+	lr.SetSeverityText(record[logColSeverity])
+	lr.SetSeverityNumber(plog.SeverityNumber(severityNumber))
+	if err := m.decodeBody(record[logColBody], lr.Body()); err != nil {
+		return fmt.Errorf("failed to parse body: %w", err)
+	}
+	if err := m.decodeAttributes(record[logColAttributes], lr.Attributes()); err != nil {
+		return fmt.Errorf("failed to parse attributes: %w", err)
+	}
+	lr.SetTraceID(traceID)
+	lr.SetSpanID(spanID)
+	lr.SetFlags(plog.LogRecordFlags(flags))
+	return nil
+}
@@ -0,0 +1,718 @@
+package marshaler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// Column indexes of the metrics CSV layout, see metricsCSVHeader.
+const (
+	metricColTimestamp = iota
+	metricColName
+	metricColType
+	metricColValueType
+	metricColValue
+	metricColCount
+	metricColSum
+	metricColMin
+	metricColMax
+	metricColBucketCounts
+	metricColExplicitBounds
+	metricColScale
+	metricColZeroCount
+	metricColPositiveOffset
+	metricColPositiveBucketCounts
+	metricColNegativeOffset
+	metricColNegativeBucketCounts
+	metricColQuantiles
+	metricColAggregationTemporality
+	metricColIsMonotonic
+	metricColStartTimestamp
+	metricColUnit
+	metricColDescription
+	metricColAttributes
+	metricColResourceAttributes
+	metricColScopeName
+	metricColScopeVersion
+	metricColFlags
+	metricColExemplars
+	numMetricColumns
+)
+
+// metricsCSVHeader is the column layout of the metrics CSV:
+//
+//	timestamp                 data point timestamp
+//	metric_name               name of the metric
+//	metric_type               Gauge, Sum, Histogram, ExponentialHistogram or Summary
+//	value_type                Int or Double, only for Gauge and Sum
+//	value                     data point value, only for Gauge and Sum
+//	count                     number of observations, Histogram, ExponentialHistogram and Summary
+//	sum                       sum of observations, empty when not recorded
+//	min                       minimum observation, empty when not recorded
+//	max                       maximum observation, empty when not recorded
+//	bucket_counts             Histogram bucket counts separated by ';'
+//	explicit_bounds           Histogram bucket boundaries separated by ';'
+//	scale                     ExponentialHistogram scale
+//	zero_count                ExponentialHistogram zero bucket count
+//	positive_offset           ExponentialHistogram positive bucket offset
+//	positive_bucket_counts    ExponentialHistogram positive bucket counts separated by ';'
+//	negative_offset           ExponentialHistogram negative bucket offset
+//	negative_bucket_counts    ExponentialHistogram negative bucket counts separated by ';'
+//	quantiles                 Summary quantiles as 'quantile:value' pairs separated by ';'
+//	aggregation_temporality   Delta or Cumulative, Sum, Histogram and ExponentialHistogram
+//	is_monotonic              true or false, only for Sum
+//	start_timestamp           start of the aggregation of cumulative and delta data points
+//	unit                      unit of the metric
+//	description               description of the metric
+//	attributes                data point attributes, identifying the series of the metric
+//	resource_attributes       attributes of the resource, e.g. the service.instance.id of an ATM
+//	scope_name                name of the instrumentation scope
+//	scope_version             version of the instrumentation scope
+//	flags                     data point flags, 1 when no value was recorded
+//	exemplars                 OTLP/JSON array of the exemplars, except for Summary
+var metricsCSVHeader = []string{
+	"timestamp",
+	"metric_name",
+	"metric_type",
+	"value_type",
+	"value",
+	"count",
+	"sum",
+	"min",
+	"max",
+	"bucket_counts",
+	"explicit_bounds",
+	"scale",
+	"zero_count",
+	"positive_offset",
+	"positive_bucket_counts",
+	"negative_offset",
+	"negative_bucket_counts",
+	"quantiles",
+	"aggregation_temporality",
+	"is_monotonic",
+	"start_timestamp",
+	"unit",
+	"description",
+	"attributes",
+	"resource_attributes",
+	"scope_name",
+	"scope_version",
+	"flags",
+	"exemplars",
+}
+
+// listSeparator separates the elements of list-valued metrics columns.
+const listSeparator = ";"
+
+// metricToCSVRows builds the row of every data point of a metric and hands
+// it to write along with the attributes of the data point. The resource and
+// scope columns are left for write to fill.
+func (m CSVMarshaler) metricToCSVRows(row *csvRow, metric pmetric.Metric, write func(attributes pcommon.Map) error) error {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := metric.Gauge().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := m.numberDataPointToCSVRow(row, metric, dp); err != nil {
+				return err
+			}
+			if err := write(dp.Attributes()); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeSum:
+		sum := metric.Sum()
+		dps := sum.DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := m.numberDataPointToCSVRow(row, metric, dp); err != nil {
+				return err
+			}
+			row.setString(metricColAggregationTemporality, sum.AggregationTemporality().String())
+			row.setBool(metricColIsMonotonic, sum.IsMonotonic())
+			if err := write(dp.Attributes()); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeHistogram:
+		histogram := metric.Histogram()
+		dps := histogram.DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := m.newMetricCSVRow(row, metric, dp.Timestamp(), dp.StartTimestamp(), dp.Flags(), dp.Attributes()); err != nil {
+				return err
+			}
+			setExemplars(row, dp.Exemplars())
+			row.setUint(metricColCount, dp.Count())
+			if dp.HasSum() {
+				row.setFloat(metricColSum, dp.Sum())
+			}
+			if dp.HasMin() {
+				row.setFloat(metricColMin, dp.Min())
+			}
+			if dp.HasMax() {
+				row.setFloat(metricColMax, dp.Max())
+			}
+			start := row.mark()
+			row.buf = appendUints(row.buf, dp.BucketCounts())
+			row.end(metricColBucketCounts, start)
+			start = row.mark()
+			row.buf = appendFloats(row.buf, dp.ExplicitBounds())
+			row.end(metricColExplicitBounds, start)
+			row.setString(metricColAggregationTemporality, histogram.AggregationTemporality().String())
+			if err := write(dp.Attributes()); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		histogram := metric.ExponentialHistogram()
+		dps := histogram.DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := m.newMetricCSVRow(row, metric, dp.Timestamp(), dp.StartTimestamp(), dp.Flags(), dp.Attributes()); err != nil {
+				return err
+			}
+			setExemplars(row, dp.Exemplars())
+			row.setUint(metricColCount, dp.Count())
+			if dp.HasSum() {
+				row.setFloat(metricColSum, dp.Sum())
+			}
+			if dp.HasMin() {
+				row.setFloat(metricColMin, dp.Min())
+			}
+			if dp.HasMax() {
+				row.setFloat(metricColMax, dp.Max())
+			}
+			row.setInt(metricColScale, int64(dp.Scale()))
+			row.setUint(metricColZeroCount, dp.ZeroCount())
+			row.setInt(metricColPositiveOffset, int64(dp.Positive().Offset()))
+			start := row.mark()
+			row.buf = appendUints(row.buf, dp.Positive().BucketCounts())
+			row.end(metricColPositiveBucketCounts, start)
+			row.setInt(metricColNegativeOffset, int64(dp.Negative().Offset()))
+			start = row.mark()
+			row.buf = appendUints(row.buf, dp.Negative().BucketCounts())
+			row.end(metricColNegativeBucketCounts, start)
+			row.setString(metricColAggregationTemporality, histogram.AggregationTemporality().String())
+			if err := write(dp.Attributes()); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := m.newMetricCSVRow(row, metric, dp.Timestamp(), dp.StartTimestamp(), dp.Flags(), dp.Attributes()); err != nil {
+				return err
+			}
+			row.setUint(metricColCount, dp.Count())
+			row.setFloat(metricColSum, dp.Sum())
+			start := row.mark()
+			row.buf = appendCSVQuantiles(row.buf, dp.QuantileValues())
+			row.end(metricColQuantiles, start)
+			if err := write(dp.Attributes()); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeEmpty:
+		// A metric without data has no data points to write.
+	default:
+		return fmt.Errorf("unsupported metric type: %s", metric.Type())
+	}
+	return nil
+}
+
+// dataPoint holds the fields shared by the data points of every metric type.
+type dataPoint interface {
+	Timestamp() pcommon.Timestamp
+	SetTimestamp(pcommon.Timestamp)
+	StartTimestamp() pcommon.Timestamp
+	SetStartTimestamp(pcommon.Timestamp)
+	Flags() pmetric.DataPointFlags
+	Attributes() pcommon.Map
+}
+
+// newMetricCSVRow starts the row of a data point with the columns shared by
+// every metric type. The data point fields are passed rather than a
+// dataPoint, as converting a data point to an interface allocates.
+func (m CSVMarshaler) newMetricCSVRow(row *csvRow, metric pmetric.Metric, ts, start pcommon.Timestamp, flags pmetric.DataPointFlags, attrs pcommon.Map) error {
+	row.reset(numMetricColumns)
+	m.setTimestamp(row, metricColTimestamp, ts)
+	row.setString(metricColName, metric.Name())
+	row.setString(metricColType, metric.Type().String())
+	m.setTimestamp(row, metricColStartTimestamp, start)
+	row.setString(metricColUnit, metric.Unit())
+	row.setString(metricColDescription, metric.Description())
+	row.setUint(metricColFlags, uint64(flags))
+	return m.setAttributes(row, metricColAttributes, attrs)
+}
+
+// setExemplars sets the exemplars column of a Gauge, Sum, Histogram or
+// ExponentialHistogram data point.
+func setExemplars(row *csvRow, exemplars pmetric.ExemplarSlice) {
+	start := row.mark()
+	row.buf = appendExemplarsJSON(row.buf, exemplars)
+	row.end(metricColExemplars, start)
+}
+
+// numberDataPointToCSVRow builds the row of a Gauge or Sum data point.
+func (m CSVMarshaler) numberDataPointToCSVRow(row *csvRow, metric pmetric.Metric, dp pmetric.NumberDataPoint) error {
+	if err := m.newMetricCSVRow(row, metric, dp.Timestamp(), dp.StartTimestamp(), dp.Flags(), dp.Attributes()); err != nil {
+		return err
+	}
+	setExemplars(row, dp.Exemplars())
+	switch dp.ValueType() {
+	case pmetric.NumberDataPointValueTypeInt:
+		row.setString(metricColValueType, dp.ValueType().String())
+		row.setInt(metricColValue, dp.IntValue())
+	case pmetric.NumberDataPointValueTypeDouble:
+		row.setString(metricColValueType, dp.ValueType().String())
+		row.setFloat(metricColValue, dp.DoubleValue())
+	}
+	return nil
+}
+
+// formatFloat formats a float with the minimal precision that round-trips.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// appendFloat appends a float as formatFloat formats it.
+func appendFloat(dst []byte, f float64) []byte {
+	return strconv.AppendFloat(dst, f, 'g', -1, 64)
+}
+
+// appendUints appends unsigned integers separated by listSeparator.
+func appendUints(dst []byte, values pcommon.UInt64Slice) []byte {
+	for i := 0; i < values.Len(); i++ {
+		if i > 0 {
+			dst = append(dst, listSeparator...)
+		}
+		dst = strconv.AppendUint(dst, values.At(i), 10)
+	}
+	return dst
+}
+
+// appendFloats appends floats separated by listSeparator.
+func appendFloats(dst []byte, values pcommon.Float64Slice) []byte {
+	for i := 0; i < values.Len(); i++ {
+		if i > 0 {
+			dst = append(dst, listSeparator...)
+		}
+		dst = appendFloat(dst, values.At(i))
+	}
+	return dst
+}
+
+// appendCSVQuantiles appends summary quantiles as 'quantile:value' pairs
+// separated by listSeparator.
+func appendCSVQuantiles(dst []byte, quantiles pmetric.SummaryDataPointValueAtQuantileSlice) []byte {
+	for i := 0; i < quantiles.Len(); i++ {
+		if i > 0 {
+			dst = append(dst, listSeparator...)
+		}
+		q := quantiles.At(i)
+		dst = appendFloat(dst, q.Quantile())
+		dst = append(dst, ':')
+		dst = appendFloat(dst, q.Value())
+	}
+	return dst
+}
+
+// metricTypes maps the metric_type column back to a metric type.
+var metricTypes = map[string]pmetric.MetricType{
+	pmetric.MetricTypeGauge.String():                pmetric.MetricTypeGauge,
+	pmetric.MetricTypeSum.String():                  pmetric.MetricTypeSum,
+	pmetric.MetricTypeHistogram.String():            pmetric.MetricTypeHistogram,
+	pmetric.MetricTypeExponentialHistogram.String(): pmetric.MetricTypeExponentialHistogram,
+	pmetric.MetricTypeSummary.String():              pmetric.MetricTypeSummary,
+}
+
+// aggregationTemporalities maps the aggregation_temporality column back to a temporality.
+var aggregationTemporalities = map[string]pmetric.AggregationTemporality{
+	"": pmetric.AggregationTemporalityUnspecified,
+	pmetric.AggregationTemporalityUnspecified.String(): pmetric.AggregationTemporalityUnspecified,
+	pmetric.AggregationTemporalityDelta.String():       pmetric.AggregationTemporalityDelta,
+	pmetric.AggregationTemporalityCumulative.String():  pmetric.AggregationTemporalityCumulative,
+}
+
+// sameMetric reports whether two records hold data points of the same metric.
+func sameMetric(a, b []string) bool {
+	return a[metricColName] == b[metricColName] &&
+		a[metricColType] == b[metricColType] &&
+		a[metricColUnit] == b[metricColUnit] &&
+		a[metricColDescription] == b[metricColDescription] &&
+		a[metricColAggregationTemporality] == b[metricColAggregationTemporality] &&
+		a[metricColIsMonotonic] == b[metricColIsMonotonic]
+}
+
+// metricFromCSVRecord appends the data point held by a CSV record to a metric.
+// The metric is initialized from the record when it has no type yet.
+func (m CSVMarshaler) metricFromCSVRecord(metric pmetric.Metric, record []string) error {
+	metricType, ok := metricTypes[record[metricColType]]
+	if !ok {
+		return fmt.Errorf("unsupported metric type: %s", record[metricColType])
+	}
+	temporality, ok := aggregationTemporalities[record[metricColAggregationTemporality]]
+	if !ok {
+		return fmt.Errorf("unsupported aggregation temporality: %s", record[metricColAggregationTemporality])
+	}
+
+	if metric.Type() == pmetric.MetricTypeEmpty {
+		metric.SetName(record[metricColName])
+		metric.SetUnit(record[metricColUnit])
+		metric.SetDescription(record[metricColDescription])
+		switch metricType {
+		case pmetric.MetricTypeGauge:
+			metric.SetEmptyGauge()
+		case pmetric.MetricTypeSum:
+			sum := metric.SetEmptySum()
+			sum.SetAggregationTemporality(temporality)
+			sum.SetIsMonotonic(record[metricColIsMonotonic] == "true")
+		case pmetric.MetricTypeHistogram:
+			metric.SetEmptyHistogram().SetAggregationTemporality(temporality)
+		case pmetric.MetricTypeExponentialHistogram:
+			metric.SetEmptyExponentialHistogram().SetAggregationTemporality(temporality)
+		case pmetric.MetricTypeSummary:
+			metric.SetEmptySummary()
+		}
+	}
+
+	var dp dataPoint
+	p := metricRecordParser{record: record}
+	switch metricType {
+	case pmetric.MetricTypeGauge:
+		number := metric.Gauge().DataPoints().AppendEmpty()
+		p.numberDataPoint(number)
+		number.SetFlags(p.flags())
+		p.exemplars(number.Exemplars())
+		dp = number
+	case pmetric.MetricTypeSum:
+		number := metric.Sum().DataPoints().AppendEmpty()
+		p.numberDataPoint(number)
+		number.SetFlags(p.flags())
+		p.exemplars(number.Exemplars())
+		dp = number
+	case pmetric.MetricTypeHistogram:
+		histogram := metric.Histogram().DataPoints().AppendEmpty()
+		histogram.SetCount(p.uint(metricColCount))
+		if p.has(metricColSum) {
+			histogram.SetSum(p.float(metricColSum))
+		}
+		if p.has(metricColMin) {
+			histogram.SetMin(p.float(metricColMin))
+		}
+		if p.has(metricColMax) {
+			histogram.SetMax(p.float(metricColMax))
+		}
+		histogram.BucketCounts().FromRaw(p.uints(metricColBucketCounts))
+		histogram.ExplicitBounds().FromRaw(p.floats(metricColExplicitBounds))
+		histogram.SetFlags(p.flags())
+		p.exemplars(histogram.Exemplars())
+		dp = histogram
+	case pmetric.MetricTypeExponentialHistogram:
+		histogram := metric.ExponentialHistogram().DataPoints().AppendEmpty()
+		histogram.SetCount(p.uint(metricColCount))
+		if p.has(metricColSum) {
+			histogram.SetSum(p.float(metricColSum))
+		}
+		if p.has(metricColMin) {
+			histogram.SetMin(p.float(metricColMin))
+		}
+		if p.has(metricColMax) {
+			histogram.SetMax(p.float(metricColMax))
+		}
+		histogram.SetScale(int32(p.int(metricColScale)))
+		histogram.SetZeroCount(p.uint(metricColZeroCount))
+		histogram.Positive().SetOffset(int32(p.int(metricColPositiveOffset)))
+		histogram.Positive().BucketCounts().FromRaw(p.uints(metricColPositiveBucketCounts))
+		histogram.Negative().SetOffset(int32(p.int(metricColNegativeOffset)))
+		histogram.Negative().BucketCounts().FromRaw(p.uints(metricColNegativeBucketCounts))
+		histogram.SetFlags(p.flags())
+		p.exemplars(histogram.Exemplars())
+		dp = histogram
+	case pmetric.MetricTypeSummary:
+		summary := metric.Summary().DataPoints().AppendEmpty()
+		summary.SetCount(p.uint(metricColCount))
+		summary.SetSum(p.float(metricColSum))
+		p.quantiles(summary.QuantileValues(), metricColQuantiles)
+		summary.SetFlags(p.flags())
+		dp = summary
+	}
+	if p.err != nil {
+		return p.err
+	}
+	return m.dataPointFromCSVRecord(dp, record)
+}
+
+// dataPointFromCSVRecord fills the timestamps and attributes of a data point
+// from a CSV record.
+func (m CSVMarshaler) dataPointFromCSVRecord(dp dataPoint, record []string) error {
+	timestamp, err := m.parseTimestamp(record[metricColTimestamp])
+	if err != nil {
+		return err
+	}
+	dp.SetTimestamp(timestamp)
+	if record[metricColStartTimestamp] != "" {
+		start, err := m.parseTimestamp(record[metricColStartTimestamp])
+		if err != nil {
+			return err
+		}
+		dp.SetStartTimestamp(start)
+	}
+	if err := m.decodeAttributes(record[metricColAttributes], dp.Attributes()); err != nil {
+		return fmt.Errorf("failed to parse attributes: %w", err)
+	}
+	return nil
+}
+
+// metricRecordParser parses the numeric columns of a metrics CSV record. The
+// first parse failure is kept in err and later calls become no-ops, so a
+// record can be parsed without checking every column individually.
+type metricRecordParser struct {
+	record []string
+	err    error
+}
+
+// has reports whether a column holds a value.
+func (p *metricRecordParser) has(col int) bool {
+	return p.record[col] != ""
+}
+
+func (p *metricRecordParser) fail(col int, err error) {
+	if p.err == nil {
+		p.err = fmt.Errorf("failed to parse %s: %w", metricsCSVHeader[col], err)
+	}
+}
+
+func (p *metricRecordParser) int(col int) int64 {
+	if p.err != nil || !p.has(col) {
+		return 0
+	}
+	v, err := strconv.ParseInt(p.record[col], 10, 64)
+	if err != nil {
+		p.fail(col, err)
+	}
+	return v
+}
+
+func (p *metricRecordParser) uint(col int) uint64 {
+	if p.err != nil || !p.has(col) {
+		return 0
+	}
+	v, err := strconv.ParseUint(p.record[col], 10, 64)
+	if err != nil {
+		p.fail(col, err)
+	}
+	return v
+}
+
+func (p *metricRecordParser) float(col int) float64 {
+	if p.err != nil || !p.has(col) {
+		return 0
+	}
+	v, err := strconv.ParseFloat(p.record[col], 64)
+	if err != nil {
+		p.fail(col, err)
+	}
+	return v
+}
+
+func (p *metricRecordParser) uints(col int) []uint64 {
+	if p.err != nil || !p.has(col) {
+		return nil
+	}
+	parts := strings.Split(p.record[col], listSeparator)
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			p.fail(col, err)
+			return nil
+		}
+		values[i] = v
+	}
+	return values
+}
+
+func (p *metricRecordParser) floats(col int) []float64 {
+	if p.err != nil || !p.has(col) {
+		return nil
+	}
+	parts := strings.Split(p.record[col], listSeparator)
+	values := make([]float64, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			p.fail(col, err)
+			return nil
+		}
+		values[i] = v
+	}
+	return values
+}
+
+func (p *metricRecordParser) quantiles(dest pmetric.SummaryDataPointValueAtQuantileSlice, col int) {
+	if p.err != nil || !p.has(col) {
+		return
+	}
+	for _, part := range strings.Split(p.record[col], listSeparator) {
+		quantile, value, ok := strings.Cut(part, ":")
+		if !ok {
+			p.fail(col, errors.New("missing ':' between quantile and value"))
+			return
+		}
+		q, err := strconv.ParseFloat(quantile, 64)
+		if err != nil {
+			p.fail(col, err)
+			return
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			p.fail(col, err)
+			return
+		}
+		qv := dest.AppendEmpty()
+		qv.SetQuantile(q)
+		qv.SetValue(v)
+	}
+}
+
+// flags parses the flags column, no flags when empty.
+func (p *metricRecordParser) flags() pmetric.DataPointFlags {
+	if !p.has(metricColFlags) {
+		return 0
+	}
+	return pmetric.DataPointFlags(p.uint(metricColFlags))
+}
+
+// exemplars parses the OTLP/JSON array of the exemplars column into dest.
+func (p *metricRecordParser) exemplars(dest pmetric.ExemplarSlice) {
+	if p.err != nil || !p.has(metricColExemplars) {
+		return
+	}
+	if err := exemplarsFromJSONString(p.record[metricColExemplars], dest); err != nil {
+		p.fail(metricColExemplars, err)
+	}
+}
+
+// numberDataPoint fills a Gauge or Sum data point from the record.
+func (p *metricRecordParser) numberDataPoint(dp pmetric.NumberDataPoint) {
+	switch p.record[metricColValueType] {
+	case pmetric.NumberDataPointValueTypeInt.String():
+		dp.SetIntValue(p.int(metricColValue))
+	case pmetric.NumberDataPointValueTypeDouble.String():
+		dp.SetDoubleValue(p.float(metricColValue))
+	}
+}
+
+// jsonExemplar is the OTLP/JSON representation of an exemplar, with hex
+// encoded IDs. Filtered attributes always use the typed encoding.
+type jsonExemplar struct {
+	FilteredAttributes []jsonKeyValue  `json:"filteredAttributes,omitempty"`
+	TimeUnixNano       string          `json:"timeUnixNano,omitempty"`
+	AsDouble           json.RawMessage `json:"asDouble,omitempty"`
+	AsInt              string          `json:"asInt,omitempty"`
+	SpanID             string          `json:"spanId,omitempty"`
+	TraceID            string          `json:"traceId,omitempty"`
+}
+
+// appendExemplarsJSON appends exemplars as a JSON array of jsonExemplar,
+// nothing when there are no exemplars.
+func appendExemplarsJSON(dst []byte, exemplars pmetric.ExemplarSlice) []byte {
+	if exemplars.Len() == 0 {
+		return dst
+	}
+	dst = append(dst, '[')
+	for i := 0; i < exemplars.Len(); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		exemplar := exemplars.At(i)
+		dst = append(dst, '{')
+		if exemplar.FilteredAttributes().Len() > 0 {
+			dst = append(dst, `"filteredAttributes":`...)
+			dst = appendKeyValuesJSON(dst, exemplar.FilteredAttributes())
+			dst = append(dst, ',')
+		}
+		dst = append(dst, `"timeUnixNano":"`...)
+		dst = strconv.AppendUint(dst, uint64(exemplar.Timestamp()), 10)
+		dst = append(dst, '"')
+		switch exemplar.ValueType() {
+		case pmetric.ExemplarValueTypeDouble:
+			dst = append(dst, `,"asDouble":`...)
+			dst = appendDoubleJSON(dst, exemplar.DoubleValue())
+		case pmetric.ExemplarValueTypeInt:
+			dst = append(dst, `,"asInt":"`...)
+			dst = strconv.AppendInt(dst, exemplar.IntValue(), 10)
+			dst = append(dst, '"')
+		}
+		if spanID := exemplar.SpanID(); !spanID.IsEmpty() {
+			dst = append(dst, `,"spanId":"`...)
+			dst = appendID(dst, spanID[:])
+			dst = append(dst, '"')
+		}
+		if traceID := exemplar.TraceID(); !traceID.IsEmpty() {
+			dst = append(dst, `,"traceId":"`...)
+			dst = appendID(dst, traceID[:])
+			dst = append(dst, '"')
+		}
+		dst = append(dst, '}')
+	}
+	return append(dst, ']')
+}
+
+// exemplarsFromJSONString parses an OTLP/JSON array of exemplars into dest.
+func exemplarsFromJSONString(s string, dest pmetric.ExemplarSlice) error {
+	if s == "" {
+		return nil
+	}
+	var jexemplars []jsonExemplar
+	if err := json.Unmarshal([]byte(s), &jexemplars); err != nil {
+		return err
+	}
+	dest.EnsureCapacity(len(jexemplars))
+	for _, jexemplar := range jexemplars {
+		timestamp, err := parseOptionalUint(jexemplar.TimeUnixNano, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse exemplar timestamp: %w", err)
+		}
+		traceID, err := parseTraceID(jexemplar.TraceID)
+		if err != nil {
+			return err
+		}
+		spanID, err := parseSpanID(jexemplar.SpanID)
+		if err != nil {
+			return err
+		}
+		exemplar := dest.AppendEmpty()
+		exemplar.SetTimestamp(pcommon.Timestamp(timestamp))
+		switch {
+		case jexemplar.AsDouble != nil:
+			f, err := doubleFromJSON(jexemplar.AsDouble)
+			if err != nil {
+				return fmt.Errorf("failed to parse exemplar value: %w", err)
+			}
+			exemplar.SetDoubleValue(f)
+		case jexemplar.AsInt != "":
+			v, err := strconv.ParseInt(jexemplar.AsInt, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse exemplar value: %w", err)
+			}
+			exemplar.SetIntValue(v)
+		}
+		exemplar.SetTraceID(traceID)
+		exemplar.SetSpanID(spanID)
+		if err := mapFromJSON(jexemplar.FilteredAttributes, exemplar.FilteredAttributes()); err != nil {
+			return fmt.Errorf("exemplar: %w", err)
+		}
+	}
+	return nil
+}
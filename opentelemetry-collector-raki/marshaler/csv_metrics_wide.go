@@ -0,0 +1,207 @@
+package marshaler
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// wideMetricsKeyColumns are the leading columns of the wide metrics layout,
+// identifying the series and time of a row. The metric columns follow.
+var wideMetricsKeyColumns = []string{
+	"timestamp",
+	"start_timestamp",
+	"resource_attributes",
+	"attributes",
+}
+
+// wideMetricsKey identifies a row of the wide metrics layout.
+type wideMetricsKey struct {
+	timestamp, startTimestamp, resource, attributes string
+}
+
+// wideMetricsRow holds the metric values of a row by column name.
+type wideMetricsRow struct {
+	wideMetricsKey
+	values map[string]string
+}
+
+// writeWideMetrics writes metrics pivoted into one row per timestamp, start
+// timestamp and series, a series being the resource attributes and data
+// point attributes of a data point, and one column per metric after
+// wideMetricsKeyColumns:
+//
+//	timestamp,start_timestamp,resource_attributes,attributes,atm.cash,atm.cash_unit
+//
+// Gauges and sums are written as a column named after the metric, holding
+// their value. Histograms, exponential histograms and summaries are written
+// as name_count and name_sum columns. The unit and description of a metric,
+// when set, are repeated in name_unit and name_description columns. Metric
+// columns are sorted by name and rows are in order of first appearance.
+// Cells without a data point are left empty, and when several data points
+// fall into the same cell the last one wins. Metrics writing the same column,
+// such as a gauge x_count and a histogram x, or a key column, such as a gauge
+// named timestamp, are rejected.
+//
+// The columns are only known once every data point is seen, so the output is
+// built in memory before being written.
+func (m CSVMarshaler) writeWideMetrics(w io.Writer, md pmetric.Metrics) error {
+	var rows []*wideMetricsRow
+	index := map[wideMetricsKey]*wideMetricsRow{}
+	// columns maps every metric column to the name of the metric writing it.
+	columns := map[string]string{}
+	set := func(resource, name string, dp dataPoint, column, value string) error {
+		if slices.Contains(wideMetricsKeyColumns, column) {
+			return fmt.Errorf("column %s of metric %s collides with a key column", column, name)
+		}
+		if owner, ok := columns[column]; ok && owner != name {
+			return fmt.Errorf("column %s of metric %s collides with metric %s", column, name, owner)
+		}
+		attributes, err := m.encodeAttributes(dp.Attributes())
+		if err != nil {
+			return fmt.Errorf("failed to serialize attributes: %w", err)
+		}
+		key := wideMetricsKey{m.formatTimestamp(dp.Timestamp()), m.formatTimestamp(dp.StartTimestamp()), resource, attributes}
+		row, ok := index[key]
+		if !ok {
+			row = &wideMetricsRow{wideMetricsKey: key, values: map[string]string{}}
+			index[key] = row
+			rows = append(rows, row)
+		}
+		row.values[column] = value
+		columns[column] = name
+		return nil
+	}
+
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		resource, err := m.encodeAttributes(rm.Resource().Attributes())
+		if err != nil {
+			return fmt.Errorf("failed to serialize resource attributes: %w", err)
+		}
+		ilms := rm.ScopeMetrics()
+		for j := 0; j < ilms.Len(); j++ {
+			metrics := ilms.At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				metric := metrics.At(k)
+				name := metric.Name()
+				if err := wideMetricValues(metric, func(dp dataPoint, column, value string) error {
+					if err := set(resource, name, dp, column, value); err != nil {
+						return err
+					}
+					if metric.Unit() != "" {
+						if err := set(resource, name, dp, name+"_unit", metric.Unit()); err != nil {
+							return err
+						}
+					}
+					if metric.Description() != "" {
+						return set(resource, name, dp, name+"_description", metric.Description())
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	metricColumns := make([]string, 0, len(columns))
+	for column := range columns {
+		metricColumns = append(metricColumns, column)
+	}
+	slices.Sort(metricColumns)
+	header := append(slices.Clone(wideMetricsKeyColumns), metricColumns...)
+
+	writer := m.newWriter(w, nil)
+	defer writer.release()
+	if err := writer.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	record := make([]string, len(header))
+	for _, row := range rows {
+		record[0], record[1], record[2], record[3] = row.timestamp, row.startTimestamp, row.resource, row.attributes
+		for n, column := range metricColumns {
+			record[len(wideMetricsKeyColumns)+n] = row.values[column]
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error during CSV writing: %w", err)
+	}
+	return nil
+}
+
+// wideMetricValues calls fn with the column and value of every cell held by
+// the data points of a metric.
+func wideMetricValues(metric pmetric.Metric, fn func(dp dataPoint, column, value string) error) error {
+	name := metric.Name()
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		return wideNumberValues(name, metric.Gauge().DataPoints(), fn)
+	case pmetric.MetricTypeSum:
+		return wideNumberValues(name, metric.Sum().DataPoints(), fn)
+	case pmetric.MetricTypeHistogram:
+		dps := metric.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := wideAggregateValues(name, dp, dp.Count(), dp.HasSum(), dp.Sum(), fn); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := wideAggregateValues(name, dp, dp.Count(), dp.HasSum(), dp.Sum(), fn); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := wideAggregateValues(name, dp, dp.Count(), true, dp.Sum(), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// wideNumberValues calls fn with the value of every Gauge or Sum data point.
+func wideNumberValues(name string, dps pmetric.NumberDataPointSlice, fn func(dp dataPoint, column, value string) error) error {
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		var value string
+		switch dp.ValueType() {
+		case pmetric.NumberDataPointValueTypeInt:
+			value = strconv.FormatInt(dp.IntValue(), 10)
+		case pmetric.NumberDataPointValueTypeDouble:
+			value = formatFloat(dp.DoubleValue())
+		default:
+			continue
+		}
+		if err := fn(dp, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wideAggregateValues calls fn with the count and, when recorded, the sum of
+// a Histogram, ExponentialHistogram or Summary data point.
+func wideAggregateValues(name string, dp dataPoint, count uint64, hasSum bool, sum float64, fn func(dp dataPoint, column, value string) error) error {
+	if err := fn(dp, name+"_count", strconv.FormatUint(count, 10)); err != nil {
+		return err
+	}
+	if !hasSum {
+		return nil
+	}
+	return fn(dp, name+"_sum", formatFloat(sum))
+}
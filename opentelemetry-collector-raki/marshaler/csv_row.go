@@ -0,0 +1,139 @@
+package marshaler
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// csvRow is a row of a CSV layout whose fields are formatted in place into a
+// single buffer. Rows are reset and reused for every record, so that once the
+// buffer has grown to the size of the largest row, building a row does not
+// allocate.
+type csvRow struct {
+	buf    []byte
+	fields []csvField
+}
+
+// csvField locates a field in the buffer of its row. Unset fields are empty.
+type csvField struct {
+	start, end int
+}
+
+// reset clears the row for a layout of n columns.
+func (r *csvRow) reset(n int) {
+	r.buf = r.buf[:0]
+	r.fields = slices.Grow(r.fields[:0], n)[:n]
+	clear(r.fields)
+}
+
+// mark returns the start of the next field. Once the field is appended to
+// buf, end sets it as a column.
+func (r *csvRow) mark() int {
+	return len(r.buf)
+}
+
+// end sets a column to what was appended to buf since start.
+func (r *csvRow) end(col, start int) {
+	r.fields[col] = csvField{start: start, end: len(r.buf)}
+}
+
+// field returns the value of a column. It is only valid until the row is
+// modified.
+func (r *csvRow) field(col int) []byte {
+	f := r.fields[col]
+	return r.buf[f.start:f.end]
+}
+
+func (r *csvRow) setString(col int, s string) {
+	start := r.mark()
+	r.buf = append(r.buf, s...)
+	r.end(col, start)
+}
+
+func (r *csvRow) setBytes(col int, b []byte) {
+	start := r.mark()
+	r.buf = append(r.buf, b...)
+	r.end(col, start)
+}
+
+func (r *csvRow) setInt(col int, v int64) {
+	start := r.mark()
+	r.buf = strconv.AppendInt(r.buf, v, 10)
+	r.end(col, start)
+}
+
+func (r *csvRow) setUint(col int, v uint64) {
+	start := r.mark()
+	r.buf = strconv.AppendUint(r.buf, v, 10)
+	r.end(col, start)
+}
+
+func (r *csvRow) setFloat(col int, f float64) {
+	start := r.mark()
+	r.buf = appendFloat(r.buf, f)
+	r.end(col, start)
+}
+
+func (r *csvRow) setBool(col int, b bool) {
+	start := r.mark()
+	r.buf = strconv.AppendBool(r.buf, b)
+	r.end(col, start)
+}
+
+// setID sets a column to a hex encoded trace or span ID.
+func (r *csvRow) setID(col int, id []byte) {
+	start := r.mark()
+	r.buf = appendID(r.buf, id)
+	r.end(col, start)
+}
+
+// strings returns the columns of the row as strings, as column mappings read
+// them.
+func (r *csvRow) strings() []string {
+	record := make([]string, len(r.fields))
+	for col := range r.fields {
+		record[col] = string(r.field(col))
+	}
+	return record
+}
+
+// setTimestamp sets a column to a timestamp, see formatTimestamp.
+func (m CSVMarshaler) setTimestamp(r *csvRow, col int, ts pcommon.Timestamp) {
+	start := r.mark()
+	r.buf = m.appendTimestamp(r.buf, ts)
+	r.end(col, start)
+}
+
+// setAttributes sets a column to the encoding of attributes, see
+// encodeAttributes.
+func (m CSVMarshaler) setAttributes(r *csvRow, col int, attrs pcommon.Map) error {
+	start := r.mark()
+	var err error
+	if r.buf, err = m.appendAttributes(r.buf, attrs); err != nil {
+		return fmt.Errorf("failed to serialize attributes: %w", err)
+	}
+	r.end(col, start)
+	return nil
+}
+
+// setBody sets a column to the encoding of a log body.
+func (m CSVMarshaler) setBody(r *csvRow, col int, body pcommon.Value) {
+	start := r.mark()
+	r.buf = m.appendBody(r.buf, body)
+	r.end(col, start)
+}
+
+// appendID appends a hex encoded trace or span ID, nothing for the empty ID
+// as the String methods of the IDs do.
+func appendID(dst, id []byte) []byte {
+	for _, b := range id {
+		if b != 0 {
+			return hex.AppendEncode(dst, id)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,744 @@
+package marshaler_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+var testTimestamp = pcommon.NewTimestampFromTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+
+func testLogs() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "default/atm-111/app/0")
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("filelog")
+	sl.Scope().SetVersion("v0.117.0")
+	logs := sl.LogRecords()
+
+	lr := logs.AppendEmpty()
+	lr.SetTimestamp(testTimestamp)
+	lr.SetSeverityText("INFO")
+	lr.SetSeverityNumber(plog.SeverityNumberInfo)
+	lr.Body().SetStr("hello, \"world\"")
+	lr.Attributes().PutStr("service", "atm")
+	lr.Attributes().PutInt("attempt", 3)
+	lr.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	lr.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	lr.SetFlags(plog.DefaultLogRecordFlags.WithIsSampled(true))
+
+	lr = rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetSeverityText("ERROR")
+	lr.Body().SetStr("multi\nline")
+
+	rl = ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "default/atm-222/app/0")
+	rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("second resource")
+	return ld
+}
+
+func testMetrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.instance.id", "atm-111")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("hostmetrics")
+	sm.Scope().SetVersion("v0.117.0")
+	metrics := sm.Metrics()
+
+	gauge := metrics.AppendEmpty()
+	gauge.SetName("int_gauge")
+	gauge.SetUnit("{bills}")
+	gauge.SetDescription("Bills left in the cassette")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(testTimestamp)
+	dp.SetIntValue(42)
+	dp.Attributes().PutStr("cassette", "a")
+	gauge.Gauge().DataPoints().AppendEmpty().SetIntValue(-1)
+
+	sum := metrics.AppendEmpty()
+	sum.SetName("double_sum")
+	s := sum.SetEmptySum()
+	s.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	s.SetIsMonotonic(true)
+	sdp0 := s.DataPoints().AppendEmpty()
+	sdp0.SetStartTimestamp(testTimestamp - 60e9)
+	sdp0.SetTimestamp(testTimestamp)
+	sdp0.SetDoubleValue(0.1)
+
+	histogram := metrics.AppendEmpty()
+	histogram.SetName("histogram")
+	h := histogram.SetEmptyHistogram()
+	h.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	hdp := h.DataPoints().AppendEmpty()
+	hdp.SetTimestamp(testTimestamp)
+	hdp.SetCount(6)
+	hdp.SetSum(12.5)
+	hdp.SetMin(0.5)
+	hdp.SetMax(7)
+	hdp.BucketCounts().FromRaw([]uint64{1, 2, 3})
+	hdp.ExplicitBounds().FromRaw([]float64{1, 5})
+
+	exponential := metrics.AppendEmpty()
+	exponential.SetName("exponential_histogram")
+	e := exponential.SetEmptyExponentialHistogram()
+	e.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	edp := e.DataPoints().AppendEmpty()
+	edp.SetCount(4)
+	edp.SetScale(-2)
+	edp.SetZeroCount(1)
+	edp.Positive().SetOffset(-3)
+	edp.Positive().BucketCounts().FromRaw([]uint64{1, 1})
+	edp.Negative().BucketCounts().FromRaw([]uint64{1})
+
+	summary := metrics.AppendEmpty()
+	summary.SetName("summary")
+	sdp := summary.SetEmptySummary().DataPoints().AppendEmpty()
+	sdp.SetCount(10)
+	sdp.SetSum(100)
+	q := sdp.QuantileValues().AppendEmpty()
+	q.SetQuantile(0.99)
+	q.SetValue(9.5)
+
+	rm = md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.instance.id", "atm-222")
+	gauge = rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	gauge.SetName("int_gauge")
+	gauge.SetUnit("{bills}")
+	gauge.SetDescription("Bills left in the cassette")
+	dp = gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(testTimestamp)
+	dp.SetIntValue(7)
+	dp.Attributes().PutStr("cassette", "a")
+	return md
+}
+
+func testTraces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "atm")
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("tailtracer")
+	spans := ss.Spans()
+
+	root := spans.AppendEmpty()
+	root.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	root.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	root.SetName("GET /balance")
+	root.SetKind(ptrace.SpanKindServer)
+	root.SetStartTimestamp(testTimestamp)
+	root.SetEndTimestamp(testTimestamp + 1e9)
+	root.Status().SetCode(ptrace.StatusCodeError)
+	root.Status().SetMessage("insufficient funds")
+	root.TraceState().FromRaw("atm=1")
+	root.Attributes().PutInt("atm.id", 111)
+	event := root.Events().AppendEmpty()
+	event.SetName("exception")
+	event.SetTimestamp(testTimestamp + 5e8)
+	event.Attributes().PutStr("exception.message", "balance too low")
+
+	child := spans.AppendEmpty()
+	child.SetTraceID(root.TraceID())
+	child.SetSpanID(pcommon.SpanID{8, 7, 6, 5, 4, 3, 2, 1})
+	child.SetParentSpanID(root.SpanID())
+	child.SetName("SELECT accounts")
+	child.SetKind(ptrace.SpanKindClient)
+	link := child.Links().AppendEmpty()
+	link.SetTraceID(pcommon.TraceID{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	link.SetSpanID(pcommon.SpanID{1, 1, 1, 1, 1, 1, 1, 1})
+	return td
+}
+
+func TestCSVLogsRoundTrip(t *testing.T) {
+	m := marshaler.NewCSVMarshaler()
+	for name, ld := range map[string]plog.Logs{"empty": plog.NewLogs(), "logs": testLogs()} {
+		t.Run(name, func(t *testing.T) {
+			first, err := m.MarshalLogs(ld)
+			if err != nil {
+				t.Fatalf("MarshalLogs() failed: %v", err)
+			}
+			decoded, err := m.UnmarshalLogs(first)
+			if err != nil {
+				t.Fatalf("UnmarshalLogs() failed: %v", err)
+			}
+			if decoded.ResourceLogs().Len() != ld.ResourceLogs().Len() {
+				t.Fatalf("Expected %d resources, got %d", ld.ResourceLogs().Len(), decoded.ResourceLogs().Len())
+			}
+			second, err := m.MarshalLogs(decoded)
+			if err != nil {
+				t.Fatalf("MarshalLogs() failed: %v", err)
+			}
+			if !bytes.Equal(first, second) {
+				t.Fatalf("Expected round-trip to be stable, got:\n%s\nwant:\n%s", second, first)
+			}
+		})
+	}
+}
+
+func TestCSVLogsBodyRoundTrip(t *testing.T) {
+	ld := plog.NewLogs()
+	logs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	logs.AppendEmpty().Body().SetStr("")
+	logs.AppendEmpty().Body().SetStr("{\"not\":\"a map\"}")
+	logs.AppendEmpty().Body().SetInt(42)
+	logs.AppendEmpty().Body().SetDouble(0.5)
+	logs.AppendEmpty().Body().SetBool(true)
+	logs.AppendEmpty().Body().SetEmptyBytes().FromRaw([]byte{0, 1, 2})
+	body := logs.AppendEmpty().Body().SetEmptyMap()
+	body.PutStr("user", "alice")
+	body.PutEmptySlice("items").AppendEmpty().SetInt(1)
+	logs.AppendEmpty()
+
+	m := marshaler.NewCSVMarshaler()
+	buf, err := m.MarshalLogs(ld)
+	if err != nil {
+		t.Fatalf("MarshalLogs() failed: %v", err)
+	}
+	decoded, err := m.UnmarshalLogs(buf)
+	if err != nil {
+		t.Fatalf("UnmarshalLogs() failed: %v", err)
+	}
+	got := decoded.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	if got.Len() != logs.Len() {
+		t.Fatalf("Expected %d log records, got %d", logs.Len(), got.Len())
+	}
+	for i := 0; i < logs.Len(); i++ {
+		want := logs.At(i).Body()
+		if body := got.At(i).Body(); body.Type() != want.Type() || !reflect.DeepEqual(body.AsRaw(), want.AsRaw()) {
+			t.Errorf("Expected body %d to be %s %v, got %s %v", i, want.Type(), want.AsRaw(), body.Type(), body.AsRaw())
+		}
+	}
+}
+
+func TestCSVMetricsRoundTrip(t *testing.T) {
+	m := marshaler.NewCSVMarshaler()
+	for name, md := range map[string]pmetric.Metrics{"empty": pmetric.NewMetrics(), "metrics": testMetrics()} {
+		t.Run(name, func(t *testing.T) {
+			first, err := m.MarshalMetrics(md)
+			if err != nil {
+				t.Fatalf("MarshalMetrics() failed: %v", err)
+			}
+			decoded, err := m.UnmarshalMetrics(first)
+			if err != nil {
+				t.Fatalf("UnmarshalMetrics() failed: %v", err)
+			}
+			if decoded.DataPointCount() != md.DataPointCount() {
+				t.Fatalf("Expected %d data points, got %d", md.DataPointCount(), decoded.DataPointCount())
+			}
+			second, err := m.MarshalMetrics(decoded)
+			if err != nil {
+				t.Fatalf("MarshalMetrics() failed: %v", err)
+			}
+			if !bytes.Equal(first, second) {
+				t.Fatalf("Expected round-trip to be stable, got:\n%s\nwant:\n%s", second, first)
+			}
+		})
+	}
+}
+
+// exemplarMetrics returns a gauge data point with exemplars and a histogram
+// data point without a recorded value.
+func exemplarMetrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	ms := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	gauge := ms.AppendEmpty()
+	gauge.SetName("atm.cash")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(testTimestamp)
+	dp.SetDoubleValue(1.5)
+	exemplar := dp.Exemplars().AppendEmpty()
+	exemplar.SetTimestamp(testTimestamp)
+	exemplar.SetDoubleValue(0.25)
+	exemplar.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	exemplar.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	exemplar.FilteredAttributes().PutStr("atm.id", "atm-111")
+	dp.Exemplars().AppendEmpty().SetIntValue(3)
+	histogram := ms.AppendEmpty()
+	histogram.SetName("atm.latency")
+	hdp := histogram.SetEmptyHistogram().DataPoints().AppendEmpty()
+	hdp.SetTimestamp(testTimestamp)
+	hdp.SetFlags(pmetric.DefaultDataPointFlags.WithNoRecordedValue(true))
+	return md
+}
+
+func TestCSVMetricsExemplars(t *testing.T) {
+	for name, m := range map[string]interface {
+		pmetric.Marshaler
+		pmetric.Unmarshaler
+	}{
+		"csv":     marshaler.NewCSVMarshaler(),
+		"parquet": marshaler.NewParquetMarshaler(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf, err := m.MarshalMetrics(exemplarMetrics())
+			if err != nil {
+				t.Fatalf("MarshalMetrics() failed: %v", err)
+			}
+			md, err := m.UnmarshalMetrics(buf)
+			if err != nil {
+				t.Fatalf("UnmarshalMetrics() failed: %v", err)
+			}
+			want, _ := (&pmetric.JSONMarshaler{}).MarshalMetrics(exemplarMetrics())
+			got, _ := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+			if string(got) != string(want) {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		})
+	}
+
+	buf, err := marshaler.NewCSVMarshalerWithConfig(marshaler.CSVConfig{
+		Columns: marshaler.CSVColumns{Metrics: []string{"metric_name", "flags", "exemplars"}},
+	}).MarshalMetrics(exemplarMetrics())
+	if err != nil {
+		t.Fatalf("MarshalMetrics() failed: %v", err)
+	}
+	want := "metric_name,flags,exemplars\n" +
+		`atm.cash,0,"[{""filteredAttributes"":[{""key"":""atm.id"",""value"":{""stringValue"":""atm-111""}}],""timeUnixNano"":""1735787045000000000"",` +
+		`""asDouble"":0.25,""spanId"":""0102030405060708"",""traceId"":""0102030405060708090a0b0c0d0e0f10""},{""timeUnixNano"":""0"",""asInt"":""3""}]"` + "\n" +
+		"atm.latency,1,\n"
+	if string(buf) != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, buf)
+	}
+}
+
+func TestCSVMetricsSeries(t *testing.T) {
+	m := marshaler.NewCSVMarshaler()
+	buf, err := m.MarshalMetrics(testMetrics())
+	if err != nil {
+		t.Fatalf("MarshalMetrics() failed: %v", err)
+	}
+	md, err := m.UnmarshalMetrics(buf)
+	if err != nil {
+		t.Fatalf("UnmarshalMetrics() failed: %v", err)
+	}
+	if md.ResourceMetrics().Len() != 2 {
+		t.Fatalf("Expected 2 resources, got %d", md.ResourceMetrics().Len())
+	}
+	rm := md.ResourceMetrics().At(1)
+	if id, _ := rm.Resource().Attributes().Get("service.instance.id"); id.Str() != "atm-222" {
+		t.Errorf("Expected service.instance.id atm-222, got %q", id.Str())
+	}
+	gauge := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+	if gauge.Unit() != "{bills}" || gauge.Description() != "Bills left in the cassette" {
+		t.Errorf("Expected unit and description to be kept, got %q and %q", gauge.Unit(), gauge.Description())
+	}
+	if cassette, _ := gauge.Gauge().DataPoints().At(0).Attributes().Get("cassette"); cassette.Str() != "a" {
+		t.Errorf("Expected cassette attribute a, got %q", cassette.Str())
+	}
+	sum := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(1).Sum().DataPoints().At(0)
+	if sum.StartTimestamp() != testTimestamp-60e9 {
+		t.Errorf("Expected start timestamp %v, got %v", testTimestamp-60e9, sum.StartTimestamp())
+	}
+}
+
+func TestCSVMetricsWide(t *testing.T) {
+	config := marshaler.CSVConfig{MetricsLayout: marshaler.MetricsLayoutWide, AttributeEncoding: marshaler.AttributeEncodingFlat}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	m := marshaler.NewCSVMarshalerWithConfig(config)
+	buf, err := m.MarshalMetrics(testMetrics())
+	if err != nil {
+		t.Fatalf("MarshalMetrics() failed: %v", err)
+	}
+	want := "timestamp,start_timestamp,resource_attributes,attributes,double_sum,exponential_histogram_count,histogram_count,histogram_sum," +
+		"int_gauge,int_gauge_description,int_gauge_unit,summary_count,summary_sum\n" +
+		"2025-01-02T03:04:05Z,,service.instance.id=atm-111,cassette=a,,,,,42,Bills left in the cassette,{bills},,\n" +
+		",,service.instance.id=atm-111,,,4,,,-1,Bills left in the cassette,{bills},10,100\n" +
+		"2025-01-02T03:04:05Z,2025-01-02T03:03:05Z,service.instance.id=atm-111,,0.1,,,,,,,,\n" +
+		"2025-01-02T03:04:05Z,,service.instance.id=atm-111,,,,6,12.5,,,,,\n" +
+		"2025-01-02T03:04:05Z,,service.instance.id=atm-222,cassette=a,,,,,7,Bills left in the cassette,{bills},,\n"
+	if string(buf) != want {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", want, buf)
+	}
+	if _, err := m.UnmarshalMetrics(buf); err == nil {
+		t.Fatal("Expected unmarshaling wide metrics to fail")
+	}
+
+	invalid := []marshaler.CSVConfig{
+		{MetricsLayout: "pivot"},
+		{MetricsLayout: marshaler.MetricsLayoutWide, Columns: marshaler.CSVColumns{Metrics: []string{"timestamp"}}},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", config)
+		}
+	}
+}
+
+func TestCSVMetricsWideCollisions(t *testing.T) {
+	m := marshaler.NewCSVMarshalerWithConfig(marshaler.CSVConfig{MetricsLayout: marshaler.MetricsLayoutWide})
+	for name, metrics := range map[string]func(ms pmetric.MetricSlice){
+		"key column": func(ms pmetric.MetricSlice) {
+			gauge := ms.AppendEmpty()
+			gauge.SetName("timestamp")
+			gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+		},
+		"count column": func(ms pmetric.MetricSlice) {
+			gauge := ms.AppendEmpty()
+			gauge.SetName("x_count")
+			gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+			histogram := ms.AppendEmpty()
+			histogram.SetName("x")
+			histogram.SetEmptyHistogram().DataPoints().AppendEmpty().SetCount(2)
+		},
+		"unit column": func(ms pmetric.MetricSlice) {
+			gauge := ms.AppendEmpty()
+			gauge.SetName("x")
+			gauge.SetUnit("s")
+			gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+			unit := ms.AppendEmpty()
+			unit.SetName("x_unit")
+			unit.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(2)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			md := pmetric.NewMetrics()
+			metrics(md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics())
+			if _, err := m.MarshalMetrics(md); err == nil {
+				t.Fatal("Expected colliding columns to fail")
+			}
+		})
+	}
+}
+
+func TestCSVTracesRoundTrip(t *testing.T) {
+	m := marshaler.NewCSVMarshaler()
+	for name, td := range map[string]ptrace.Traces{"empty": ptrace.NewTraces(), "traces": testTraces()} {
+		t.Run(name, func(t *testing.T) {
+			first, err := m.MarshalTraces(td)
+			if err != nil {
+				t.Fatalf("MarshalTraces() failed: %v", err)
+			}
+			decoded, err := m.UnmarshalTraces(first)
+			if err != nil {
+				t.Fatalf("UnmarshalTraces() failed: %v", err)
+			}
+			if decoded.SpanCount() != td.SpanCount() {
+				t.Fatalf("Expected %d spans, got %d", td.SpanCount(), decoded.SpanCount())
+			}
+			second, err := m.MarshalTraces(decoded)
+			if err != nil {
+				t.Fatalf("MarshalTraces() failed: %v", err)
+			}
+			if !bytes.Equal(first, second) {
+				t.Fatalf("Expected round-trip to be stable, got:\n%s\nwant:\n%s", second, first)
+			}
+		})
+	}
+}
+
+func TestCSVTracesColumns(t *testing.T) {
+	m := marshaler.NewCSVMarshaler()
+	buf, err := m.MarshalTraces(testTraces())
+	if err != nil {
+		t.Fatalf("MarshalTraces() failed: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(buf)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %d records", len(records))
+	}
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	root, child := records[1], records[2]
+
+	want := map[string]string{
+		"duration":        "1000000000",
+		"trace_state":     "atm=1",
+		"attributes":      `[{"key":"atm.id","value":{"intValue":"111"}}]`,
+		"service_name":    "atm",
+		"scope_name":      "tailtracer",
+		"status_code":     "Error",
+		"start_timestamp": "2025-01-02T03:04:05Z",
+		"events":          `[{"timeUnixNano":"1735787045500000000","name":"exception","attributes":[{"key":"exception.message","value":{"stringValue":"balance too low"}}]}]`,
+	}
+	for name, value := range want {
+		if got := root[columns[name]]; got != value {
+			t.Errorf("Expected %s to be %s, got %s", name, value, got)
+		}
+	}
+	if got := child[columns["parent_span_id"]]; got != root[columns["span_id"]] {
+		t.Errorf("Expected parent_span_id %s, got %s", root[columns["span_id"]], got)
+	}
+	if got := child[columns["duration"]]; got != "" {
+		t.Errorf("Expected no duration for a span without timestamps, got %s", got)
+	}
+	if got, want := child[columns["links"]], `[{"traceId":"100f0e0d0c0b0a090807060504030201","spanId":"0101010101010101"}]`; got != want {
+		t.Errorf("Expected links %s, got %s", want, got)
+	}
+
+	decoded, err := m.UnmarshalTraces(buf)
+	if err != nil {
+		t.Fatalf("UnmarshalTraces() failed: %v", err)
+	}
+	rs := decoded.ResourceSpans().At(0)
+	if got, _ := rs.Resource().Attributes().Get("service.name"); got.Str() != "atm" {
+		t.Errorf("Expected service.name atm, got %q", got.Str())
+	}
+	spans := rs.ScopeSpans().At(0).Spans()
+	if got := spans.At(0).Events().At(0).Name(); got != "exception" {
+		t.Errorf("Expected event exception, got %q", got)
+	}
+	if got := spans.At(1).Links().Len(); got != 1 {
+		t.Errorf("Expected 1 link, got %d", got)
+	}
+}
+
+func TestCSVTypedAttributes(t *testing.T) {
+	ld := plog.NewLogs()
+	attrs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes()
+	attrs.PutStr("query", "a=1; b=2")
+	attrs.PutInt("atm.id", 111)
+	attrs.PutDouble("ratio", 0.25)
+	attrs.PutBool("cached", false)
+	attrs.PutEmptyBytes("payload").FromRaw([]byte{0, 1, 2})
+	attrs.PutEmptySlice("tags").FromRaw([]any{"x", int64(1), true})
+	attrs.PutEmptyMap("nested").PutEmptyMap("inner").PutStr("k", "v")
+
+	m := marshaler.NewCSVMarshaler()
+	buf, err := m.MarshalLogs(ld)
+	if err != nil {
+		t.Fatalf("MarshalLogs() failed: %v", err)
+	}
+	decoded, err := m.UnmarshalLogs(buf)
+	if err != nil {
+		t.Fatalf("UnmarshalLogs() failed: %v", err)
+	}
+
+	got := decoded.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+	if !reflect.DeepEqual(got.AsRaw(), attrs.AsRaw()) {
+		t.Fatalf("Expected attributes %v, got %v", attrs.AsRaw(), got.AsRaw())
+	}
+}
+
+func TestCSVAttributesEscaping(t *testing.T) {
+	value := "<a href=\"x\">&</a>\b\f\n\r\t\x00\x1f\u2028\u2029\xff ok é"
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes().PutStr("note", value)
+
+	m := marshaler.NewCSVMarshaler()
+	buf, err := m.MarshalLogs(ld)
+	if err != nil {
+		t.Fatalf("MarshalLogs() failed: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(buf)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	want, err := json.Marshal([]any{map[string]any{"key": "note", "value": map[string]any{"stringValue": value}}})
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	for col, name := range records[0] {
+		if name == "attributes" && records[1][col] != string(want) {
+			t.Fatalf("Expected attributes %s, got %s", want, records[1][col])
+		}
+	}
+}
+
+func TestCSVFlatAttributes(t *testing.T) {
+	ld := plog.NewLogs()
+	attrs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes()
+	attrs.PutStr("service", "atm")
+	attrs.PutEmptyMap("nested").PutStr("k", "v")
+
+	m := marshaler.NewCSVMarshalerWithConfig(marshaler.CSVConfig{AttributeEncoding: marshaler.AttributeEncodingFlat})
+	buf, err := m.MarshalLogs(ld)
+	if err != nil {
+		t.Fatalf("MarshalLogs() failed: %v", err)
+	}
+	if !bytes.Contains(buf, []byte(`service=atm; nested={""k"":""v""}`)) {
+		t.Fatalf("Expected flat attributes, got:\n%s", buf)
+	}
+}
+
+func TestCSVDialect(t *testing.T) {
+	tests := []struct {
+		name   string
+		config marshaler.CSVConfig
+		want   string
+	}{
+		{
+			name:   "default",
+			config: marshaler.CSVConfig{},
+			want:   "timestamp,severity,body\n2025-01-02T03:04:05.000000006Z,INFO," + `"{""stringValue"":""a,b""}"` + "\n,,\n",
+		},
+		{
+			name: "dialect",
+			config: marshaler.CSVConfig{
+				Delimiter:       ";",
+				Quoting:         marshaler.QuoteModeAll,
+				SkipHeader:      true,
+				TimestampFormat: marshaler.TimestampFormatUnixNano,
+				NullValue:       "NULL",
+			},
+			want: `"1735787045000000006";"INFO";"{""stringValue"":""a,b""}"` + "\n" + `"NULL";"NULL";"NULL"` + "\n",
+		},
+		{
+			name: "unix millis",
+			config: marshaler.CSVConfig{
+				TimestampFormat: marshaler.TimestampFormatUnixMilli,
+			},
+			want: "timestamp,severity,body\n1735787045000,INFO," + `"{""stringValue"":""a,b""}"` + "\n,,\n",
+		},
+	}
+
+	ld := plog.NewLogs()
+	logs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	lr := logs.AppendEmpty()
+	lr.SetTimestamp(testTimestamp + 6)
+	lr.SetSeverityText("INFO")
+	lr.Body().SetStr("a,b")
+	logs.AppendEmpty()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Columns.Logs = []string{"timestamp", "severity", "body"}
+			if err := tt.config.Validate(); err != nil {
+				t.Fatalf("Validate() failed: %v", err)
+			}
+			m := marshaler.NewCSVMarshalerWithConfig(tt.config)
+			buf, err := m.MarshalLogs(ld)
+			if err != nil {
+				t.Fatalf("MarshalLogs() failed: %v", err)
+			}
+			if string(buf) != tt.want {
+				t.Fatalf("Expected:\n%s\ngot:\n%s", tt.want, buf)
+			}
+			decoded, err := m.UnmarshalLogs(buf)
+			if err != nil {
+				t.Fatalf("UnmarshalLogs() failed: %v", err)
+			}
+			if decoded.LogRecordCount() != ld.LogRecordCount() {
+				t.Fatalf("Expected %d log records, got %d", ld.LogRecordCount(), decoded.LogRecordCount())
+			}
+		})
+	}
+}
+
+func TestCSVColumnMapping(t *testing.T) {
+	config := marshaler.CSVConfig{
+		Mapping: marshaler.ColumnMappings{
+			Logs: []marshaler.ColumnMapping{
+				{Name: "ts", Source: "field:timestamp"},
+				{Name: "service", Source: "resource:service.name"},
+				{Name: "atm_id", Source: "attribute:atm.id", Type: marshaler.ColumnTypeInt, Default: "-1"},
+				{Name: "user", Source: "body:user.name", Default: "anonymous"},
+				{Name: "amount", Source: "body:$.items.0.amount", Type: marshaler.ColumnTypeDouble},
+			},
+			Traces: []marshaler.ColumnMapping{
+				{Name: "name", Source: "field:name"},
+				{Name: "secret", Source: "attribute:secret.attr"},
+				{Name: "duration", Source: "computed:duration", Type: marshaler.ColumnTypeInt},
+			},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	m := marshaler.NewCSVMarshalerWithConfig(config)
+
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "atm")
+	logs := rl.ScopeLogs().AppendEmpty().LogRecords()
+	lr := logs.AppendEmpty()
+	lr.SetTimestamp(testTimestamp)
+	lr.Attributes().PutStr("atm.id", "42")
+	lr.Body().SetStr(`{"user":{"name":"alice"},"items":[{"amount":12.5}]}`)
+	lr = logs.AppendEmpty()
+	body := lr.Body().SetEmptyMap()
+	body.PutEmptyMap("user").PutStr("name", "bob")
+
+	buf, err := m.MarshalLogs(ld)
+	if err != nil {
+		t.Fatalf("MarshalLogs() failed: %v", err)
+	}
+	want := "ts,service,atm_id,user,amount\n" +
+		"2025-01-02T03:04:05Z,atm,42,alice,12.5\n" +
+		",atm,-1,bob,\n"
+	if string(buf) != want {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", want, buf)
+	}
+	if _, err := m.UnmarshalLogs(buf); err == nil {
+		t.Fatal("Expected unmarshaling mapped logs to fail")
+	}
+
+	td := testTraces()
+	td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes().PutStr("secret.attr", "s3cr3t")
+	buf, err = m.MarshalTraces(td)
+	if err != nil {
+		t.Fatalf("MarshalTraces() failed: %v", err)
+	}
+	want = "name,secret,duration\n" +
+		"GET /balance,s3cr3t,1000000000\n" +
+		"SELECT accounts,,\n"
+	if string(buf) != want {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", want, buf)
+	}
+
+	invalid := []marshaler.ColumnMappings{
+		{Logs: []marshaler.ColumnMapping{{Name: "x", Source: "field:unknown"}}},
+		{Logs: []marshaler.ColumnMapping{{Name: "x", Source: "computed:duration"}}},
+		{Traces: []marshaler.ColumnMapping{{Name: "x", Source: "body:user"}}},
+		{Traces: []marshaler.ColumnMapping{{Name: "x", Source: "field:name"}, {Name: "x", Source: "field:kind"}}},
+		{Metrics: []marshaler.ColumnMapping{{Name: "x", Source: "attribute:k", Type: "date"}}},
+	}
+	for _, mapping := range invalid {
+		if err := mapping.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", mapping)
+		}
+	}
+}
+
+func TestCSVColumnMappingConversionFailure(t *testing.T) {
+	config := marshaler.CSVConfig{
+		NullValue: "NULL",
+		Mapping: marshaler.ColumnMappings{
+			Logs: []marshaler.ColumnMapping{
+				{Name: "atm_id", Source: "attribute:atm.id", Type: marshaler.ColumnTypeInt},
+				{Name: "user", Source: "body:user"},
+				{Name: "amount", Source: "body:amount", Type: marshaler.ColumnTypeDouble},
+			},
+		},
+	}
+	m := marshaler.NewCSVMarshalerWithConfig(config)
+
+	ld := plog.NewLogs()
+	logs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	lr := logs.AppendEmpty()
+	lr.Attributes().PutStr("atm.id", "atm-42")
+	lr.Body().SetStr(`{"user":"alice","amount":"twelve"}`)
+	lr = logs.AppendEmpty()
+	lr.Attributes().PutInt("atm.id", 7)
+	lr.Body().SetStr(`{"user":"bob","amount":1.5}`)
+
+	buf, err := m.MarshalLogs(ld)
+	if err != nil {
+		t.Fatalf("MarshalLogs() failed: %v", err)
+	}
+	want := "atm_id,user,amount\n" +
+		"NULL,alice,NULL\n" +
+		"7,bob,1.5\n"
+	if string(buf) != want {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", want, buf)
+	}
+	if got := m.ConversionFailures(); got != 2 {
+		t.Fatalf("Expected 2 conversion failures, got %d", got)
+	}
+}
@@ -0,0 +1,404 @@
+package marshaler
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Column indexes of the traces CSV layout, see tracesCSVHeader.
+const (
+	spanColTraceID = iota
+	spanColSpanID
+	spanColParentSpanID
+	spanColName
+	spanColKind
+	spanColStartTimestamp
+	spanColEndTimestamp
+	spanColStatusCode
+	spanColStatusMessage
+	spanColDuration
+	spanColTraceState
+	spanColAttributes
+	spanColServiceName
+	spanColResourceAttributes
+	spanColScopeName
+	spanColScopeVersion
+	spanColEvents
+	spanColLinks
+	numSpanColumns
+)
+
+// tracesCSVHeader is the column layout of the traces CSV, one row per span:
+//
+//	trace_id              hex encoded trace ID
+//	span_id               hex encoded span ID
+//	parent_span_id        hex encoded parent span ID, empty for root spans
+//	name                  span name
+//	kind                  Unspecified, Internal, Server, Client, Producer or Consumer
+//	start_timestamp       span start time
+//	end_timestamp         span end time
+//	status_code           Unset, Ok or Error
+//	status_message        status description
+//	duration              span duration in nanoseconds, empty for spans that did not end
+//	trace_state           W3C trace state
+//	attributes            span attributes
+//	service_name          service.name attribute of the resource
+//	resource_attributes   attributes of the resource that emitted the span
+//	scope_name            instrumentation scope name
+//	scope_version         instrumentation scope version
+//	events                span events as an OTLP/JSON array, see jsonSpanEvent
+//	links                 span links as an OTLP/JSON array, see jsonSpanLink
+var tracesCSVHeader = []string{
+	"trace_id",
+	"span_id",
+	"parent_span_id",
+	"name",
+	"kind",
+	"start_timestamp",
+	"end_timestamp",
+	"status_code",
+	"status_message",
+	"duration",
+	"trace_state",
+	"attributes",
+	"service_name",
+	"resource_attributes",
+	"scope_name",
+	"scope_version",
+	"events",
+	"links",
+}
+
+// serviceNameKey is the resource attribute written in the service_name column.
+const serviceNameKey = "service.name"
+
+// spanKinds maps the kind column back to a span kind.
+var spanKinds = map[string]ptrace.SpanKind{
+	"":                                  ptrace.SpanKindUnspecified,
+	ptrace.SpanKindUnspecified.String(): ptrace.SpanKindUnspecified,
+	ptrace.SpanKindInternal.String():    ptrace.SpanKindInternal,
+	ptrace.SpanKindServer.String():      ptrace.SpanKindServer,
+	ptrace.SpanKindClient.String():      ptrace.SpanKindClient,
+	ptrace.SpanKindProducer.String():    ptrace.SpanKindProducer,
+	ptrace.SpanKindConsumer.String():    ptrace.SpanKindConsumer,
+}
+
+// statusCodes maps the status_code column back to a status code.
+var statusCodes = map[string]ptrace.StatusCode{
+	"":                              ptrace.StatusCodeUnset,
+	ptrace.StatusCodeUnset.String(): ptrace.StatusCodeUnset,
+	ptrace.StatusCodeOk.String():    ptrace.StatusCodeOk,
+	ptrace.StatusCodeError.String(): ptrace.StatusCodeError,
+}
+
+// spanToCSVRow builds the row of a span. The resource and scope columns are
+// left for the caller to fill.
+func (m CSVMarshaler) spanToCSVRow(row *csvRow, s ptrace.Span) error {
+	row.reset(numSpanColumns)
+	traceID, spanID, parentSpanID := s.TraceID(), s.SpanID(), s.ParentSpanID()
+	row.setID(spanColTraceID, traceID[:])
+	row.setID(spanColSpanID, spanID[:])
+	row.setID(spanColParentSpanID, parentSpanID[:])
+	row.setString(spanColName, s.Name())
+	row.setString(spanColKind, s.Kind().String())
+	m.setTimestamp(row, spanColStartTimestamp, s.StartTimestamp())
+	m.setTimestamp(row, spanColEndTimestamp, s.EndTimestamp())
+	row.setString(spanColStatusCode, s.Status().Code().String())
+	row.setString(spanColStatusMessage, s.Status().Message())
+	if duration, ok := spanDuration(s); ok {
+		row.setInt(spanColDuration, duration)
+	}
+	row.setString(spanColTraceState, s.TraceState().AsRaw())
+	if err := m.setAttributes(row, spanColAttributes, s.Attributes()); err != nil {
+		return err
+	}
+	start := row.mark()
+	row.buf = appendSpanEventsJSON(row.buf, s.Events())
+	row.end(spanColEvents, start)
+	start = row.mark()
+	row.buf = appendSpanLinksJSON(row.buf, s.Links())
+	row.end(spanColLinks, start)
+	return nil
+}
+
+// spanDuration returns the duration of a span in nanoseconds, reporting false
+// for spans that did not end.
+func spanDuration(s ptrace.Span) (int64, bool) {
+	if s.StartTimestamp() == 0 || s.EndTimestamp() < s.StartTimestamp() {
+		return 0, false
+	}
+	return int64(s.EndTimestamp() - s.StartTimestamp()), true
+}
+
+// serviceName returns the service.name attribute of a resource, empty when
+// it is not a string.
+func serviceName(resource pcommon.Map) string {
+	if v, ok := resource.Get(serviceNameKey); ok && v.Type() == pcommon.ValueTypeStr {
+		return v.Str()
+	}
+	return ""
+}
+
+// spanComputedFields returns the computed fields of a span available to
+// column mappings. The duration is only computed for spans that ended.
+func spanComputedFields(s ptrace.Span) map[string]pcommon.Value {
+	duration, ok := spanDuration(s)
+	if !ok {
+		return nil
+	}
+	return map[string]pcommon.Value{
+		computedDuration: pcommon.NewValueInt(duration),
+	}
+}
+
+// spanFromCSVRecord fills a span from a CSV record. The duration is derived
+// from the timestamps and is not read.
+func (m CSVMarshaler) spanFromCSVRecord(s ptrace.Span, record []string) error {
+	traceID, err := parseTraceID(record[spanColTraceID])
+	if err != nil {
+		return err
+	}
+	spanID, err := parseSpanID(record[spanColSpanID])
+	if err != nil {
+		return err
+	}
+	parentSpanID, err := parseSpanID(record[spanColParentSpanID])
+	if err != nil {
+		return err
+	}
+	kind, ok := spanKinds[record[spanColKind]]
+	if !ok {
+		return fmt.Errorf("unsupported span kind: %s", record[spanColKind])
+	}
+	start, err := m.parseTimestamp(record[spanColStartTimestamp])
+	if err != nil {
+		return err
+	}
+	end, err := m.parseTimestamp(record[spanColEndTimestamp])
+	if err != nil {
+		return err
+	}
+	code, ok := statusCodes[record[spanColStatusCode]]
+	if !ok {
+		return fmt.Errorf("unsupported status code: %s", record[spanColStatusCode])
+	}
+
+	s.SetTraceID(traceID)
+	s.SetSpanID(spanID)
+	s.SetParentSpanID(parentSpanID)
+	s.SetName(record[spanColName])
+	s.SetKind(kind)
+	s.SetStartTimestamp(start)
+	s.SetEndTimestamp(end)
+	s.Status().SetCode(code)
+	s.Status().SetMessage(record[spanColStatusMessage])
+	s.TraceState().FromRaw(record[spanColTraceState])
+	if err := m.decodeAttributes(record[spanColAttributes], s.Attributes()); err != nil {
+		return fmt.Errorf("failed to parse attributes: %w", err)
+	}
+	if err := spanEventsFromJSONString(record[spanColEvents], s.Events()); err != nil {
+		return fmt.Errorf("failed to parse events: %w", err)
+	}
+	if err := spanLinksFromJSONString(record[spanColLinks], s.Links()); err != nil {
+		return fmt.Errorf("failed to parse links: %w", err)
+	}
+	return nil
+}
+
+// jsonSpanEvent is the OTLP/JSON representation of a span event. Event
+// attributes always use the typed encoding, whatever the attribute encoding
+// of the marshaler.
+type jsonSpanEvent struct {
+	TimeUnixNano           string         `json:"timeUnixNano,omitempty"`
+	Name                   string         `json:"name"`
+	Attributes             []jsonKeyValue `json:"attributes,omitempty"`
+	DroppedAttributesCount uint32         `json:"droppedAttributesCount,omitempty"`
+}
+
+// jsonSpanLink is the OTLP/JSON representation of a span link, with hex
+// encoded IDs. Link attributes always use the typed encoding.
+type jsonSpanLink struct {
+	TraceID                string         `json:"traceId"`
+	SpanID                 string         `json:"spanId"`
+	TraceState             string         `json:"traceState,omitempty"`
+	Attributes             []jsonKeyValue `json:"attributes,omitempty"`
+	DroppedAttributesCount uint32         `json:"droppedAttributesCount,omitempty"`
+	Flags                  uint32         `json:"flags,omitempty"`
+}
+
+// spanEventsToJSONString serializes span events as an OTLP/JSON array. No
+// events are written as an empty string.
+func spanEventsToJSONString(events ptrace.SpanEventSlice) (string, error) {
+	return string(appendSpanEventsJSON(nil, events)), nil
+}
+
+// appendSpanEventsJSON appends span events as a JSON array of jsonSpanEvent,
+// nothing when there are no events.
+func appendSpanEventsJSON(dst []byte, events ptrace.SpanEventSlice) []byte {
+	if events.Len() == 0 {
+		return dst
+	}
+	dst = append(dst, '[')
+	for i := 0; i < events.Len(); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		event := events.At(i)
+		dst = append(dst, '{')
+		if event.Timestamp() != 0 {
+			dst = append(dst, `"timeUnixNano":"`...)
+			dst = strconv.AppendUint(dst, uint64(event.Timestamp()), 10)
+			dst = append(dst, `",`...)
+		}
+		dst = append(dst, `"name":`...)
+		dst = appendJSONString(dst, event.Name())
+		if event.Attributes().Len() > 0 {
+			dst = append(dst, `,"attributes":`...)
+			dst = appendKeyValuesJSON(dst, event.Attributes())
+		}
+		if dropped := event.DroppedAttributesCount(); dropped != 0 {
+			dst = append(dst, `,"droppedAttributesCount":`...)
+			dst = strconv.AppendUint(dst, uint64(dropped), 10)
+		}
+		dst = append(dst, '}')
+	}
+	return append(dst, ']')
+}
+
+// spanEventsFromJSONString parses an OTLP/JSON array of span events into dest.
+func spanEventsFromJSONString(s string, dest ptrace.SpanEventSlice) error {
+	if s == "" {
+		return nil
+	}
+	var jevents []jsonSpanEvent
+	if err := json.Unmarshal([]byte(s), &jevents); err != nil {
+		return err
+	}
+	dest.EnsureCapacity(len(jevents))
+	for _, jevent := range jevents {
+		timestamp, err := parseOptionalUint(jevent.TimeUnixNano, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse event timestamp: %w", err)
+		}
+		event := dest.AppendEmpty()
+		event.SetTimestamp(pcommon.Timestamp(timestamp))
+		event.SetName(jevent.Name)
+		event.SetDroppedAttributesCount(jevent.DroppedAttributesCount)
+		if err := mapFromJSON(jevent.Attributes, event.Attributes()); err != nil {
+			return fmt.Errorf("event %q: %w", jevent.Name, err)
+		}
+	}
+	return nil
+}
+
+// spanLinksToJSONString serializes span links as an OTLP/JSON array. No links
+// are written as an empty string.
+func spanLinksToJSONString(links ptrace.SpanLinkSlice) (string, error) {
+	return string(appendSpanLinksJSON(nil, links)), nil
+}
+
+// appendSpanLinksJSON appends span links as a JSON array of jsonSpanLink,
+// nothing when there are no links.
+func appendSpanLinksJSON(dst []byte, links ptrace.SpanLinkSlice) []byte {
+	if links.Len() == 0 {
+		return dst
+	}
+	dst = append(dst, '[')
+	for i := 0; i < links.Len(); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		link := links.At(i)
+		traceID, spanID := link.TraceID(), link.SpanID()
+		dst = append(dst, `{"traceId":"`...)
+		dst = appendID(dst, traceID[:])
+		dst = append(dst, `","spanId":"`...)
+		dst = appendID(dst, spanID[:])
+		dst = append(dst, '"')
+		if traceState := link.TraceState().AsRaw(); traceState != "" {
+			dst = append(dst, `,"traceState":`...)
+			dst = appendJSONString(dst, traceState)
+		}
+		if link.Attributes().Len() > 0 {
+			dst = append(dst, `,"attributes":`...)
+			dst = appendKeyValuesJSON(dst, link.Attributes())
+		}
+		if dropped := link.DroppedAttributesCount(); dropped != 0 {
+			dst = append(dst, `,"droppedAttributesCount":`...)
+			dst = strconv.AppendUint(dst, uint64(dropped), 10)
+		}
+		if flags := link.Flags(); flags != 0 {
+			dst = append(dst, `,"flags":`...)
+			dst = strconv.AppendUint(dst, uint64(flags), 10)
+		}
+		dst = append(dst, '}')
+	}
+	return append(dst, ']')
+}
+
+// spanLinksFromJSONString parses an OTLP/JSON array of span links into dest.
+func spanLinksFromJSONString(s string, dest ptrace.SpanLinkSlice) error {
+	if s == "" {
+		return nil
+	}
+	var jlinks []jsonSpanLink
+	if err := json.Unmarshal([]byte(s), &jlinks); err != nil {
+		return err
+	}
+	dest.EnsureCapacity(len(jlinks))
+	for _, jlink := range jlinks {
+		traceID, err := parseTraceID(jlink.TraceID)
+		if err != nil {
+			return err
+		}
+		spanID, err := parseSpanID(jlink.SpanID)
+		if err != nil {
+			return err
+		}
+		link := dest.AppendEmpty()
+		link.SetTraceID(traceID)
+		link.SetSpanID(spanID)
+		link.TraceState().FromRaw(jlink.TraceState)
+		link.SetDroppedAttributesCount(jlink.DroppedAttributesCount)
+		link.SetFlags(jlink.Flags)
+		if err := mapFromJSON(jlink.Attributes, link.Attributes()); err != nil {
+			return fmt.Errorf("link %s: %w", jlink.SpanID, err)
+		}
+	}
+	return nil
+}
+
+// parseTraceID parses a hex encoded trace ID, an empty string being the empty ID.
+func parseTraceID(s string) (pcommon.TraceID, error) {
+	var id pcommon.TraceID
+	if s == "" {
+		return id, nil
+	}
+	if hex.DecodedLen(len(s)) != len(id) {
+		return id, fmt.Errorf("invalid trace ID length: %s", s)
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return id, fmt.Errorf("failed to parse trace ID: %w", err)
+	}
+	return id, nil
+}
+
+// parseSpanID parses a hex encoded span ID, an empty string being the empty ID.
+func parseSpanID(s string) (pcommon.SpanID, error) {
+	var id pcommon.SpanID
+	if s == "" {
+		return id, nil
+	}
+	if hex.DecodedLen(len(s)) != len(id) {
+		return id, fmt.Errorf("invalid span ID length: %s", s)
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return id, fmt.Errorf("failed to parse span ID: %w", err)
+	}
+	return id, nil
+}
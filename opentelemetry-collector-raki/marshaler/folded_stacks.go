@@ -0,0 +1,179 @@
+package marshaler
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// FoldedStacksMarshaler writes traces as folded stacks, the input of
+// flamegraph tools such as flamegraph.pl, inferno or speedscope:
+//
+//	atm;GET /balance;SELECT accounts 200000000
+//
+// Span trees are rebuilt from parent span IDs across all the resources of the
+// batch. Every stack starts with the service of its root span, followed by the
+// span names down to the span, and a service frame is inserted whenever a
+// child belongs to another service than its parent. Spans whose parent is not
+// in the batch are roots.
+//
+// The weight of a stack is the self time of its spans in nanoseconds: their
+// duration minus the time covered by their children. Identical stacks are
+// summed up, so a batch of many traces shows where time goes overall, and
+// stacks without self time are left out. Lines are sorted by stack.
+type FoldedStacksMarshaler struct{}
+
+func NewFoldedStacksMarshaler() FoldedStacksMarshaler {
+	return FoldedStacksMarshaler{}
+}
+
+// foldedSpan is a node of a rebuilt span tree.
+type foldedSpan struct {
+	service    string
+	name       string
+	start, end pcommon.Timestamp
+	parent     spanKey
+	children   []*foldedSpan
+	stack      string
+}
+
+// MarshalTraces converts OpenTelemetry traces into folded stacks.
+func (m FoldedStacksMarshaler) MarshalTraces(td ptrace.Traces) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteTraces(&buf, td); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTraces writes OpenTelemetry traces into w as folded stacks.
+func (m FoldedStacksMarshaler) WriteTraces(w io.Writer, td ptrace.Traces) error {
+	spans := map[spanKey]*foldedSpan{}
+	var order []*foldedSpan
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		service := serviceName(rss.At(i).Resource().Attributes())
+		if service == "" {
+			service = "unknown_service"
+		}
+		ilss := rss.At(i).ScopeSpans()
+		for j := 0; j < ilss.Len(); j++ {
+			ss := ilss.At(j).Spans()
+			for k := 0; k < ss.Len(); k++ {
+				s := ss.At(k)
+				span := &foldedSpan{
+					service: foldedFrame(service),
+					name:    foldedFrame(s.Name()),
+					start:   s.StartTimestamp(),
+					end:     s.EndTimestamp(),
+					parent:  spanKey{s.TraceID(), s.ParentSpanID()},
+				}
+				spans[spanKey{s.TraceID(), s.SpanID()}] = span
+				order = append(order, span)
+			}
+		}
+	}
+	for _, span := range order {
+		if parent, ok := spans[span.parent]; ok && parent != span && !span.parent.spanID.IsEmpty() {
+			parent.children = append(parent.children, span)
+		}
+	}
+
+	weights := map[string]uint64{}
+	for _, span := range order {
+		if self := span.selfTime(); self > 0 {
+			weights[span.foldedStack(spans, 0)] += self
+		}
+	}
+	stacks := make([]string, 0, len(weights))
+	for stack := range weights {
+		stacks = append(stacks, stack)
+	}
+	slices.Sort(stacks)
+
+	bw := bufio.NewWriter(w)
+	for _, stack := range stacks {
+		bw.WriteString(stack)
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.FormatUint(weights[stack], 10))
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write folded stacks: %w", err)
+	}
+	return nil
+}
+
+// maxFoldedDepth bounds the stacks of malformed batches whose parent span IDs
+// form a cycle.
+const maxFoldedDepth = 1024
+
+// foldedStack returns the stack of a span, computing the stacks of its
+// ancestors on the way.
+func (s *foldedSpan) foldedStack(spans map[spanKey]*foldedSpan, depth int) string {
+	if s.stack != "" {
+		return s.stack
+	}
+	parent, ok := spans[s.parent]
+	if !ok || parent == s || s.parent.spanID.IsEmpty() || depth == maxFoldedDepth {
+		s.stack = s.service + ";" + s.name
+		return s.stack
+	}
+	stack := parent.foldedStack(spans, depth+1)
+	if parent.service != s.service {
+		stack += ";" + s.service
+	}
+	s.stack = stack + ";" + s.name
+	return s.stack
+}
+
+// selfTime returns the duration of a span not covered by its children, in
+// nanoseconds. Children running concurrently are only counted once.
+func (s *foldedSpan) selfTime() uint64 {
+	if s.start == 0 || s.end <= s.start {
+		return 0
+	}
+	type interval struct{ start, end pcommon.Timestamp }
+	var covered []interval
+	for _, child := range s.children {
+		start, end := max(child.start, s.start), min(child.end, s.end)
+		if child.start != 0 && end > start {
+			covered = append(covered, interval{start, end})
+		}
+	}
+	slices.SortFunc(covered, func(a, b interval) int {
+		return cmp.Compare(a.start, b.start)
+	})
+	self := uint64(s.end - s.start)
+	var last pcommon.Timestamp
+	for _, c := range covered {
+		start := max(c.start, last)
+		if c.end > start {
+			self -= uint64(c.end - start)
+			last = c.end
+		}
+	}
+	return self
+}
+
+// foldedFrame replaces the characters that separate frames and lines, falling
+// back to unknown for empty names.
+func foldedFrame(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == ';' || r == '\n' || r == '\r' {
+			return '_'
+		}
+		return r
+	}, name)
+}
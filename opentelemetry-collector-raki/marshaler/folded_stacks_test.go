@@ -0,0 +1,68 @@
+package marshaler_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler/marshalertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestFoldedStacks(t *testing.T) {
+	m := marshaler.NewFoldedStacksTraces()
+	if _, ok := marshaler.BaseTracesMarshalers()[m.Encoding()]; !ok {
+		t.Errorf("Expected %s to be registered", m.Encoding())
+	}
+
+	td := ptrace.NewTraces()
+	atm := td.ResourceSpans().AppendEmpty()
+	atm.Resource().Attributes().PutStr("service.name", "atm")
+	atmSpans := atm.ScopeSpans().AppendEmpty().Spans()
+	backend := td.ResourceSpans().AppendEmpty()
+	backend.Resource().Attributes().PutStr("service.name", "backend")
+	backendSpans := backend.ScopeSpans().AppendEmpty().Spans()
+
+	span := func(spans ptrace.SpanSlice, traceID byte, id, parent byte, name string, start, end time.Duration) {
+		s := spans.AppendEmpty()
+		s.SetTraceID(pcommon.TraceID{traceID})
+		s.SetSpanID(pcommon.SpanID{id})
+		if parent != 0 {
+			s.SetParentSpanID(pcommon.SpanID{parent})
+		}
+		s.SetName(name)
+		s.SetStartTimestamp(testTimestamp + pcommon.Timestamp(start))
+		s.SetEndTimestamp(testTimestamp + pcommon.Timestamp(end))
+	}
+	// The database calls run concurrently and the backend span is written
+	// before its own child, in another resource than its parent.
+	span(backendSpans, 1, 4, 1, "POST /debit", 500*time.Millisecond, 900*time.Millisecond)
+	span(atmSpans, 1, 1, 0, "GET /balance", 0, time.Second)
+	span(atmSpans, 1, 2, 1, "SELECT", 100*time.Millisecond, 300*time.Millisecond)
+	span(atmSpans, 1, 3, 1, "SELECT", 200*time.Millisecond, 400*time.Millisecond)
+	span(backendSpans, 1, 5, 4, "UPDATE", 600*time.Millisecond, 700*time.Millisecond)
+	span(atmSpans, 2, 1, 0, "GET /balance", 0, time.Second)
+	span(backendSpans, 3, 1, 9, "a;b", 0, 50)
+	span(backendSpans, 3, 2, 0, "unfinished", 0, 0)
+
+	buf, err := m.Marshal(td)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	want := strings.Join([]string{
+		"atm;GET /balance 1300000000",
+		"atm;GET /balance;SELECT 400000000",
+		"atm;GET /balance;backend;POST /debit 300000000",
+		"atm;GET /balance;backend;POST /debit;UPDATE 100000000",
+		"backend;a_b 50",
+	}, "\n") + "\n"
+	if string(buf) != want {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", want, buf)
+	}
+}
+
+func TestFoldedStacksConformance(t *testing.T) {
+	marshalertest.TestTraces(t, marshaler.NewFoldedStacksTraces(), nil)
+}
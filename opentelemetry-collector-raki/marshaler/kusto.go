@@ -0,0 +1,278 @@
+package marshaler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kusto scalar types of the columns of an Azure Data Explorer table.
+const (
+	KustoTypeString   = "string"
+	KustoTypeInt      = "int"
+	KustoTypeLong     = "long"
+	KustoTypeReal     = "real"
+	KustoTypeBool     = "bool"
+	KustoTypeDatetime = "datetime"
+	KustoTypeDynamic  = "dynamic"
+)
+
+// Data formats of Azure Data Explorer ingestion. The delimited formats all use
+// csv mappings.
+const (
+	kustoFormatCSV     = "csv"
+	kustoFormatTSV     = "tsv"
+	kustoFormatSCSV    = "scsv"
+	kustoFormatPSV     = "psv"
+	kustoFormatSOHSV   = "sohsv"
+	kustoFormatParquet = "parquet"
+)
+
+// kustoDelimitedFormats maps the CSV delimiters to their data format.
+var kustoDelimitedFormats = map[string]string{
+	"":     kustoFormatCSV,
+	",":    kustoFormatCSV,
+	"\t":   kustoFormatTSV,
+	";":    kustoFormatSCSV,
+	"|":    kustoFormatPSV,
+	"\x01": kustoFormatSOHSV,
+}
+
+// kustoJSONColumns are the layout columns holding JSON, ingested as dynamic
+// when they are written as JSON.
+var kustoJSONColumns = map[string]bool{
+	"attributes":          true,
+	"resource_attributes": true,
+	"body":                true,
+	"events":              true,
+	"links":               true,
+	"exemplars":           true,
+}
+
+// csvKustoTypes are the Kusto types of the CSV layout columns. Timestamps,
+// attributes and list-valued metrics columns are typed by csvKustoType.
+var csvKustoTypes = map[string]string{
+	// logs
+	"severity":        KustoTypeString,
+	"severity_number": KustoTypeInt,
+	"trace_id":        KustoTypeString,
+	"span_id":         KustoTypeString,
+	"flags":           KustoTypeLong,
+	"scope_name":      KustoTypeString,
+	"scope_version":   KustoTypeString,
+
+	// metrics
+	"metric_name":             KustoTypeString,
+	"metric_type":             KustoTypeString,
+	"value_type":              KustoTypeString,
+	"value":                   KustoTypeReal,
+	"count":                   KustoTypeLong,
+	"sum":                     KustoTypeReal,
+	"min":                     KustoTypeReal,
+	"max":                     KustoTypeReal,
+	"scale":                   KustoTypeInt,
+	"zero_count":              KustoTypeLong,
+	"positive_offset":         KustoTypeInt,
+	"negative_offset":         KustoTypeInt,
+	"aggregation_temporality": KustoTypeString,
+	"is_monotonic":            KustoTypeBool,
+	"unit":                    KustoTypeString,
+	"description":             KustoTypeString,
+
+	// traces
+	"parent_span_id": KustoTypeString,
+	"name":           KustoTypeString,
+	"kind":           KustoTypeString,
+	"status_code":    KustoTypeString,
+	"status_message": KustoTypeString,
+	"duration":       KustoTypeLong,
+	"trace_state":    KustoTypeString,
+	"service_name":   KustoTypeString,
+}
+
+// columnKustoTypes are the Kusto types of the mapped column types.
+var columnKustoTypes = map[ColumnType]string{
+	"":               KustoTypeString,
+	ColumnTypeString: KustoTypeString,
+	ColumnTypeInt:    KustoTypeLong,
+	ColumnTypeDouble: KustoTypeReal,
+	ColumnTypeBool:   KustoTypeBool,
+}
+
+// KustoColumn is a column of an Azure Data Explorer table.
+type KustoColumn struct {
+	// Name is the name of the column, the same as in the marshaled output.
+	Name string
+
+	// Type is the Kusto scalar type of the column, e.g. datetime.
+	Type string
+}
+
+// KustoSchema describes the Azure Data Explorer table that the output of a
+// marshaler for one signal is ingested into.
+type KustoSchema struct {
+	// Format is the ingestion data format, e.g. csv, tsv or parquet.
+	Format string
+
+	// Columns are the columns of the output, in order.
+	Columns []KustoColumn
+}
+
+// KustoSchemas holds the Azure Data Explorer schema of each signal.
+type KustoSchemas struct {
+	Logs    KustoSchema
+	Metrics KustoSchema
+	Traces  KustoSchema
+}
+
+// CSVKustoSchemas returns the schemas of the CSV output written with the
+// given options, following the selected columns or the column mapping of
+// each signal. Timestamps are datetime columns when written as RFC 3339, long
+// otherwise, and attribute maps are dynamic unless written as flat pairs.
+// Only the delimiters supported by Azure Data Explorer are accepted: ',',
+// tab, ';', '|' and \x01.
+//
+// The metric columns of the wide metrics layout depend on the batch, so the
+// metrics schema then only holds the leading timestamp and series columns.
+func CSVKustoSchemas(config CSVConfig) (KustoSchemas, error) {
+	if err := config.Validate(); err != nil {
+		return KustoSchemas{}, err
+	}
+	format, ok := kustoDelimitedFormats[config.Delimiter]
+	if !ok {
+		return KustoSchemas{}, fmt.Errorf("delimiter %q is not supported by Azure Data Explorer", config.Delimiter)
+	}
+	m := NewCSVMarshalerWithConfig(config)
+	metrics := m.kustoSchema(format, metricsCSVHeader, m.metricColumns, m.metricMapper)
+	if config.MetricsLayout == MetricsLayoutWide {
+		metrics = m.kustoSchema(format, wideMetricsKeyColumns, nil, nil)
+	}
+	return KustoSchemas{
+		Logs:    m.kustoSchema(format, logsCSVHeader, m.logColumns, m.logMapper),
+		Metrics: metrics,
+		Traces:  m.kustoSchema(format, tracesCSVHeader, m.spanColumns, m.spanMapper),
+	}, nil
+}
+
+// kustoSchema returns the schema of the columns written by startCSV.
+func (m CSVMarshaler) kustoSchema(format string, layout []string, columns []int, mapper *columnMapper) KustoSchema {
+	schema := KustoSchema{Format: format}
+	if mapper != nil {
+		for _, column := range mapper.columns {
+			schema.Columns = append(schema.Columns, KustoColumn{Name: column.name, Type: columnKustoTypes[column.typ]})
+		}
+		return schema
+	}
+	if columns == nil {
+		for _, name := range layout {
+			schema.Columns = append(schema.Columns, KustoColumn{Name: name, Type: m.csvKustoType(name)})
+		}
+		return schema
+	}
+	for _, col := range columns {
+		schema.Columns = append(schema.Columns, KustoColumn{Name: layout[col], Type: m.csvKustoType(layout[col])})
+	}
+	return schema
+}
+
+// csvKustoType returns the Kusto type of a CSV layout column.
+func (m CSVMarshaler) csvKustoType(name string) string {
+	switch {
+	case name == "timestamp" || strings.HasSuffix(name, "_timestamp"):
+		if m.config.TimestampFormat == "" || m.config.TimestampFormat == TimestampFormatRFC3339Nano {
+			return KustoTypeDatetime
+		}
+		return KustoTypeLong
+	case (name == "attributes" || name == "resource_attributes" || name == "body") && m.config.AttributeEncoding == AttributeEncodingFlat:
+		return KustoTypeString
+	case kustoJSONColumns[name]:
+		return KustoTypeDynamic
+	}
+	if typ, ok := csvKustoTypes[name]; ok {
+		return typ
+	}
+	// List-valued metrics columns, e.g. bucket_counts, are ';' separated.
+	return KustoTypeString
+}
+
+// CreateTableCommand returns the .create table command of a table holding
+// the output, e.g.
+//
+//	.create table ['OTelLogs'] (['timestamp']:datetime, ['severity']:string, ...)
+func (s KustoSchema) CreateTableCommand(table string) string {
+	var sb strings.Builder
+	sb.WriteString(".create table ")
+	sb.WriteString(kustoName(table))
+	sb.WriteString(" (")
+	for i, column := range s.Columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(kustoName(column.Name))
+		sb.WriteByte(':')
+		sb.WriteString(column.Type)
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// kustoMappingColumn is an element of an ingestion mapping.
+type kustoMappingColumn struct {
+	Column     string            `json:"Column"`
+	DataType   string            `json:"DataType"`
+	Properties map[string]string `json:"Properties"`
+}
+
+// IngestionMapping returns the ingestion mapping of the output as JSON: a
+// csv mapping by ordinal for delimited formats, a parquet mapping by path
+// otherwise.
+func (s KustoSchema) IngestionMapping() (string, error) {
+	mapping := make([]kustoMappingColumn, len(s.Columns))
+	for i, column := range s.Columns {
+		properties := map[string]string{"Ordinal": strconv.Itoa(i)}
+		if s.Format == kustoFormatParquet {
+			properties = map[string]string{"Path": "$." + column.Name}
+			if strings.ContainsAny(column.Name, ".[]'\" ") {
+				properties["Path"] = "$['" + strings.ReplaceAll(column.Name, "'", `\'`) + "']"
+			}
+		}
+		mapping[i] = kustoMappingColumn{Column: column.Name, DataType: column.Type, Properties: properties}
+	}
+	b, err := json.Marshal(mapping)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ingestion mapping: %w", err)
+	}
+	return string(b), nil
+}
+
+// MappingKind returns the kind of the ingestion mapping, csv for every
+// delimited format.
+func (s KustoSchema) MappingKind() string {
+	if s.Format == kustoFormatParquet {
+		return kustoFormatParquet
+	}
+	return kustoFormatCSV
+}
+
+// CreateMappingCommand returns the command creating or altering the named
+// ingestion mapping of a table, referenced when ingesting the output, e.g.
+// through the kustoIngestionMappingReference blob metadata.
+func (s KustoSchema) CreateMappingCommand(table, mapping string) (string, error) {
+	columns, err := s.IngestionMapping()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(".create-or-alter table %s ingestion %s mapping %s %s",
+		kustoName(table), s.MappingKind(), kustoString(mapping), kustoString(columns)), nil
+}
+
+// kustoName quotes a table or column name, e.g. ['service.name'].
+func kustoName(name string) string {
+	return "[" + kustoString(name) + "]"
+}
+
+// kustoString quotes a string literal, escaping quotes and backslashes.
+func kustoString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,50 @@
+package marshaler
+
+import (
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+// ParquetKustoSchemas returns the schemas of the Parquet output, derived from
+// logsArrowSchema, metricsArrowSchema and tracesArrowSchema so that they
+// follow every change of the columnar layout. Attribute maps, span events and
+// links are dynamic columns, as are lists such as bucket_counts.
+func ParquetKustoSchemas() KustoSchemas {
+	return KustoSchemas{
+		Logs:    arrowKustoSchema(logsArrowSchema),
+		Metrics: arrowKustoSchema(metricsArrowSchema),
+		Traces:  arrowKustoSchema(tracesArrowSchema),
+	}
+}
+
+// arrowKustoSchema converts a columnar schema into a parquet Kusto schema.
+func arrowKustoSchema(schema *arrow.Schema) KustoSchema {
+	columns := make([]KustoColumn, 0, len(schema.Fields()))
+	for _, field := range schema.Fields() {
+		columns = append(columns, KustoColumn{Name: field.Name, Type: arrowKustoType(field)})
+	}
+	return KustoSchema{Format: kustoFormatParquet, Columns: columns}
+}
+
+// arrowKustoType returns the Kusto type of a column. Unsigned 32-bit integers
+// are long, as int is signed.
+func arrowKustoType(field arrow.Field) string {
+	if kustoJSONColumns[field.Name] {
+		return KustoTypeDynamic
+	}
+	switch field.Type.ID() {
+	case arrow.TIMESTAMP, arrow.DATE32, arrow.DATE64:
+		return KustoTypeDatetime
+	case arrow.BOOL:
+		return KustoTypeBool
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.UINT8, arrow.UINT16:
+		return KustoTypeInt
+	case arrow.INT64, arrow.UINT32, arrow.UINT64:
+		return KustoTypeLong
+	case arrow.FLOAT16, arrow.FLOAT32, arrow.FLOAT64:
+		return KustoTypeReal
+	case arrow.LIST, arrow.LARGE_LIST, arrow.FIXED_SIZE_LIST, arrow.STRUCT, arrow.MAP:
+		return KustoTypeDynamic
+	default:
+		return KustoTypeString
+	}
+}
@@ -0,0 +1,115 @@
+package marshaler_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+)
+
+func TestCSVKustoSchemas(t *testing.T) {
+	schemas, err := marshaler.CSVKustoSchemas(marshaler.CSVConfig{})
+	if err != nil {
+		t.Fatalf("CSVKustoSchemas() failed: %v", err)
+	}
+	want := ".create table ['OTelLogs'] (['timestamp']:datetime, ['severity']:string, ['body']:dynamic, ['attributes']:dynamic, " +
+		"['severity_number']:int, ['trace_id']:string, ['span_id']:string, ['flags']:long, ['resource_attributes']:dynamic, " +
+		"['scope_name']:string, ['scope_version']:string)"
+	if got := schemas.Logs.CreateTableCommand("OTelLogs"); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	// The schema follows the header of the marshaled output.
+	metrics, _ := marshaler.NewCSVMarshaler().MarshalMetrics(testMetrics())
+	traces, _ := marshaler.NewCSVMarshaler().MarshalTraces(testTraces())
+	for _, tt := range []struct {
+		schema marshaler.KustoSchema
+		output []byte
+	}{
+		{schemas.Metrics, metrics},
+		{schemas.Traces, traces},
+	} {
+		header, _, _ := strings.Cut(string(tt.output), "\n")
+		var names []string
+		for _, column := range tt.schema.Columns {
+			names = append(names, column.Name)
+			if column.Type == "" {
+				t.Errorf("Expected column %s to have a type", column.Name)
+			}
+		}
+		if got := strings.Join(names, ","); got != header {
+			t.Errorf("Expected columns %s, got %s", header, got)
+		}
+	}
+
+	mapping, err := schemas.Traces.IngestionMapping()
+	if err != nil {
+		t.Fatalf("IngestionMapping() failed: %v", err)
+	}
+	var columns []struct {
+		Column     string
+		DataType   string
+		Properties map[string]string
+	}
+	if err := json.Unmarshal([]byte(mapping), &columns); err != nil {
+		t.Fatalf("Expected a JSON mapping, got %v", err)
+	}
+	if columns[5].Column != "start_timestamp" || columns[5].DataType != "datetime" || columns[5].Properties["Ordinal"] != "5" {
+		t.Errorf("Expected start_timestamp at ordinal 5, got %+v", columns[5])
+	}
+	if columns[16].Column != "events" || columns[16].DataType != "dynamic" {
+		t.Errorf("Expected dynamic events, got %+v", columns[16])
+	}
+
+	command, err := schemas.Logs.CreateMappingCommand("OTelLogs", "otlp_csv")
+	if err != nil {
+		t.Fatalf("CreateMappingCommand() failed: %v", err)
+	}
+	if !strings.HasPrefix(command, ".create-or-alter table ['OTelLogs'] ingestion csv mapping 'otlp_csv' '[{\"Column\":\"timestamp\"") {
+		t.Errorf("Unexpected mapping command: %s", command)
+	}
+}
+
+func TestCSVKustoSchemasWithConfig(t *testing.T) {
+	schemas, err := marshaler.CSVKustoSchemas(marshaler.CSVConfig{
+		Delimiter:         "\t",
+		TimestampFormat:   marshaler.TimestampFormatUnixNano,
+		AttributeEncoding: marshaler.AttributeEncodingFlat,
+		Columns:           marshaler.CSVColumns{Logs: []string{"body", "timestamp", "attributes"}},
+		Mapping: marshaler.ColumnMappings{Traces: []marshaler.ColumnMapping{
+			{Name: "Service", Source: "resource:service.name"},
+			{Name: "Duration", Source: "computed:duration", Type: marshaler.ColumnTypeInt},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("CSVKustoSchemas() failed: %v", err)
+	}
+	if schemas.Logs.Format != "tsv" || schemas.Logs.MappingKind() != "csv" {
+		t.Errorf("Expected tsv with a csv mapping, got %s and %s", schemas.Logs.Format, schemas.Logs.MappingKind())
+	}
+	if got := schemas.Logs.CreateTableCommand("L"); got != ".create table ['L'] (['body']:string, ['timestamp']:long, ['attributes']:string)" {
+		t.Errorf("Unexpected selected columns: %s", got)
+	}
+	if got := schemas.Traces.CreateTableCommand("T"); got != ".create table ['T'] (['Service']:string, ['Duration']:long)" {
+		t.Errorf("Unexpected mapped columns: %s", got)
+	}
+
+	if _, err := marshaler.CSVKustoSchemas(marshaler.CSVConfig{Delimiter: "#"}); err == nil {
+		t.Error("Expected a delimiter unsupported by Azure Data Explorer to be rejected")
+	}
+}
+
+func TestParquetKustoSchemas(t *testing.T) {
+	schemas := marshaler.ParquetKustoSchemas()
+	if schemas.Logs.MappingKind() != "parquet" {
+		t.Errorf("Expected a parquet mapping, got %s", schemas.Logs.MappingKind())
+	}
+	mapping, err := schemas.Logs.IngestionMapping()
+	if err != nil {
+		t.Fatalf("IngestionMapping() failed: %v", err)
+	}
+	if !strings.HasPrefix(mapping, `[{"Column":"timestamp","DataType":"datetime","Properties":{"Path":"$.timestamp"}}`) {
+		t.Errorf("Unexpected parquet mapping: %s", mapping)
+	}
+}
@@ -0,0 +1,188 @@
+package marshaler
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// LogfmtMarshaler writes logs as logfmt, one line of key=value pairs per log
+// record, in this order:
+//
+//	time       log record timestamp, or the observed timestamp, as RFC 3339
+//	level      severity text, or the name of the severity number
+//	msg        string body; the fields of a map body are flattened instead
+//	...        resource attributes, then log record attributes
+//	trace_id   hex encoded trace ID of the correlated span, when set
+//	span_id    hex encoded span ID of the correlated span, when set
+//
+// Nested maps and slices are flattened into dot separated keys, e.g.
+// http.request.header.0=value. Characters not allowed in keys are replaced by
+// '_', and values are quoted when needed.
+type LogfmtMarshaler struct{}
+
+func NewLogfmtMarshaler() LogfmtMarshaler {
+	return LogfmtMarshaler{}
+}
+
+// MarshalLogs converts OpenTelemetry logs into logfmt.
+func (m LogfmtMarshaler) MarshalLogs(ld plog.Logs) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteLogs(&buf, ld); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteLogs writes OpenTelemetry logs into w as logfmt, one line at a time.
+func (m LogfmtMarshaler) WriteLogs(w io.Writer, ld plog.Logs) error {
+	bw := bufio.NewWriter(w)
+	line := logfmtLine{}
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		ills := rl.ScopeLogs()
+		for j := 0; j < ills.Len(); j++ {
+			logs := ills.At(j).LogRecords()
+			for k := 0; k < logs.Len(); k++ {
+				lr := logs.At(k)
+				line.reset()
+				if ts := logTimestamp(lr); ts != 0 {
+					line.add("time", ts.AsTime().UTC().Format(time.RFC3339Nano))
+				}
+				if level := logLevel(lr); level != "" {
+					line.add("level", level)
+				}
+				if lr.Body().Type() == pcommon.ValueTypeMap {
+					line.addMap("", lr.Body().Map())
+				} else if lr.Body().Type() != pcommon.ValueTypeEmpty {
+					line.add("msg", lr.Body().AsString())
+				}
+				line.addMap("", rl.Resource().Attributes())
+				line.addMap("", lr.Attributes())
+				if !lr.TraceID().IsEmpty() {
+					line.add("trace_id", lr.TraceID().String())
+				}
+				if !lr.SpanID().IsEmpty() {
+					line.add("span_id", lr.SpanID().String())
+				}
+				line.buf.WriteByte('\n')
+				if _, err := bw.Write(line.buf.Bytes()); err != nil {
+					return fmt.Errorf("failed to write logfmt line: %w", err)
+				}
+			}
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write logfmt line: %w", err)
+	}
+	return nil
+}
+
+// logTimestamp returns the timestamp of a log record, falling back to the
+// time it was observed.
+func logTimestamp(lr plog.LogRecord) pcommon.Timestamp {
+	if lr.Timestamp() != 0 {
+		return lr.Timestamp()
+	}
+	return lr.ObservedTimestamp()
+}
+
+// logLevel returns the severity text of a log record, falling back to the
+// lower case name of its severity number.
+func logLevel(lr plog.LogRecord) string {
+	if lr.SeverityText() != "" {
+		return lr.SeverityText()
+	}
+	if lr.SeverityNumber() == plog.SeverityNumberUnspecified {
+		return ""
+	}
+	return strings.ToLower(lr.SeverityNumber().String())
+}
+
+// logfmtLine builds a logfmt line, reused from one log record to the next.
+type logfmtLine struct {
+	buf bytes.Buffer
+}
+
+func (l *logfmtLine) reset() {
+	l.buf.Reset()
+}
+
+// add appends a key=value pair.
+func (l *logfmtLine) add(key, value string) {
+	if l.buf.Len() > 0 {
+		l.buf.WriteByte(' ')
+	}
+	l.buf.WriteString(logfmtKey(key))
+	l.buf.WriteByte('=')
+	if logfmtNeedsQuotes(value) {
+		l.buf.WriteString(strconv.Quote(value))
+	} else {
+		l.buf.WriteString(value)
+	}
+}
+
+// addMap appends the entries of a map, flattening nested maps and slices.
+func (l *logfmtLine) addMap(prefix string, attrs pcommon.Map) {
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		l.addValue(prefix+k, v)
+		return true
+	})
+}
+
+func (l *logfmtLine) addValue(key string, v pcommon.Value) {
+	switch v.Type() {
+	case pcommon.ValueTypeMap:
+		if v.Map().Len() == 0 {
+			l.add(key, "")
+			return
+		}
+		l.addMap(key+".", v.Map())
+	case pcommon.ValueTypeSlice:
+		if v.Slice().Len() == 0 {
+			l.add(key, "")
+			return
+		}
+		for i := 0; i < v.Slice().Len(); i++ {
+			l.addValue(key+"."+strconv.Itoa(i), v.Slice().At(i))
+		}
+	default:
+		l.add(key, v.AsString())
+	}
+}
+
+// logfmtKey replaces the characters that are not allowed in a key by '_'.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtNeedsQuotes reports whether a value must be quoted: when it is empty
+// or holds spaces, '=', '"', '\' or non printable characters.
+func logfmtNeedsQuotes(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
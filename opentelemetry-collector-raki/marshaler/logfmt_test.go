@@ -0,0 +1,39 @@
+package marshaler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler/marshalertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestLogfmt(t *testing.T) {
+	m := marshaler.NewLogfmtLogs()
+	if _, ok := marshaler.BaseLogsMarshalers()[m.Encoding()]; !ok {
+		t.Errorf("Expected %s to be registered", m.Encoding())
+	}
+	ld := testLogs()
+	body := ld.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0).Body().SetEmptyMap()
+	body.PutStr("event", "withdrawal")
+	body.PutEmptySlice("notes").AppendEmpty().SetStr("first note")
+	ld.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0).SetSeverityNumber(plog.SeverityNumberWarn2)
+
+	buf, err := m.Marshal(ld)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	want := strings.Join([]string{
+		`time=2025-01-02T03:04:05Z level=INFO msg="hello, \"world\"" service.name=default/atm-111/app/0 service=atm attempt=3 trace_id=0102030405060708090a0b0c0d0e0f10 span_id=0102030405060708`,
+		`level=ERROR msg="multi\nline" service.name=default/atm-111/app/0`,
+		`level=warn2 event=withdrawal notes.0="first note" service.name=default/atm-222/app/0`,
+	}, "\n") + "\n"
+	if string(buf) != want {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", want, buf)
+	}
+}
+
+func TestLogfmtConformance(t *testing.T) {
+	marshalertest.TestLogs(t, marshaler.NewLogfmtLogs(), nil)
+}
@@ -1,6 +1,8 @@
 package marshaler
 
 import (
+	"io"
+
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -44,3 +46,101 @@ type Traces interface {
 	// before encodings e.g. x-protobuf
 	ContentType() string
 }
+
+// LogsUnmarshaler defines an interface for Unmarshaling bytes into logs.
+type LogsUnmarshaler interface {
+	// Unmarshal deserializes bytes into logs.
+	Unmarshal(buf []byte) (plog.Logs, error)
+
+	// Encoding is the name of the encoding that this unmarshaler supports.
+	Encoding() string
+}
+
+// MetricsUnmarshaler defines an interface for Unmarshaling bytes into metrics.
+type MetricsUnmarshaler interface {
+	// Unmarshal deserializes bytes into metrics.
+	Unmarshal(buf []byte) (pmetric.Metrics, error)
+
+	// Encoding is the name of the encoding that this unmarshaler supports.
+	Encoding() string
+}
+
+// TracesUnmarshaler defines an interface for Unmarshaling bytes into traces.
+type TracesUnmarshaler interface {
+	// Unmarshal deserializes bytes into traces.
+	Unmarshal(buf []byte) (ptrace.Traces, error)
+
+	// Encoding is the name of the encoding that this unmarshaler supports.
+	Encoding() string
+}
+
+// StreamingLogs is implemented by logs marshalers that can write their output
+// incrementally, so that the memory used does not grow with the batch size.
+type StreamingLogs interface {
+	Logs
+
+	// MarshalTo serializes logs into w.
+	MarshalTo(w io.Writer, logs plog.Logs) error
+}
+
+// StreamingMetrics is implemented by metrics marshalers that can write their
+// output incrementally, so that the memory used does not grow with the batch
+// size.
+type StreamingMetrics interface {
+	Metrics
+
+	// MarshalTo serializes metrics into w.
+	MarshalTo(w io.Writer, metrics pmetric.Metrics) error
+}
+
+// StreamingTraces is implemented by traces marshalers that can write their
+// output incrementally, so that the memory used does not grow with the batch
+// size.
+type StreamingTraces interface {
+	Traces
+
+	// MarshalTo serializes traces into w.
+	MarshalTo(w io.Writer, traces ptrace.Traces) error
+}
+
+// MarshalLogsTo serializes logs into w, streaming them when the marshaler
+// supports it and writing the marshaled bytes otherwise.
+func MarshalLogsTo(w io.Writer, m Logs, logs plog.Logs) error {
+	if sm, ok := m.(StreamingLogs); ok {
+		return sm.MarshalTo(w, logs)
+	}
+	b, err := m.Marshal(logs)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// MarshalMetricsTo serializes metrics into w, streaming them when the
+// marshaler supports it and writing the marshaled bytes otherwise.
+func MarshalMetricsTo(w io.Writer, m Metrics, metrics pmetric.Metrics) error {
+	if sm, ok := m.(StreamingMetrics); ok {
+		return sm.MarshalTo(w, metrics)
+	}
+	b, err := m.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// MarshalTracesTo serializes traces into w, streaming them when the
+// marshaler supports it and writing the marshaled bytes otherwise.
+func MarshalTracesTo(w io.Writer, m Traces, traces ptrace.Traces) error {
+	if sm, ok := m.(StreamingTraces); ok {
+		return sm.MarshalTo(w, traces)
+	}
+	b, err := m.Marshal(traces)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
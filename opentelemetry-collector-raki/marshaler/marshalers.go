@@ -1,5 +1,7 @@
 package marshaler
 
+import "maps"
+
 // Marshalers is a collection of marshalers for logs, metrics, and traces
 type Marshalers struct {
 	Logs    map[string]Logs
@@ -19,23 +21,93 @@ func BaseMarshalers() *Marshalers {
 // baseLogsMarshalers returns the set of supported logs marshalers
 func BaseLogsMarshalers() map[string]Logs {
 	otlpCsv := NewOtlpCsvLogs()
-	return map[string]Logs{
-		otlpCsv.Encoding(): otlpCsv,
-	}
+	otlpParquet := NewOtlpParquetLogs()
+	otlpArrowIPC := NewOtlpArrowIPCLogs()
+	otlpProtobuf := NewOtlpProtobufLogs()
+	otlpJSON := NewOtlpJSONLogs()
+	logfmt := NewLogfmtLogs()
+	syslog := NewSyslogLogs()
+	return withCompressedLogs(map[string]Logs{
+		otlpCsv.Encoding():      otlpCsv,
+		otlpParquet.Encoding():  otlpParquet,
+		otlpArrowIPC.Encoding(): otlpArrowIPC,
+		otlpProtobuf.Encoding(): otlpProtobuf,
+		otlpJSON.Encoding():     otlpJSON,
+		logfmt.Encoding():       logfmt,
+		syslog.Encoding():       syslog,
+	})
 }
 
 // baseMetricsMarshalers returns the set of supported metrics marshalers
 func BaseMetricsMarshalers() map[string]Metrics {
 	otlpCsv := NewOtlpCsvMetrics()
-	return map[string]Metrics{
-		otlpCsv.Encoding(): otlpCsv,
-	}
+	otlpParquet := NewOtlpParquetMetrics()
+	otlpArrowIPC := NewOtlpArrowIPCMetrics()
+	otlpProtobuf := NewOtlpProtobufMetrics()
+	otlpJSON := NewOtlpJSONMetrics()
+	prometheusText := NewPrometheusTextMetrics()
+	return withCompressedMetrics(map[string]Metrics{
+		otlpCsv.Encoding():        otlpCsv,
+		otlpParquet.Encoding():    otlpParquet,
+		otlpArrowIPC.Encoding():   otlpArrowIPC,
+		otlpProtobuf.Encoding():   otlpProtobuf,
+		otlpJSON.Encoding():       otlpJSON,
+		prometheusText.Encoding(): prometheusText,
+	})
 }
 
 // baseTracesMarshalers returns the set of supported traces marshalers
 func BaseTracesMarshalers() map[string]Traces {
 	otlpCsv := NewOtlpCsvTraces()
-	return map[string]Traces{
-		otlpCsv.Encoding(): otlpCsv,
+	otlpParquet := NewOtlpParquetTraces()
+	otlpArrowIPC := NewOtlpArrowIPCTraces()
+	otlpProtobuf := NewOtlpProtobufTraces()
+	otlpJSON := NewOtlpJSONTraces()
+	chromeTrace := NewChromeTraceTraces()
+	foldedStacks := NewFoldedStacksTraces()
+	return withCompressedTraces(map[string]Traces{
+		otlpCsv.Encoding():      otlpCsv,
+		otlpParquet.Encoding():  otlpParquet,
+		otlpArrowIPC.Encoding(): otlpArrowIPC,
+		otlpProtobuf.Encoding(): otlpProtobuf,
+		otlpJSON.Encoding():     otlpJSON,
+		chromeTrace.Encoding():  chromeTrace,
+		foldedStacks.Encoding(): foldedStacks,
+	})
+}
+
+// withCompressedLogs registers every compression of the given logs marshalers
+// under their combined encoding, e.g. otlp_csv+zstd.
+func withCompressedLogs(marshalers map[string]Logs) map[string]Logs {
+	for _, m := range maps.Clone(marshalers) {
+		for _, compression := range Compressions() {
+			compressed, _ := NewCompressedLogs(m, compression)
+			marshalers[compressed.Encoding()] = compressed
+		}
+	}
+	return marshalers
+}
+
+// withCompressedMetrics registers every compression of the given metrics
+// marshalers under their combined encoding, e.g. otlp_csv+zstd.
+func withCompressedMetrics(marshalers map[string]Metrics) map[string]Metrics {
+	for _, m := range maps.Clone(marshalers) {
+		for _, compression := range Compressions() {
+			compressed, _ := NewCompressedMetrics(m, compression)
+			marshalers[compressed.Encoding()] = compressed
+		}
+	}
+	return marshalers
+}
+
+// withCompressedTraces registers every compression of the given traces
+// marshalers under their combined encoding, e.g. otlp_csv+zstd.
+func withCompressedTraces(marshalers map[string]Traces) map[string]Traces {
+	for _, m := range maps.Clone(marshalers) {
+		for _, compression := range Compressions() {
+			compressed, _ := NewCompressedTraces(m, compression)
+			marshalers[compressed.Encoding()] = compressed
+		}
 	}
+	return marshalers
 }
@@ -0,0 +1,316 @@
+package marshalertest
+
+import (
+	"math"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// LogsCase is a named batch of logs of the corpus.
+type LogsCase struct {
+	Name string
+	Logs plog.Logs
+}
+
+// MetricsCase is a named batch of metrics of the corpus.
+type MetricsCase struct {
+	Name    string
+	Metrics pmetric.Metrics
+}
+
+// TracesCase is a named batch of traces of the corpus.
+type TracesCase struct {
+	Name   string
+	Traces ptrace.Traces
+}
+
+var (
+	corpusTimestamp = pcommon.NewTimestampFromTime(time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC))
+	corpusTraceID   = pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	corpusSpanID    = pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8}
+)
+
+// LogsCorpus returns the logs corpus. Every call returns new batches, which
+// the caller may modify.
+func LogsCorpus() []LogsCase {
+	return []LogsCase{
+		{Name: "empty", Logs: plog.NewLogs()},
+		{Name: "empty_resource", Logs: emptyResourceLogs()},
+		{Name: "unicode", Logs: unicodeLogs()},
+		{Name: "nested_attributes", Logs: nestedAttributesLogs()},
+		{Name: "multiple_resources", Logs: multipleResourcesLogs()},
+	}
+}
+
+func emptyResourceLogs() plog.Logs {
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	return ld
+}
+
+func unicodeLogs() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "caisse-épargne/ATM-東京")
+	lr := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetTimestamp(corpusTimestamp)
+	lr.SetSeverityText("AVERTISSEMENT")
+	lr.SetSeverityNumber(plog.SeverityNumberWarn)
+	lr.Body().SetStr("héllo, \"wörld\"\n\t世界 🚀; a=b")
+	lr.Attributes().PutStr("clé", "välue; with=separators")
+	lr.Attributes().PutStr("emoji", "🏧💳")
+	return ld
+}
+
+func nestedAttributesLogs() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "atm")
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("filelog")
+	sl.Scope().SetVersion("v0.117.0")
+	lr := sl.LogRecords().AppendEmpty()
+	lr.SetTimestamp(corpusTimestamp)
+	lr.SetObservedTimestamp(corpusTimestamp + 1)
+	lr.SetSeverityNumber(plog.SeverityNumberError)
+	lr.SetTraceID(corpusTraceID)
+	lr.SetSpanID(corpusSpanID)
+	lr.SetFlags(plog.DefaultLogRecordFlags.WithIsSampled(true))
+	putNestedAttributes(lr.Attributes())
+	body := lr.Body().SetEmptyMap()
+	body.PutStr("user", "alice")
+	body.PutEmptySlice("items").AppendEmpty().SetInt(42)
+	return ld
+}
+
+func multipleResourcesLogs() plog.Logs {
+	ld := plog.NewLogs()
+	for _, service := range []string{"atm", "bank"} {
+		rl := ld.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("service.name", service)
+		for _, scope := range []string{"filelog", "otlp"} {
+			sl := rl.ScopeLogs().AppendEmpty()
+			sl.Scope().SetName(scope)
+			lr := sl.LogRecords().AppendEmpty()
+			lr.SetTimestamp(corpusTimestamp)
+			lr.Body().SetStr(service + " " + scope)
+		}
+	}
+	return ld
+}
+
+// putNestedAttributes puts a value of every type into attrs.
+func putNestedAttributes(attrs pcommon.Map) {
+	attrs.PutStr("str", "value")
+	attrs.PutStr("empty.str", "")
+	attrs.PutInt("int", math.MaxInt64)
+	attrs.PutDouble("double", 3.25)
+	attrs.PutDouble("nan", math.NaN())
+	attrs.PutBool("bool", true)
+	attrs.PutEmptyBytes("bytes").FromRaw([]byte{0, 1, 0xff})
+	slice := attrs.PutEmptySlice("slice")
+	slice.AppendEmpty().SetStr("a")
+	slice.AppendEmpty().SetInt(1)
+	nested := attrs.PutEmptyMap("map")
+	nested.PutStr("k", "v")
+	nested.PutEmptyMap("inner").PutBool("deep", false)
+	attrs.PutEmptyMap("empty.map")
+	attrs.PutEmpty("empty")
+}
+
+// MetricsCorpus returns the metrics corpus, covering every metric type. Every
+// call returns new batches, which the caller may modify.
+func MetricsCorpus() []MetricsCase {
+	return []MetricsCase{
+		{Name: "empty", Metrics: pmetric.NewMetrics()},
+		{Name: "gauge", Metrics: gaugeMetrics()},
+		{Name: "sum", Metrics: sumMetrics()},
+		{Name: "histogram", Metrics: histogramMetrics()},
+		{Name: "exponential_histogram", Metrics: exponentialHistogramMetrics()},
+		{Name: "summary", Metrics: summaryMetrics()},
+		{Name: "unicode", Metrics: unicodeMetrics()},
+	}
+}
+
+// newMetric appends a metric to a new batch with a resource and a scope.
+func newMetric(name string) (pmetric.Metrics, pmetric.Metric) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "atm")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("hostmetrics")
+	metric := sm.Metrics().AppendEmpty()
+	metric.SetName(name)
+	metric.SetDescription("description of " + name)
+	metric.SetUnit("1")
+	return md, metric
+}
+
+func gaugeMetrics() pmetric.Metrics {
+	md, metric := newMetric("atm.temperature")
+	dps := metric.SetEmptyGauge().DataPoints()
+	dp := dps.AppendEmpty()
+	dp.SetTimestamp(corpusTimestamp)
+	dp.SetIntValue(-21)
+	dp.Attributes().PutStr("sensor", "cpu")
+	dp = dps.AppendEmpty()
+	dp.SetTimestamp(corpusTimestamp + 1)
+	dp.SetDoubleValue(21.5)
+	return md
+}
+
+func sumMetrics() pmetric.Metrics {
+	md, metric := newMetric("atm.withdrawals")
+	sum := metric.SetEmptySum()
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	sum.SetIsMonotonic(true)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(corpusTimestamp - 1e9)
+	dp.SetTimestamp(corpusTimestamp)
+	dp.SetIntValue(7)
+	putNestedAttributes(dp.Attributes())
+	return md
+}
+
+func histogramMetrics() pmetric.Metrics {
+	md, metric := newMetric("atm.latency")
+	histogram := metric.SetEmptyHistogram()
+	histogram.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	dp := histogram.DataPoints().AppendEmpty()
+	dp.SetTimestamp(corpusTimestamp)
+	dp.SetCount(6)
+	dp.SetSum(1.5)
+	dp.SetMin(0.1)
+	dp.SetMax(0.9)
+	dp.BucketCounts().FromRaw([]uint64{1, 2, 3})
+	dp.ExplicitBounds().FromRaw([]float64{0.25, 0.5})
+	return md
+}
+
+func exponentialHistogramMetrics() pmetric.Metrics {
+	md, metric := newMetric("atm.latency.exponential")
+	histogram := metric.SetEmptyExponentialHistogram()
+	histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := histogram.DataPoints().AppendEmpty()
+	dp.SetTimestamp(corpusTimestamp)
+	dp.SetCount(5)
+	dp.SetSum(2.5)
+	dp.SetScale(-2)
+	dp.SetZeroCount(1)
+	dp.Positive().SetOffset(-1)
+	dp.Positive().BucketCounts().FromRaw([]uint64{1, 2})
+	dp.Negative().SetOffset(3)
+	dp.Negative().BucketCounts().FromRaw([]uint64{1})
+	return md
+}
+
+func summaryMetrics() pmetric.Metrics {
+	md, metric := newMetric("atm.session")
+	dp := metric.SetEmptySummary().DataPoints().AppendEmpty()
+	dp.SetTimestamp(corpusTimestamp)
+	dp.SetCount(10)
+	dp.SetSum(100)
+	for _, q := range []float64{0, 0.5, 0.99, 1} {
+		qv := dp.QuantileValues().AppendEmpty()
+		qv.SetQuantile(q)
+		qv.SetValue(q * 10)
+	}
+	return md
+}
+
+func unicodeMetrics() pmetric.Metrics {
+	md, metric := newMetric("guichet.retraits.€")
+	dp := metric.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(corpusTimestamp)
+	dp.SetDoubleValue(math.Inf(1))
+	dp.Attributes().PutStr("ville", "Zürich, \"CH\"")
+	return md
+}
+
+// TracesCorpus returns the traces corpus, covering span links and events.
+// Every call returns new batches, which the caller may modify.
+func TracesCorpus() []TracesCase {
+	return []TracesCase{
+		{Name: "empty", Traces: ptrace.NewTraces()},
+		{Name: "parent_child", Traces: parentChildTraces()},
+		{Name: "links_and_events", Traces: linksAndEventsTraces()},
+		{Name: "unicode", Traces: unicodeTraces()},
+	}
+}
+
+// newSpans appends a span slice to a new batch with a resource and a scope.
+func newSpans() (ptrace.Traces, ptrace.SpanSlice) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "atm")
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("otelhttp")
+	ss.Scope().SetVersion("v0.58.0")
+	return td, ss.Spans()
+}
+
+func parentChildTraces() ptrace.Traces {
+	td, spans := newSpans()
+	root := spans.AppendEmpty()
+	root.SetTraceID(corpusTraceID)
+	root.SetSpanID(corpusSpanID)
+	root.SetName("GET /balance")
+	root.SetKind(ptrace.SpanKindServer)
+	root.SetStartTimestamp(corpusTimestamp)
+	root.SetEndTimestamp(corpusTimestamp + 1e9)
+	root.Status().SetCode(ptrace.StatusCodeError)
+	root.Status().SetMessage("insufficient funds")
+	root.Attributes().PutStr("atm.id", "42")
+
+	child := spans.AppendEmpty()
+	child.SetTraceID(corpusTraceID)
+	child.SetSpanID(pcommon.SpanID{8, 7, 6, 5, 4, 3, 2, 1})
+	child.SetParentSpanID(corpusSpanID)
+	child.SetName("SELECT accounts")
+	child.SetKind(ptrace.SpanKindClient)
+	child.SetStartTimestamp(corpusTimestamp + 1)
+	child.SetEndTimestamp(corpusTimestamp + 2)
+	child.Status().SetCode(ptrace.StatusCodeOk)
+	return td
+}
+
+func linksAndEventsTraces() ptrace.Traces {
+	td, spans := newSpans()
+	span := spans.AppendEmpty()
+	span.SetTraceID(corpusTraceID)
+	span.SetSpanID(corpusSpanID)
+	span.TraceState().FromRaw("vendor=value")
+	span.SetName("withdraw")
+	span.SetKind(ptrace.SpanKindInternal)
+	span.SetStartTimestamp(corpusTimestamp)
+	span.SetEndTimestamp(corpusTimestamp + 5)
+	putNestedAttributes(span.Attributes())
+
+	event := span.Events().AppendEmpty()
+	event.SetName("exception")
+	event.SetTimestamp(corpusTimestamp + 3)
+	event.Attributes().PutStr("exception.message", "card rejected")
+	span.Events().AppendEmpty().SetName("retry")
+
+	link := span.Links().AppendEmpty()
+	link.SetTraceID(pcommon.TraceID{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	link.SetSpanID(pcommon.SpanID{1, 1, 1, 1, 1, 1, 1, 1})
+	link.Attributes().PutStr("link.kind", "follows_from")
+	return td
+}
+
+func unicodeTraces() ptrace.Traces {
+	td, spans := newSpans()
+	span := spans.AppendEmpty()
+	span.SetTraceID(corpusTraceID)
+	span.SetSpanID(corpusSpanID)
+	span.SetName("retrait d'espèces, \"€\" 🏧")
+	span.SetStartTimestamp(corpusTimestamp)
+	span.SetEndTimestamp(corpusTimestamp + 1)
+	span.Status().SetMessage("ошибка\nстрока")
+	return td
+}
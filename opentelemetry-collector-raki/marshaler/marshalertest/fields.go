@@ -0,0 +1,321 @@
+package marshalertest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// fields maps the path of every field set in a batch to its value. Records
+// are numbered in batch order and carry the fields of their resource and
+// scope, so that a batch regrouped by an unmarshaler compares equal to the
+// original. Fields holding their zero value are left out.
+type fields map[string]string
+
+func (f fields) put(path, value string) {
+	if value != "" && value != "0" && value != "false" {
+		f[path] = value
+	}
+}
+
+func (f fields) putInt(path string, i int64) {
+	f.put(path, strconv.FormatInt(i, 10))
+}
+
+func (f fields) putUint(path string, i uint64) {
+	f.put(path, strconv.FormatUint(i, 10))
+}
+
+func (f fields) putDouble(path string, d float64) {
+	f.put(path, strconv.FormatFloat(d, 'g', -1, 64))
+}
+
+func (f fields) putTimestamp(path string, ts pcommon.Timestamp) {
+	f.putUint(path, uint64(ts))
+}
+
+// putValue puts a value tagged with its type. Maps and slices are flattened
+// into one field per element, empty ones being kept as a field of their own.
+func (f fields) putValue(path string, v pcommon.Value) {
+	switch v.Type() {
+	case pcommon.ValueTypeMap:
+		if v.Map().Len() == 0 {
+			f[path] = "Map()"
+		}
+		f.putMap(path, v.Map())
+	case pcommon.ValueTypeSlice:
+		if v.Slice().Len() == 0 {
+			f[path] = "Slice()"
+		}
+		for i := 0; i < v.Slice().Len(); i++ {
+			f.putValue(fmt.Sprintf("%s[%d]", path, i), v.Slice().At(i))
+		}
+	case pcommon.ValueTypeDouble:
+		f[path] = fmt.Sprintf("Double(%s)", strconv.FormatFloat(v.Double(), 'g', -1, 64))
+	default:
+		f[path] = fmt.Sprintf("%s(%s)", v.Type(), v.AsString())
+	}
+}
+
+func (f fields) putMap(path string, m pcommon.Map) {
+	m.Range(func(k string, v pcommon.Value) bool {
+		f.putValue(path+"."+k, v)
+		return true
+	})
+}
+
+func (f fields) putResource(path string, r pcommon.Resource) {
+	f.putMap(path+".resource.attributes", r.Attributes())
+	f.putUint(path+".resource.dropped_attributes_count", uint64(r.DroppedAttributesCount()))
+}
+
+func (f fields) putScope(path string, s pcommon.InstrumentationScope) {
+	f.put(path+".scope.name", s.Name())
+	f.put(path+".scope.version", s.Version())
+	f.putMap(path+".scope.attributes", s.Attributes())
+	f.putUint(path+".scope.dropped_attributes_count", uint64(s.DroppedAttributesCount()))
+}
+
+func (f fields) putIDs(path string, traceID pcommon.TraceID, spanID pcommon.SpanID) {
+	if !traceID.IsEmpty() {
+		f.put(path+".trace_id", traceID.String())
+	}
+	if !spanID.IsEmpty() {
+		f.put(path+".span_id", spanID.String())
+	}
+}
+
+// lost lists the fields of want that are missing or different in got, as
+// path: want value, got value.
+func lost(want, got fields) []string {
+	var lost []string
+	for path, w := range want {
+		if g, ok := got[path]; !ok {
+			lost = append(lost, fmt.Sprintf("%s: want %s, got nothing", path, w))
+		} else if g != w {
+			lost = append(lost, fmt.Sprintf("%s: want %s, got %s", path, w, g))
+		}
+	}
+	sort.Strings(lost)
+	return lost
+}
+
+func logsFields(ld plog.Logs) fields {
+	f := fields{}
+	n := 0
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				lr := sl.LogRecords().At(k)
+				path := fmt.Sprintf("log[%d]", n)
+				n++
+				f.putResource(path, rl.Resource())
+				f.putScope(path, sl.Scope())
+				f.putTimestamp(path+".timestamp", lr.Timestamp())
+				f.putTimestamp(path+".observed_timestamp", lr.ObservedTimestamp())
+				f.put(path+".severity_text", lr.SeverityText())
+				f.putInt(path+".severity_number", int64(lr.SeverityNumber()))
+				if lr.Body().Type() != pcommon.ValueTypeEmpty {
+					f.putValue(path+".body", lr.Body())
+				}
+				f.putMap(path+".attributes", lr.Attributes())
+				f.putUint(path+".dropped_attributes_count", uint64(lr.DroppedAttributesCount()))
+				f.putUint(path+".flags", uint64(lr.Flags()))
+				f.putIDs(path, lr.TraceID(), lr.SpanID())
+			}
+		}
+	}
+	return f
+}
+
+// metricsFields numbers data points rather than metrics, a metric without
+// data points having nothing to export.
+func metricsFields(md pmetric.Metrics) fields {
+	f := fields{}
+	n := 0
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				metric := sm.Metrics().At(k)
+				dataPoints := metricDataPoints(metric)
+				for _, putDataPoint := range dataPoints {
+					path := fmt.Sprintf("datapoint[%d]", n)
+					n++
+					f.putResource(path, rm.Resource())
+					f.putScope(path, sm.Scope())
+					f.put(path+".metric.name", metric.Name())
+					f.put(path+".metric.description", metric.Description())
+					f.put(path+".metric.unit", metric.Unit())
+					f.put(path+".metric.type", metric.Type().String())
+					putDataPoint(f, path)
+				}
+			}
+		}
+	}
+	return f
+}
+
+// metricDataPoints returns a function putting the fields of each data point
+// of a metric.
+func metricDataPoints(metric pmetric.Metric) []func(f fields, path string) {
+	var points []func(f fields, path string)
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := metric.Gauge().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			points = append(points, func(f fields, path string) {
+				putNumberDataPoint(f, path, dp)
+			})
+		}
+	case pmetric.MetricTypeSum:
+		sum := metric.Sum()
+		for i := 0; i < sum.DataPoints().Len(); i++ {
+			dp := sum.DataPoints().At(i)
+			points = append(points, func(f fields, path string) {
+				f.put(path+".metric.aggregation_temporality", sum.AggregationTemporality().String())
+				f.put(path+".metric.monotonic", strconv.FormatBool(sum.IsMonotonic()))
+				putNumberDataPoint(f, path, dp)
+			})
+		}
+	case pmetric.MetricTypeHistogram:
+		histogram := metric.Histogram()
+		for i := 0; i < histogram.DataPoints().Len(); i++ {
+			dp := histogram.DataPoints().At(i)
+			points = append(points, func(f fields, path string) {
+				f.put(path+".metric.aggregation_temporality", histogram.AggregationTemporality().String())
+				putDataPoint(f, path, dp.StartTimestamp(), dp.Timestamp(), dp.Attributes(), uint32(dp.Flags()), dp.Exemplars())
+				f.putUint(path+".count", dp.Count())
+				if dp.HasSum() {
+					f.putDouble(path+".sum", dp.Sum())
+				}
+				if dp.HasMin() {
+					f.putDouble(path+".min", dp.Min())
+				}
+				if dp.HasMax() {
+					f.putDouble(path+".max", dp.Max())
+				}
+				f.put(path+".bucket_counts", fmt.Sprint(dp.BucketCounts().AsRaw()))
+				f.put(path+".explicit_bounds", fmt.Sprint(dp.ExplicitBounds().AsRaw()))
+			})
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		histogram := metric.ExponentialHistogram()
+		for i := 0; i < histogram.DataPoints().Len(); i++ {
+			dp := histogram.DataPoints().At(i)
+			points = append(points, func(f fields, path string) {
+				f.put(path+".metric.aggregation_temporality", histogram.AggregationTemporality().String())
+				putDataPoint(f, path, dp.StartTimestamp(), dp.Timestamp(), dp.Attributes(), uint32(dp.Flags()), dp.Exemplars())
+				f.putUint(path+".count", dp.Count())
+				if dp.HasSum() {
+					f.putDouble(path+".sum", dp.Sum())
+				}
+				if dp.HasMin() {
+					f.putDouble(path+".min", dp.Min())
+				}
+				if dp.HasMax() {
+					f.putDouble(path+".max", dp.Max())
+				}
+				f.putInt(path+".scale", int64(dp.Scale()))
+				f.putUint(path+".zero_count", dp.ZeroCount())
+				f.putDouble(path+".zero_threshold", dp.ZeroThreshold())
+				f.putInt(path+".positive.offset", int64(dp.Positive().Offset()))
+				f.put(path+".positive.bucket_counts", fmt.Sprint(dp.Positive().BucketCounts().AsRaw()))
+				f.putInt(path+".negative.offset", int64(dp.Negative().Offset()))
+				f.put(path+".negative.bucket_counts", fmt.Sprint(dp.Negative().BucketCounts().AsRaw()))
+			})
+		}
+	case pmetric.MetricTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			points = append(points, func(f fields, path string) {
+				putDataPoint(f, path, dp.StartTimestamp(), dp.Timestamp(), dp.Attributes(), uint32(dp.Flags()), pmetric.NewExemplarSlice())
+				f.putUint(path+".count", dp.Count())
+				f.putDouble(path+".sum", dp.Sum())
+				for q := 0; q < dp.QuantileValues().Len(); q++ {
+					qv := dp.QuantileValues().At(q)
+					f[fmt.Sprintf("%s.quantile[%d]", path, q)] = fmt.Sprintf("%g=%g", qv.Quantile(), qv.Value())
+				}
+			})
+		}
+	}
+	return points
+}
+
+func putNumberDataPoint(f fields, path string, dp pmetric.NumberDataPoint) {
+	putDataPoint(f, path, dp.StartTimestamp(), dp.Timestamp(), dp.Attributes(), uint32(dp.Flags()), dp.Exemplars())
+	switch dp.ValueType() {
+	case pmetric.NumberDataPointValueTypeInt:
+		f[path+".value"] = fmt.Sprintf("Int(%d)", dp.IntValue())
+	case pmetric.NumberDataPointValueTypeDouble:
+		f[path+".value"] = fmt.Sprintf("Double(%g)", dp.DoubleValue())
+	}
+}
+
+func putDataPoint(f fields, path string, start, ts pcommon.Timestamp, attrs pcommon.Map, flags uint32, exemplars pmetric.ExemplarSlice) {
+	f.putTimestamp(path+".start_timestamp", start)
+	f.putTimestamp(path+".timestamp", ts)
+	f.putMap(path+".attributes", attrs)
+	f.putUint(path+".flags", uint64(flags))
+	f.putInt(path+".exemplars", int64(exemplars.Len()))
+}
+
+func tracesFields(td ptrace.Traces) fields {
+	f := fields{}
+	n := 0
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				span := ss.Spans().At(k)
+				path := fmt.Sprintf("span[%d]", n)
+				n++
+				f.putResource(path, rs.Resource())
+				f.putScope(path, ss.Scope())
+				f.putIDs(path, span.TraceID(), span.SpanID())
+				if !span.ParentSpanID().IsEmpty() {
+					f.put(path+".parent_span_id", span.ParentSpanID().String())
+				}
+				f.put(path+".trace_state", span.TraceState().AsRaw())
+				f.putUint(path+".flags", uint64(span.Flags()))
+				f.put(path+".name", span.Name())
+				f.putInt(path+".kind", int64(span.Kind()))
+				f.putTimestamp(path+".start_timestamp", span.StartTimestamp())
+				f.putTimestamp(path+".end_timestamp", span.EndTimestamp())
+				f.putMap(path+".attributes", span.Attributes())
+				f.putUint(path+".dropped_attributes_count", uint64(span.DroppedAttributesCount()))
+				f.putInt(path+".status.code", int64(span.Status().Code()))
+				f.put(path+".status.message", span.Status().Message())
+				for e := 0; e < span.Events().Len(); e++ {
+					event := span.Events().At(e)
+					eventPath := fmt.Sprintf("%s.events[%d]", path, e)
+					f[eventPath+".name"] = event.Name()
+					f.putTimestamp(eventPath+".timestamp", event.Timestamp())
+					f.putMap(eventPath+".attributes", event.Attributes())
+				}
+				f.putUint(path+".dropped_events_count", uint64(span.DroppedEventsCount()))
+				for l := 0; l < span.Links().Len(); l++ {
+					link := span.Links().At(l)
+					linkPath := fmt.Sprintf("%s.links[%d]", path, l)
+					f[linkPath] = "Link"
+					f.putIDs(linkPath, link.TraceID(), link.SpanID())
+					f.put(linkPath+".trace_state", link.TraceState().AsRaw())
+					f.putMap(linkPath+".attributes", link.Attributes())
+				}
+				f.putUint(path+".dropped_links_count", uint64(span.DroppedLinksCount()))
+			}
+		}
+	}
+	return f
+}
@@ -0,0 +1,244 @@
+// Package marshalertest checks that marshalers implement the marshaler
+// interfaces consistently, by running them against a corpus of batches that
+// are easy to get wrong: empty batches, unicode, nested attributes, every
+// metric type, and spans with links and events.
+//
+// Fields a marshaler does not preserve are not errors, most formats being
+// lossy by design: when an unmarshaler is given, the fields lost across a
+// round-trip are logged by the Test functions and returned by the Lost
+// functions.
+package marshalertest
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// TestLogs runs a logs marshaler against the logs corpus. u may be nil when
+// the encoding has no unmarshaler.
+func TestLogs(t *testing.T, m marshaler.Logs, u marshaler.LogsUnmarshaler) {
+	checkEncoding(t, m, m.Encoding(), m.ContentType(), u)
+	for _, c := range LogsCorpus() {
+		t.Run(c.Name, func(t *testing.T) {
+			want := logsFields(c.Logs)
+			b, err := m.Marshal(c.Logs)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			if got := logsFields(c.Logs); len(lost(want, got)) > 0 || len(got) != len(want) {
+				t.Errorf("Marshal() modified its input")
+			}
+			again, err := m.Marshal(c.Logs)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			checkDeterministic(t, b, again)
+			if sm, ok := m.(marshaler.StreamingLogs); ok {
+				buf := bytes.Buffer{}
+				if err := sm.MarshalTo(&buf, c.Logs); err != nil {
+					t.Fatalf("MarshalTo() failed: %v", err)
+				}
+				checkStreamed(t, b, buf.Bytes())
+			}
+			if u == nil {
+				return
+			}
+			decoded, err := u.Unmarshal(b)
+			if err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			logLost(t, lost(want, logsFields(decoded)))
+			remarshaled, err := m.Marshal(decoded)
+			if err != nil {
+				t.Fatalf("Marshal() of the unmarshaled logs failed: %v", err)
+			}
+			checkRoundTrip(t, b, remarshaled)
+		})
+	}
+}
+
+// TestMetrics runs a metrics marshaler against the metrics corpus. u may be
+// nil when the encoding has no unmarshaler.
+func TestMetrics(t *testing.T, m marshaler.Metrics, u marshaler.MetricsUnmarshaler) {
+	checkEncoding(t, m, m.Encoding(), m.ContentType(), u)
+	for _, c := range MetricsCorpus() {
+		t.Run(c.Name, func(t *testing.T) {
+			want := metricsFields(c.Metrics)
+			b, err := m.Marshal(c.Metrics)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			if got := metricsFields(c.Metrics); len(lost(want, got)) > 0 || len(got) != len(want) {
+				t.Errorf("Marshal() modified its input")
+			}
+			again, err := m.Marshal(c.Metrics)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			checkDeterministic(t, b, again)
+			if sm, ok := m.(marshaler.StreamingMetrics); ok {
+				buf := bytes.Buffer{}
+				if err := sm.MarshalTo(&buf, c.Metrics); err != nil {
+					t.Fatalf("MarshalTo() failed: %v", err)
+				}
+				checkStreamed(t, b, buf.Bytes())
+			}
+			if u == nil {
+				return
+			}
+			decoded, err := u.Unmarshal(b)
+			if err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			logLost(t, lost(want, metricsFields(decoded)))
+			remarshaled, err := m.Marshal(decoded)
+			if err != nil {
+				t.Fatalf("Marshal() of the unmarshaled metrics failed: %v", err)
+			}
+			checkRoundTrip(t, b, remarshaled)
+		})
+	}
+}
+
+// TestTraces runs a traces marshaler against the traces corpus. u may be nil
+// when the encoding has no unmarshaler.
+func TestTraces(t *testing.T, m marshaler.Traces, u marshaler.TracesUnmarshaler) {
+	checkEncoding(t, m, m.Encoding(), m.ContentType(), u)
+	for _, c := range TracesCorpus() {
+		t.Run(c.Name, func(t *testing.T) {
+			want := tracesFields(c.Traces)
+			b, err := m.Marshal(c.Traces)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			if got := tracesFields(c.Traces); len(lost(want, got)) > 0 || len(got) != len(want) {
+				t.Errorf("Marshal() modified its input")
+			}
+			again, err := m.Marshal(c.Traces)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			checkDeterministic(t, b, again)
+			if sm, ok := m.(marshaler.StreamingTraces); ok {
+				buf := bytes.Buffer{}
+				if err := sm.MarshalTo(&buf, c.Traces); err != nil {
+					t.Fatalf("MarshalTo() failed: %v", err)
+				}
+				checkStreamed(t, b, buf.Bytes())
+			}
+			if u == nil {
+				return
+			}
+			decoded, err := u.Unmarshal(b)
+			if err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			logLost(t, lost(want, tracesFields(decoded)))
+			remarshaled, err := m.Marshal(decoded)
+			if err != nil {
+				t.Fatalf("Marshal() of the unmarshaled traces failed: %v", err)
+			}
+			checkRoundTrip(t, b, remarshaled)
+		})
+	}
+}
+
+// LostLogs marshals and unmarshals logs and returns the fields lost on the
+// way, as path: want value, got value.
+func LostLogs(m marshaler.Logs, u marshaler.LogsUnmarshaler, ld plog.Logs) ([]string, error) {
+	b, err := m.Marshal(ld)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal logs: %w", err)
+	}
+	decoded, err := u.Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal logs: %w", err)
+	}
+	return lost(logsFields(ld), logsFields(decoded)), nil
+}
+
+// LostMetrics marshals and unmarshals metrics and returns the fields lost on
+// the way, as path: want value, got value.
+func LostMetrics(m marshaler.Metrics, u marshaler.MetricsUnmarshaler, md pmetric.Metrics) ([]string, error) {
+	b, err := m.Marshal(md)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+	decoded, err := u.Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metrics: %w", err)
+	}
+	return lost(metricsFields(md), metricsFields(decoded)), nil
+}
+
+// LostTraces marshals and unmarshals traces and returns the fields lost on
+// the way, as path: want value, got value.
+func LostTraces(m marshaler.Traces, u marshaler.TracesUnmarshaler, td ptrace.Traces) ([]string, error) {
+	b, err := m.Marshal(td)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal traces: %w", err)
+	}
+	decoded, err := u.Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal traces: %w", err)
+	}
+	return lost(tracesFields(td), tracesFields(decoded)), nil
+}
+
+// checkEncoding checks that the encoding and the content type of a marshaler
+// are well formed, stable, and consistent with its content encoding and its
+// unmarshaler.
+func checkEncoding(t *testing.T, m any, encoding, contentType string, u interface{ Encoding() string }) {
+	t.Helper()
+	if encoding == "" || strings.ContainsAny(encoding, " \t\n") {
+		t.Errorf("Expected an encoding without spaces, got %q", encoding)
+	}
+	if again := m.(interface{ Encoding() string }).Encoding(); again != encoding {
+		t.Errorf("Expected a stable encoding, got %q then %q", encoding, again)
+	}
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		t.Errorf("Expected a valid content type, got %q: %v", contentType, err)
+	}
+	if ce := marshaler.ContentEncodingOf(m); ce != "" && !strings.HasSuffix(encoding, "+"+ce) {
+		t.Errorf("Expected encoding %q to end with its content encoding %q", encoding, ce)
+	}
+	if u != nil && u.Encoding() != encoding {
+		t.Errorf("Expected unmarshaler encoding %q, got %q", encoding, u.Encoding())
+	}
+}
+
+func checkDeterministic(t *testing.T, first, second []byte) {
+	t.Helper()
+	if !bytes.Equal(first, second) {
+		t.Errorf("Expected Marshal() to be deterministic, got:\n%q\nthen:\n%q", first, second)
+	}
+}
+
+func checkStreamed(t *testing.T, marshaled, streamed []byte) {
+	t.Helper()
+	if !bytes.Equal(marshaled, streamed) {
+		t.Errorf("Expected MarshalTo() to match Marshal(), got:\n%q\nwant:\n%q", streamed, marshaled)
+	}
+}
+
+func checkRoundTrip(t *testing.T, marshaled, remarshaled []byte) {
+	t.Helper()
+	if !bytes.Equal(marshaled, remarshaled) {
+		t.Errorf("Expected the unmarshaled batch to marshal identically, got:\n%q\nwant:\n%q", remarshaled, marshaled)
+	}
+}
+
+func logLost(t *testing.T, lost []string) {
+	t.Helper()
+	for _, field := range lost {
+		t.Logf("lost %s", field)
+	}
+}
@@ -0,0 +1,44 @@
+package marshalertest_test
+
+import (
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler/marshalertest"
+)
+
+// TestBaseMarshalers runs every base marshaler, with its unmarshaler when the
+// encoding has one, e.g. otlp_parquet and otlp_arrow_ipc are only marshaled.
+func TestBaseMarshalers(t *testing.T) {
+	marshalers := marshaler.BaseMarshalers()
+	unmarshalers := marshaler.BaseUnmarshalers(marshaler.CSVConfig{})
+	for encoding, m := range marshalers.Logs {
+		t.Run("logs/"+encoding, func(t *testing.T) {
+			marshalertest.TestLogs(t, m, unmarshalers.Logs[encoding])
+		})
+	}
+	for encoding, m := range marshalers.Metrics {
+		t.Run("metrics/"+encoding, func(t *testing.T) {
+			marshalertest.TestMetrics(t, m, unmarshalers.Metrics[encoding])
+		})
+	}
+	for encoding, m := range marshalers.Traces {
+		t.Run("traces/"+encoding, func(t *testing.T) {
+			marshalertest.TestTraces(t, m, unmarshalers.Traces[encoding])
+		})
+	}
+}
+
+func TestLostLogs(t *testing.T) {
+	m := marshaler.NewOtlpCsvLogs()
+	u := marshaler.NewOtlpCsvLogsUnmarshaler()
+	for _, c := range marshalertest.LogsCorpus() {
+		lost, err := marshalertest.LostLogs(m, u, c.Logs)
+		if err != nil {
+			t.Fatalf("LostLogs(%s) failed: %v", c.Name, err)
+		}
+		if c.Name == "unicode" && len(lost) > 0 {
+			t.Errorf("Expected unicode logs to round-trip, lost %v", lost)
+		}
+	}
+}
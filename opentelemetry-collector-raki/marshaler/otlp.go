@@ -1,6 +1,9 @@
 package marshaler
 
 import (
+	"io"
+	"strings"
+
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -8,10 +11,74 @@ import (
 
 const (
 	// OTLP content types and encodings
-	encodingCsv    = "otlp_csv"
-	contentTypeCsv = "application/csv"
+	encodingCsv            = "otlp_csv"
+	encodingParquet        = "otlp_parquet"
+	encodingArrowIPC       = "otlp_arrow_ipc"
+	encodingPrometheusText = "prometheus_text"
+	encodingProtobuf       = "otlp_proto"
+	encodingJSON           = "otlp_json"
+	contentTypeProtobuf    = "application/x-protobuf"
+	contentTypeJSON        = "application/json"
+	contentTypeCsv         = "application/csv"
+	contentTypeParquet     = "application/vnd.apache.parquet"
+	contentTypeArrowStream = "application/vnd.apache.arrow.stream"
+	contentTypeArrowFile   = "application/vnd.apache.arrow.file"
+
+	// Prometheus content types
+	contentTypePrometheusText = "text/plain; version=0.0.4; charset=utf-8"
+	contentTypeOpenMetrics    = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+	// Plain text log encodings
+	encodingLogfmt  = "logfmt"
+	encodingSyslog  = "syslog"
+	contentTypeText = "text/plain; charset=utf-8"
+
+	// Trace viewer encodings
+	encodingChromeTrace  = "chrome_trace"
+	encodingFoldedStacks = "folded_stacks"
 )
 
+// fileExtensions are the file name extensions of the encodings.
+var fileExtensions = map[string]string{
+	encodingCsv:            ".csv",
+	encodingParquet:        ".parquet",
+	encodingArrowIPC:       ".arrow",
+	encodingPrometheusText: ".txt",
+	encodingProtobuf:       ".pb",
+	encodingJSON:           ".json",
+	encodingLogfmt:         ".txt",
+	encodingSyslog:         ".txt",
+	encodingChromeTrace:    ".json",
+	encodingFoldedStacks:   ".txt",
+}
+
+// FileExtension returns the file name extension of a payload from the name
+// of its encoding, optionally followed by a compression, e.g. .csv.zst for
+// otlp_csv+zstd. Encodings without a known extension, such as registered
+// ones, are written as .bin.
+func FileExtension(encoding string) string {
+	encoding, compression, _ := strings.Cut(encoding, "+")
+	ext, ok := fileExtensions[encoding]
+	if !ok {
+		ext = ".bin"
+	}
+	return ext + CompressionExtension(compression)
+}
+
+// logsWriter, metricsWriter and tracesWriter are implemented by the internal
+// marshalers that can stream their output, see CSVMarshaler.WriteLogs.
+type logsWriter interface {
+	WriteLogs(w io.Writer, ld plog.Logs) error
+}
+
+type metricsWriter interface {
+	WriteMetrics(w io.Writer, md pmetric.Metrics) error
+}
+
+type tracesWriter interface {
+	WriteTraces(w io.Writer, td ptrace.Traces) error
+}
+
 // otlpLogs defines a struct for marshaling logs into bytes using
 // internal implementations of plog.Marshaler.
 type otlpLogs struct {
@@ -27,18 +94,113 @@ type otlpLogs struct {
 
 // NewOtlpCsvLogs creates a new otlpLogs that uses csv as the encoding.
 func NewOtlpCsvLogs() Logs {
+	return NewOtlpCsvLogsWithConfig(CSVConfig{})
+}
+
+// NewOtlpCsvLogsWithConfig creates a new otlpLogs that uses csv as the
+// encoding with the given CSV options.
+func NewOtlpCsvLogsWithConfig(config CSVConfig) Logs {
 	return &otlpLogs{
-		logsMarshaler: &CSVMarshaler{},
+		logsMarshaler: NewCSVMarshalerWithConfig(config),
 		encoding:      encodingCsv,
 		contentType:   contentTypeCsv,
 	}
 }
 
+// NewOtlpParquetLogs creates a new otlpLogs that uses parquet as the encoding.
+func NewOtlpParquetLogs() Logs {
+	return NewOtlpParquetLogsWithConfig(ParquetConfig{})
+}
+
+// NewOtlpParquetLogsWithConfig creates a new otlpLogs that uses parquet as
+// the encoding with the given Parquet options.
+func NewOtlpParquetLogsWithConfig(config ParquetConfig) Logs {
+	return &otlpLogs{
+		logsMarshaler: NewParquetMarshalerWithConfig(config),
+		encoding:      encodingParquet,
+		contentType:   contentTypeParquet,
+	}
+}
+
+// NewOtlpArrowIPCLogs creates a new otlpLogs that uses arrow ipc as the encoding.
+func NewOtlpArrowIPCLogs() Logs {
+	return NewOtlpArrowIPCLogsWithConfig(ArrowIPCConfig{})
+}
+
+// NewOtlpArrowIPCLogsWithConfig creates a new otlpLogs that uses arrow ipc as
+// the encoding with the given Arrow IPC options.
+func NewOtlpArrowIPCLogsWithConfig(config ArrowIPCConfig) Logs {
+	m := NewArrowIPCMarshalerWithConfig(config)
+	return &otlpLogs{
+		logsMarshaler: m,
+		encoding:      encodingArrowIPC,
+		contentType:   m.contentType(),
+	}
+}
+
+// NewOtlpProtobufLogs creates a new otlpLogs that uses OTLP protobuf as the
+// encoding, the payload of an OTLP/HTTP export request.
+func NewOtlpProtobufLogs() Logs {
+	return &otlpLogs{
+		logsMarshaler: &plog.ProtoMarshaler{},
+		encoding:      encodingProtobuf,
+		contentType:   contentTypeProtobuf,
+	}
+}
+
+// NewOtlpJSONLogs creates a new otlpLogs that uses OTLP JSON as the encoding.
+func NewOtlpJSONLogs() Logs {
+	return &otlpLogs{
+		logsMarshaler: &plog.JSONMarshaler{},
+		encoding:      encodingJSON,
+		contentType:   contentTypeJSON,
+	}
+}
+
+// NewLogfmtLogs creates a new otlpLogs that uses logfmt as the encoding.
+func NewLogfmtLogs() Logs {
+	return &otlpLogs{
+		logsMarshaler: NewLogfmtMarshaler(),
+		encoding:      encodingLogfmt,
+		contentType:   contentTypeText,
+	}
+}
+
+// NewSyslogLogs creates a new otlpLogs that uses RFC 5424 syslog as the
+// encoding.
+func NewSyslogLogs() Logs {
+	return NewSyslogLogsWithConfig(SyslogConfig{})
+}
+
+// NewSyslogLogsWithConfig creates a new otlpLogs that uses RFC 5424 syslog as
+// the encoding with the given syslog options.
+func NewSyslogLogsWithConfig(config SyslogConfig) Logs {
+	return &otlpLogs{
+		logsMarshaler: NewSyslogMarshalerWithConfig(config),
+		encoding:      encodingSyslog,
+		contentType:   contentTypeText,
+	}
+}
+
 // Marshal serializes logs into bytes.
 func (o *otlpLogs) Marshal(logs plog.Logs) ([]byte, error) {
 	return o.logsMarshaler.MarshalLogs(logs)
 }
 
+// MarshalTo serializes logs into w, streaming them when the internal
+// marshaler supports it.
+func (o *otlpLogs) MarshalTo(w io.Writer, logs plog.Logs) error {
+	if sw, ok := o.logsMarshaler.(logsWriter); ok {
+		return sw.WriteLogs(w, logs)
+	}
+	b, err := o.Marshal(logs)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
 // Encoding is the name of the encoding that this marshaler supports.
 func (o *otlpLogs) Encoding() string {
 	return o.encoding
@@ -59,18 +221,105 @@ type otlpMetrics struct {
 
 // NewOtlpCsvMetrics creates a new otlpMetrics that uses csv as the encoding.
 func NewOtlpCsvMetrics() Metrics {
+	return NewOtlpCsvMetricsWithConfig(CSVConfig{})
+}
+
+// NewOtlpCsvMetricsWithConfig creates a new otlpMetrics that uses csv as the
+// encoding with the given CSV options.
+func NewOtlpCsvMetricsWithConfig(config CSVConfig) Metrics {
 	return &otlpMetrics{
-		metricsMarshaler: &CSVMarshaler{},
+		metricsMarshaler: NewCSVMarshalerWithConfig(config),
 		encoding:         encodingCsv,
 		contentType:      contentTypeCsv,
 	}
 }
 
+// NewOtlpParquetMetrics creates a new otlpMetrics that uses parquet as the encoding.
+func NewOtlpParquetMetrics() Metrics {
+	return NewOtlpParquetMetricsWithConfig(ParquetConfig{})
+}
+
+// NewOtlpParquetMetricsWithConfig creates a new otlpMetrics that uses parquet as
+// the encoding with the given Parquet options.
+func NewOtlpParquetMetricsWithConfig(config ParquetConfig) Metrics {
+	return &otlpMetrics{
+		metricsMarshaler: NewParquetMarshalerWithConfig(config),
+		encoding:         encodingParquet,
+		contentType:      contentTypeParquet,
+	}
+}
+
+// NewOtlpArrowIPCMetrics creates a new otlpMetrics that uses arrow ipc as the encoding.
+func NewOtlpArrowIPCMetrics() Metrics {
+	return NewOtlpArrowIPCMetricsWithConfig(ArrowIPCConfig{})
+}
+
+// NewOtlpArrowIPCMetricsWithConfig creates a new otlpMetrics that uses arrow ipc as
+// the encoding with the given Arrow IPC options.
+func NewOtlpArrowIPCMetricsWithConfig(config ArrowIPCConfig) Metrics {
+	m := NewArrowIPCMarshalerWithConfig(config)
+	return &otlpMetrics{
+		metricsMarshaler: m,
+		encoding:         encodingArrowIPC,
+		contentType:      m.contentType(),
+	}
+}
+
+// NewPrometheusTextMetrics creates a new otlpMetrics that uses the Prometheus
+// text exposition format as the encoding.
+func NewPrometheusTextMetrics() Metrics {
+	return NewPrometheusTextMetricsWithConfig(PrometheusConfig{})
+}
+
+// NewPrometheusTextMetricsWithConfig creates a new otlpMetrics that uses the
+// Prometheus text exposition format as the encoding with the given options.
+func NewPrometheusTextMetricsWithConfig(config PrometheusConfig) Metrics {
+	m := NewPrometheusMarshalerWithConfig(config)
+	return &otlpMetrics{
+		metricsMarshaler: m,
+		encoding:         encodingPrometheusText,
+		contentType:      m.contentType(),
+	}
+}
+
+// NewOtlpProtobufMetrics creates a new otlpMetrics that uses OTLP protobuf as the
+// encoding, the payload of an OTLP/HTTP export request.
+func NewOtlpProtobufMetrics() Metrics {
+	return &otlpMetrics{
+		metricsMarshaler: &pmetric.ProtoMarshaler{},
+		encoding:         encodingProtobuf,
+		contentType:      contentTypeProtobuf,
+	}
+}
+
+// NewOtlpJSONMetrics creates a new otlpMetrics that uses OTLP JSON as the encoding.
+func NewOtlpJSONMetrics() Metrics {
+	return &otlpMetrics{
+		metricsMarshaler: &pmetric.JSONMarshaler{},
+		encoding:         encodingJSON,
+		contentType:      contentTypeJSON,
+	}
+}
+
 // Marshal serializes metrics into bytes.
 func (o *otlpMetrics) Marshal(metrics pmetric.Metrics) ([]byte, error) {
 	return o.metricsMarshaler.MarshalMetrics(metrics)
 }
 
+// MarshalTo serializes metrics into w, streaming them when the internal
+// marshaler supports it.
+func (o *otlpMetrics) MarshalTo(w io.Writer, metrics pmetric.Metrics) error {
+	if sw, ok := o.metricsMarshaler.(metricsWriter); ok {
+		return sw.WriteMetrics(w, metrics)
+	}
+	b, err := o.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
 // Encoding is the name of the encoding that this marshaler supports.
 func (o *otlpMetrics) Encoding() string {
 	return o.encoding
@@ -91,18 +340,108 @@ type otlpTraces struct {
 
 // NewOtlpCsvTraces creates a new otlpTraces that uses csv as the encoding.
 func NewOtlpCsvTraces() Traces {
+	return NewOtlpCsvTracesWithConfig(CSVConfig{})
+}
+
+// NewOtlpCsvTracesWithConfig creates a new otlpTraces that uses csv as the
+// encoding with the given CSV options.
+func NewOtlpCsvTracesWithConfig(config CSVConfig) Traces {
 	return &otlpTraces{
-		tracesMarshaler: &CSVMarshaler{},
+		tracesMarshaler: NewCSVMarshalerWithConfig(config),
 		encoding:        encodingCsv,
 		contentType:     contentTypeCsv,
 	}
 }
 
+// NewOtlpParquetTraces creates a new otlpTraces that uses parquet as the encoding.
+func NewOtlpParquetTraces() Traces {
+	return NewOtlpParquetTracesWithConfig(ParquetConfig{})
+}
+
+// NewOtlpParquetTracesWithConfig creates a new otlpTraces that uses parquet as
+// the encoding with the given Parquet options.
+func NewOtlpParquetTracesWithConfig(config ParquetConfig) Traces {
+	return &otlpTraces{
+		tracesMarshaler: NewParquetMarshalerWithConfig(config),
+		encoding:        encodingParquet,
+		contentType:     contentTypeParquet,
+	}
+}
+
+// NewOtlpArrowIPCTraces creates a new otlpTraces that uses arrow ipc as the encoding.
+func NewOtlpArrowIPCTraces() Traces {
+	return NewOtlpArrowIPCTracesWithConfig(ArrowIPCConfig{})
+}
+
+// NewOtlpArrowIPCTracesWithConfig creates a new otlpTraces that uses arrow ipc as
+// the encoding with the given Arrow IPC options.
+func NewOtlpArrowIPCTracesWithConfig(config ArrowIPCConfig) Traces {
+	m := NewArrowIPCMarshalerWithConfig(config)
+	return &otlpTraces{
+		tracesMarshaler: m,
+		encoding:        encodingArrowIPC,
+		contentType:     m.contentType(),
+	}
+}
+
+// NewOtlpProtobufTraces creates a new otlpTraces that uses OTLP protobuf as the
+// encoding, the payload of an OTLP/HTTP export request.
+func NewOtlpProtobufTraces() Traces {
+	return &otlpTraces{
+		tracesMarshaler: &ptrace.ProtoMarshaler{},
+		encoding:        encodingProtobuf,
+		contentType:     contentTypeProtobuf,
+	}
+}
+
+// NewOtlpJSONTraces creates a new otlpTraces that uses OTLP JSON as the encoding.
+func NewOtlpJSONTraces() Traces {
+	return &otlpTraces{
+		tracesMarshaler: &ptrace.JSONMarshaler{},
+		encoding:        encodingJSON,
+		contentType:     contentTypeJSON,
+	}
+}
+
+// NewChromeTraceTraces creates a new otlpTraces that uses the Chrome Trace
+// Event JSON format as the encoding, which opens in Perfetto.
+func NewChromeTraceTraces() Traces {
+	return &otlpTraces{
+		tracesMarshaler: NewChromeTraceMarshaler(),
+		encoding:        encodingChromeTrace,
+		contentType:     contentTypeJSON,
+	}
+}
+
+// NewFoldedStacksTraces creates a new otlpTraces that uses folded stacks as
+// the encoding, the input of flamegraph tools.
+func NewFoldedStacksTraces() Traces {
+	return &otlpTraces{
+		tracesMarshaler: NewFoldedStacksMarshaler(),
+		encoding:        encodingFoldedStacks,
+		contentType:     contentTypeText,
+	}
+}
+
 // Marshal serializes traces into bytes.
 func (o *otlpTraces) Marshal(traces ptrace.Traces) ([]byte, error) {
 	return o.tracesMarshaler.MarshalTraces(traces)
 }
 
+// MarshalTo serializes traces into w, streaming them when the internal
+// marshaler supports it.
+func (o *otlpTraces) MarshalTo(w io.Writer, traces ptrace.Traces) error {
+	if sw, ok := o.tracesMarshaler.(tracesWriter); ok {
+		return sw.WriteTraces(w, traces)
+	}
+	b, err := o.Marshal(traces)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
 // Encoding is the name of the encoding that this marshaler supports.
 func (o *otlpTraces) Encoding() string {
 	return o.encoding
@@ -112,3 +451,210 @@ func (o *otlpTraces) Encoding() string {
 func (o *otlpTraces) ContentType() string {
 	return o.contentType
 }
+
+// otlpLogsUnmarshaler defines a struct for unmarshaling bytes into logs using
+// internal implementations of plog.Unmarshaler.
+type otlpLogsUnmarshaler struct {
+	logsUnmarshaler plog.Unmarshaler
+	encoding        string
+}
+
+// NewOtlpCsvLogsUnmarshaler creates a new otlpLogsUnmarshaler that uses csv
+// as the encoding.
+func NewOtlpCsvLogsUnmarshaler() LogsUnmarshaler {
+	return NewOtlpCsvLogsUnmarshalerWithConfig(CSVConfig{})
+}
+
+// NewOtlpCsvLogsUnmarshalerWithConfig creates a new otlpLogsUnmarshaler that
+// uses csv as the encoding with the given CSV options.
+func NewOtlpCsvLogsUnmarshalerWithConfig(config CSVConfig) LogsUnmarshaler {
+	return &otlpLogsUnmarshaler{
+		logsUnmarshaler: NewCSVMarshalerWithConfig(config),
+		encoding:        encodingCsv,
+	}
+}
+
+// NewOtlpParquetLogsUnmarshaler creates a new otlpLogsUnmarshaler that
+// reads the Parquet files written by the parquet encoding.
+func NewOtlpParquetLogsUnmarshaler() LogsUnmarshaler {
+	return &otlpLogsUnmarshaler{
+		logsUnmarshaler: NewParquetMarshaler(),
+		encoding:        encodingParquet,
+	}
+}
+
+// NewOtlpArrowIPCLogsUnmarshaler creates a new otlpLogsUnmarshaler that
+// reads the Arrow IPC stream and file formats written by the arrow ipc
+// encoding.
+func NewOtlpArrowIPCLogsUnmarshaler() LogsUnmarshaler {
+	return &otlpLogsUnmarshaler{
+		logsUnmarshaler: NewArrowIPCMarshaler(),
+		encoding:        encodingArrowIPC,
+	}
+}
+
+// NewOtlpProtobufLogsUnmarshaler creates a new otlpLogsUnmarshaler that
+// uses OTLP protobuf as the encoding.
+func NewOtlpProtobufLogsUnmarshaler() LogsUnmarshaler {
+	return &otlpLogsUnmarshaler{
+		logsUnmarshaler: &plog.ProtoUnmarshaler{},
+		encoding:        encodingProtobuf,
+	}
+}
+
+// NewOtlpJSONLogsUnmarshaler creates a new otlpLogsUnmarshaler that uses
+// OTLP JSON as the encoding.
+func NewOtlpJSONLogsUnmarshaler() LogsUnmarshaler {
+	return &otlpLogsUnmarshaler{
+		logsUnmarshaler: &plog.JSONUnmarshaler{},
+		encoding:        encodingJSON,
+	}
+}
+
+// Unmarshal deserializes bytes into logs.
+func (o *otlpLogsUnmarshaler) Unmarshal(buf []byte) (plog.Logs, error) {
+	return o.logsUnmarshaler.UnmarshalLogs(buf)
+}
+
+// Encoding is the name of the encoding that this unmarshaler supports.
+func (o *otlpLogsUnmarshaler) Encoding() string {
+	return o.encoding
+}
+
+// otlpMetricsUnmarshaler defines a struct for unmarshaling bytes into metrics
+// using internal implementations of pmetric.Unmarshaler.
+type otlpMetricsUnmarshaler struct {
+	metricsUnmarshaler pmetric.Unmarshaler
+	encoding           string
+}
+
+// NewOtlpCsvMetricsUnmarshaler creates a new otlpMetricsUnmarshaler that uses
+// csv as the encoding.
+func NewOtlpCsvMetricsUnmarshaler() MetricsUnmarshaler {
+	return NewOtlpCsvMetricsUnmarshalerWithConfig(CSVConfig{})
+}
+
+// NewOtlpCsvMetricsUnmarshalerWithConfig creates a new otlpMetricsUnmarshaler
+// that uses csv as the encoding with the given CSV options.
+func NewOtlpCsvMetricsUnmarshalerWithConfig(config CSVConfig) MetricsUnmarshaler {
+	return &otlpMetricsUnmarshaler{
+		metricsUnmarshaler: NewCSVMarshalerWithConfig(config),
+		encoding:           encodingCsv,
+	}
+}
+
+// NewOtlpParquetMetricsUnmarshaler creates a new otlpMetricsUnmarshaler that
+// reads the Parquet files written by the parquet encoding.
+func NewOtlpParquetMetricsUnmarshaler() MetricsUnmarshaler {
+	return &otlpMetricsUnmarshaler{
+		metricsUnmarshaler: NewParquetMarshaler(),
+		encoding:           encodingParquet,
+	}
+}
+
+// NewOtlpArrowIPCMetricsUnmarshaler creates a new otlpMetricsUnmarshaler that
+// reads the Arrow IPC stream and file formats written by the arrow ipc
+// encoding.
+func NewOtlpArrowIPCMetricsUnmarshaler() MetricsUnmarshaler {
+	return &otlpMetricsUnmarshaler{
+		metricsUnmarshaler: NewArrowIPCMarshaler(),
+		encoding:           encodingArrowIPC,
+	}
+}
+
+// NewOtlpProtobufMetricsUnmarshaler creates a new otlpMetricsUnmarshaler that
+// uses OTLP protobuf as the encoding.
+func NewOtlpProtobufMetricsUnmarshaler() MetricsUnmarshaler {
+	return &otlpMetricsUnmarshaler{
+		metricsUnmarshaler: &pmetric.ProtoUnmarshaler{},
+		encoding:           encodingProtobuf,
+	}
+}
+
+// NewOtlpJSONMetricsUnmarshaler creates a new otlpMetricsUnmarshaler that uses
+// OTLP JSON as the encoding.
+func NewOtlpJSONMetricsUnmarshaler() MetricsUnmarshaler {
+	return &otlpMetricsUnmarshaler{
+		metricsUnmarshaler: &pmetric.JSONUnmarshaler{},
+		encoding:           encodingJSON,
+	}
+}
+
+// Unmarshal deserializes bytes into metrics.
+func (o *otlpMetricsUnmarshaler) Unmarshal(buf []byte) (pmetric.Metrics, error) {
+	return o.metricsUnmarshaler.UnmarshalMetrics(buf)
+}
+
+// Encoding is the name of the encoding that this unmarshaler supports.
+func (o *otlpMetricsUnmarshaler) Encoding() string {
+	return o.encoding
+}
+
+// otlpTracesUnmarshaler defines a struct for unmarshaling bytes into traces
+// using internal implementations of ptrace.Unmarshaler.
+type otlpTracesUnmarshaler struct {
+	tracesUnmarshaler ptrace.Unmarshaler
+	encoding          string
+}
+
+// NewOtlpCsvTracesUnmarshaler creates a new otlpTracesUnmarshaler that uses
+// csv as the encoding.
+func NewOtlpCsvTracesUnmarshaler() TracesUnmarshaler {
+	return NewOtlpCsvTracesUnmarshalerWithConfig(CSVConfig{})
+}
+
+// NewOtlpCsvTracesUnmarshalerWithConfig creates a new otlpTracesUnmarshaler
+// that uses csv as the encoding with the given CSV options.
+func NewOtlpCsvTracesUnmarshalerWithConfig(config CSVConfig) TracesUnmarshaler {
+	return &otlpTracesUnmarshaler{
+		tracesUnmarshaler: NewCSVMarshalerWithConfig(config),
+		encoding:          encodingCsv,
+	}
+}
+
+// NewOtlpParquetTracesUnmarshaler creates a new otlpTracesUnmarshaler that
+// reads the Parquet files written by the parquet encoding.
+func NewOtlpParquetTracesUnmarshaler() TracesUnmarshaler {
+	return &otlpTracesUnmarshaler{
+		tracesUnmarshaler: NewParquetMarshaler(),
+		encoding:          encodingParquet,
+	}
+}
+
+// NewOtlpArrowIPCTracesUnmarshaler creates a new otlpTracesUnmarshaler that
+// reads the Arrow IPC stream and file formats written by the arrow ipc
+// encoding.
+func NewOtlpArrowIPCTracesUnmarshaler() TracesUnmarshaler {
+	return &otlpTracesUnmarshaler{
+		tracesUnmarshaler: NewArrowIPCMarshaler(),
+		encoding:          encodingArrowIPC,
+	}
+}
+
+// NewOtlpProtobufTracesUnmarshaler creates a new otlpTracesUnmarshaler that
+// uses OTLP protobuf as the encoding.
+func NewOtlpProtobufTracesUnmarshaler() TracesUnmarshaler {
+	return &otlpTracesUnmarshaler{
+		tracesUnmarshaler: &ptrace.ProtoUnmarshaler{},
+		encoding:          encodingProtobuf,
+	}
+}
+
+// NewOtlpJSONTracesUnmarshaler creates a new otlpTracesUnmarshaler that uses
+// OTLP JSON as the encoding.
+func NewOtlpJSONTracesUnmarshaler() TracesUnmarshaler {
+	return &otlpTracesUnmarshaler{
+		tracesUnmarshaler: &ptrace.JSONUnmarshaler{},
+		encoding:          encodingJSON,
+	}
+}
+
+// Unmarshal deserializes bytes into traces.
+func (o *otlpTracesUnmarshaler) Unmarshal(buf []byte) (ptrace.Traces, error) {
+	return o.tracesUnmarshaler.UnmarshalTraces(buf)
+}
+
+// Encoding is the name of the encoding that this unmarshaler supports.
+func (o *otlpTracesUnmarshaler) Encoding() string {
+	return o.encoding
+}
@@ -0,0 +1,84 @@
+package marshaler_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestOtlpPayloads(t *testing.T) {
+	tests := []struct {
+		m           marshaler.Logs
+		encoding    string
+		contentType string
+		want        plog.Marshaler
+	}{
+		{marshaler.NewOtlpProtobufLogs(), "otlp_proto", "application/x-protobuf", &plog.ProtoMarshaler{}},
+		{marshaler.NewOtlpJSONLogs(), "otlp_json", "application/json", &plog.JSONMarshaler{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			if got := tt.m.Encoding(); got != tt.encoding {
+				t.Errorf("Expected encoding %s, got %s", tt.encoding, got)
+			}
+			if got := tt.m.ContentType(); got != tt.contentType {
+				t.Errorf("Expected content type %s, got %s", tt.contentType, got)
+			}
+			got, err := tt.m.Marshal(testLogs())
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			want, err := tt.want.MarshalLogs(testLogs())
+			if err != nil {
+				t.Fatalf("MarshalLogs() failed: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatal("Expected the exact OTLP payload")
+			}
+		})
+	}
+
+	for _, encoding := range []string{"otlp_proto", "otlp_json", "otlp_proto+zstd"} {
+		if _, ok := marshaler.BaseTracesMarshalers()[encoding]; !ok {
+			t.Errorf("Expected traces marshaler %s to be registered", encoding)
+		}
+		if _, ok := marshaler.BaseUnmarshalers(marshaler.CSVConfig{}).Metrics[encoding]; !ok {
+			t.Errorf("Expected metrics unmarshaler %s to be registered", encoding)
+		}
+	}
+
+	u := marshaler.NewOtlpProtobufTracesUnmarshaler()
+	b, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(testTraces())
+	if err != nil {
+		t.Fatalf("MarshalTraces() failed: %v", err)
+	}
+	td, err := u.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	if td.SpanCount() != testTraces().SpanCount() {
+		t.Fatalf("Expected %d spans, got %d", testTraces().SpanCount(), td.SpanCount())
+	}
+}
+
+func TestFileExtension(t *testing.T) {
+	tests := []struct {
+		encoding string
+		want     string
+	}{
+		{"otlp_csv", ".csv"},
+		{"otlp_csv+zstd", ".csv.zst"},
+		{"otlp_proto+gzip", ".pb.gz"},
+		{"otlp_arrow_ipc", ".arrow"},
+		{"prometheus_text", ".txt"},
+		{"custom", ".bin"},
+	}
+	for _, tt := range tests {
+		if got := marshaler.FileExtension(tt.encoding); got != tt.want {
+			t.Errorf("Expected extension %s for %s, got %s", tt.want, tt.encoding, got)
+		}
+	}
+}
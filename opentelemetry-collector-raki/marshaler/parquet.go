@@ -0,0 +1,193 @@
+package marshaler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/apache/arrow/go/v13/parquet"
+	"github.com/apache/arrow/go/v13/parquet/compress"
+	"github.com/apache/arrow/go/v13/parquet/pqarrow"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// parquetCodecs maps the codec names accepted in ParquetConfig to a compression.
+var parquetCodecs = map[string]compress.Compression{
+	"uncompressed": compress.Codecs.Uncompressed,
+	"snappy":       compress.Codecs.Snappy,
+	"gzip":         compress.Codecs.Gzip,
+	"brotli":       compress.Codecs.Brotli,
+	"zstd":         compress.Codecs.Zstd,
+}
+
+// ParquetConfig defines the options of the Parquet marshaler.
+type ParquetConfig struct {
+	// Compression is the codec of every column: uncompressed, snappy, gzip,
+	// brotli or zstd. Defaults to snappy.
+	Compression string `mapstructure:"compression"`
+
+	// ColumnCompression overrides the codec of individual columns, keyed by
+	// column name. Columns nested in lists are addressed by their leaf path,
+	// e.g. bucket_counts.list.element. Every key must be a column of the
+	// logs, metrics or traces schema.
+	ColumnCompression map[string]string `mapstructure:"column_compression"`
+}
+
+// Validate checks that the Parquet options are supported.
+func (c ParquetConfig) Validate() error {
+	if _, err := parseParquetCodec(c.Compression); err != nil {
+		return err
+	}
+	if len(c.ColumnCompression) == 0 {
+		return nil
+	}
+	columns, err := parquetColumnPaths()
+	if err != nil {
+		return err
+	}
+	for column, codec := range c.ColumnCompression {
+		if !columns[column] {
+			return fmt.Errorf("unknown column: %s", column)
+		}
+		if _, err := parseParquetCodec(codec); err != nil {
+			return fmt.Errorf("column %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// parquetColumnPaths returns the leaf column paths of the Parquet schemas of
+// logs, metrics and traces.
+func parquetColumnPaths() (map[string]bool, error) {
+	paths := map[string]bool{}
+	for _, schema := range []*arrow.Schema{logsArrowSchema, metricsArrowSchema, tracesArrowSchema} {
+		ps, err := pqarrow.ToParquet(schema, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert schema: %w", err)
+		}
+		for i := 0; i < ps.NumColumns(); i++ {
+			paths[ps.Column(i).Path()] = true
+		}
+	}
+	return paths, nil
+}
+
+// parseParquetCodec parses a codec name, an empty name being snappy.
+func parseParquetCodec(name string) (compress.Compression, error) {
+	if name == "" {
+		return compress.Codecs.Snappy, nil
+	}
+	codec, ok := parquetCodecs[strings.ToLower(name)]
+	if !ok {
+		return compress.Codecs.Uncompressed, fmt.Errorf("unsupported compression codec: %s", name)
+	}
+	return codec, nil
+}
+
+// ParquetMarshaler writes logs, metrics and traces as Apache Parquet files
+// following the columnar schemas of logsArrowSchema, metricsArrowSchema and
+// tracesArrowSchema.
+type ParquetMarshaler struct {
+	config ParquetConfig
+}
+
+func NewParquetMarshaler() ParquetMarshaler {
+	return ParquetMarshaler{}
+}
+
+// NewParquetMarshalerWithConfig creates a Parquet marshaler with the given options.
+func NewParquetMarshalerWithConfig(config ParquetConfig) ParquetMarshaler {
+	return ParquetMarshaler{config: config}
+}
+
+// MarshalLogs converts OpenTelemetry logs into a Parquet file.
+func (m ParquetMarshaler) MarshalLogs(ld plog.Logs) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteLogs(&buf, ld); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteLogs writes OpenTelemetry logs into w as a Parquet file, one row
+// group of at most arrowBatchRows rows at a time.
+func (m ParquetMarshaler) WriteLogs(w io.Writer, ld plog.Logs) error {
+	return m.write(w, logsArrowSchema, func(write func(rec arrow.Record) error) error {
+		return logsToArrowRecords(memory.DefaultAllocator, logsArrowSchema, ld, arrowBatchRows, write)
+	})
+}
+
+// MarshalMetrics converts OpenTelemetry metrics into a Parquet file.
+func (m ParquetMarshaler) MarshalMetrics(md pmetric.Metrics) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteMetrics(&buf, md); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteMetrics writes OpenTelemetry metrics into w as a Parquet file, one row
+// group of at most arrowBatchRows rows at a time.
+func (m ParquetMarshaler) WriteMetrics(w io.Writer, md pmetric.Metrics) error {
+	return m.write(w, metricsArrowSchema, func(write func(rec arrow.Record) error) error {
+		return metricsToArrowRecords(memory.DefaultAllocator, metricsArrowSchema, md, arrowBatchRows, write)
+	})
+}
+
+// MarshalTraces converts OpenTelemetry traces into a Parquet file.
+func (m ParquetMarshaler) MarshalTraces(td ptrace.Traces) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteTraces(&buf, td); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTraces writes OpenTelemetry traces into w as a Parquet file, one row
+// group of at most arrowBatchRows rows at a time.
+func (m ParquetMarshaler) WriteTraces(w io.Writer, td ptrace.Traces) error {
+	return m.write(w, tracesArrowSchema, func(write func(rec arrow.Record) error) error {
+		return tracesToArrowRecords(memory.DefaultAllocator, tracesArrowSchema, td, arrowBatchRows, write)
+	})
+}
+
+// writerProperties builds the Parquet writer properties from the config.
+// Invalid codecs fall back to the defaults, use ParquetConfig.Validate to
+// report them.
+func (m ParquetMarshaler) writerProperties() *parquet.WriterProperties {
+	codec, _ := parseParquetCodec(m.config.Compression)
+	opts := []parquet.WriterProperty{parquet.WithCompression(codec)}
+	for column, name := range m.config.ColumnCompression {
+		if codec, err := parseParquetCodec(name); err == nil {
+			opts = append(opts, parquet.WithCompressionFor(column, codec))
+		}
+	}
+	return parquet.NewWriterProperties(opts...)
+}
+
+// write writes the records produced by records as a single Parquet file.
+func (m ParquetMarshaler) write(w io.Writer, schema *arrow.Schema, records func(write func(rec arrow.Record) error) error) error {
+	writer, err := pqarrow.NewFileWriter(schema, noCloseWriter{w}, m.writerProperties(), pqarrow.DefaultWriterProps())
+	if err != nil {
+		return fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	err = records(func(rec arrow.Record) error {
+		if err := writer.Write(rec); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+	return nil
+}
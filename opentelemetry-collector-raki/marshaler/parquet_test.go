@@ -0,0 +1,288 @@
+package marshaler_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/apache/arrow/go/v13/parquet"
+	"github.com/apache/arrow/go/v13/parquet/pqarrow"
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+var (
+	logsColumns = []string{
+		"timestamp", "severity", "body", "attributes", "severity_number", "trace_id", "span_id", "flags",
+		"resource_attributes", "scope_name", "scope_version",
+	}
+	metricsColumns = []string{
+		"timestamp", "metric_name", "metric_type", "value_type", "value", "count", "sum", "min", "max",
+		"bucket_counts", "explicit_bounds", "scale", "zero_count", "positive_offset", "positive_bucket_counts",
+		"negative_offset", "negative_bucket_counts", "quantiles", "aggregation_temporality", "is_monotonic",
+		"start_timestamp", "unit", "description", "attributes", "resource_attributes", "scope_name", "scope_version",
+		"flags", "exemplars",
+	}
+	tracesColumns = []string{
+		"trace_id", "span_id", "parent_span_id", "name", "kind", "start_timestamp", "end_timestamp",
+		"status_code", "status_message", "duration", "trace_state", "attributes", "service_name",
+		"resource_attributes", "scope_name", "scope_version", "events", "links",
+	}
+)
+
+// nestedAttributesLogs returns the test logs where the first record also has
+// a map attribute holding a list.
+func nestedAttributesLogs() plog.Logs {
+	ld := testLogs()
+	card := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().PutEmptyMap("card")
+	card.PutEmptySlice("flags").AppendEmpty().SetStr("contactless")
+	return ld
+}
+
+// readParquet reads a Parquet file back into an Arrow table.
+func readParquet(t *testing.T, b []byte) arrow.Table {
+	t.Helper()
+	table, err := pqarrow.ReadTable(context.Background(), bytes.NewReader(b),
+		parquet.NewReaderProperties(memory.DefaultAllocator), pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatalf("ReadTable() failed: %v", err)
+	}
+	t.Cleanup(table.Release)
+	return table
+}
+
+// checkColumns checks the column names of a table and its number of rows.
+func checkColumns(t *testing.T, table arrow.Table, columns []string, rows int) {
+	t.Helper()
+	var got []string
+	for _, field := range table.Schema().Fields() {
+		got = append(got, field.Name)
+	}
+	if !reflect.DeepEqual(got, columns) {
+		t.Errorf("Expected columns %v, got %v", columns, got)
+	}
+	if table.NumRows() != int64(rows) {
+		t.Errorf("Expected %d rows, got %d", rows, table.NumRows())
+	}
+}
+
+// column returns the chunks of the named column of a table.
+func column(t *testing.T, table arrow.Table, name string) []arrow.Array {
+	t.Helper()
+	indices := table.Schema().FieldIndices(name)
+	if len(indices) != 1 {
+		t.Fatalf("Expected a single %s column, got %d", name, len(indices))
+	}
+	return table.Column(indices[0]).Data().Chunks()
+}
+
+// columnStrings returns the values of a string column, dictionary encoded or
+// not.
+func columnStrings(t *testing.T, table arrow.Table, name string) []string {
+	t.Helper()
+	var values []string
+	for _, chunk := range column(t, table, name) {
+		switch a := chunk.(type) {
+		case *array.String:
+			for i := 0; i < a.Len(); i++ {
+				values = append(values, a.Value(i))
+			}
+		case *array.Dictionary:
+			dictionary := a.Dictionary().(*array.String)
+			for i := 0; i < a.Len(); i++ {
+				values = append(values, dictionary.Value(a.GetValueIndex(i)))
+			}
+		default:
+			t.Fatalf("Expected %s to be a string column, got %s", name, chunk.DataType())
+		}
+	}
+	return values
+}
+
+// columnLists returns the values of a list column, nil for null lists.
+func columnLists[T any](t *testing.T, table arrow.Table, name string, value func(values arrow.Array, i int) T) [][]T {
+	t.Helper()
+	var lists [][]T
+	for _, chunk := range column(t, table, name) {
+		a, ok := chunk.(*array.List)
+		if !ok {
+			t.Fatalf("Expected %s to be a list column, got %s", name, chunk.DataType())
+		}
+		for i := 0; i < a.Len(); i++ {
+			if a.IsNull(i) {
+				lists = append(lists, nil)
+				continue
+			}
+			start, end := a.ValueOffsets(i)
+			list := []T{}
+			for j := start; j < end; j++ {
+				list = append(list, value(a.ListValues(), int(j)))
+			}
+			lists = append(lists, list)
+		}
+	}
+	return lists
+}
+
+func uint64Value(values arrow.Array, i int) uint64 {
+	return values.(*array.Uint64).Value(i)
+}
+
+// quantileValue returns a quantile and its value.
+func quantileValue(values arrow.Array, i int) [2]float64 {
+	s := values.(*array.Struct)
+	return [2]float64{s.Field(0).(*array.Float64).Value(i), s.Field(1).(*array.Float64).Value(i)}
+}
+
+// checkLogsTable checks the columns read back from the nested attributes logs.
+func checkLogsTable(t *testing.T, table arrow.Table) {
+	t.Helper()
+	checkColumns(t, table, logsColumns, 3)
+	if got, want := columnStrings(t, table, "attributes"), []string{
+		`[{"key":"service","value":{"stringValue":"atm"}},{"key":"attempt","value":{"intValue":"3"}},` +
+			`{"key":"card","value":{"kvlistValue":{"values":[{"key":"flags","value":{"arrayValue":{"values":[{"stringValue":"contactless"}]}}}]}}}]`,
+		"", "",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected attributes %q, got %q", want, got)
+	}
+	first := `[{"key":"service.name","value":{"stringValue":"default/atm-111/app/0"}}]`
+	second := `[{"key":"service.name","value":{"stringValue":"default/atm-222/app/0"}}]`
+	if got, want := columnStrings(t, table, "resource_attributes"), []string{first, first, second}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected resource attributes %q, got %q", want, got)
+	}
+	if got, want := columnStrings(t, table, "body"), []string{
+		`{"stringValue":"hello, \"world\""}`, `{"stringValue":"multi\nline"}`, `{"stringValue":"second resource"}`,
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected bodies %q, got %q", want, got)
+	}
+}
+
+// checkMetricsTable checks the columns read back from the test metrics, one
+// row per data point.
+func checkMetricsTable(t *testing.T, table arrow.Table) {
+	t.Helper()
+	checkColumns(t, table, metricsColumns, 7)
+	if got, want := columnStrings(t, table, "metric_name"), []string{
+		"int_gauge", "int_gauge", "double_sum", "histogram", "exponential_histogram", "summary", "int_gauge",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected metric names %q, got %q", want, got)
+	}
+	cassette := `[{"key":"cassette","value":{"stringValue":"a"}}]`
+	if got, want := columnStrings(t, table, "attributes"), []string{cassette, "", "", "", "", "", cassette}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected attributes %q, got %q", want, got)
+	}
+	if got, want := columnLists(t, table, "bucket_counts", uint64Value), [][]uint64{nil, nil, nil, {1, 2, 3}, nil, nil, nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected bucket counts %v, got %v", want, got)
+	}
+	if got, want := columnLists(t, table, "positive_bucket_counts", uint64Value), [][]uint64{nil, nil, nil, nil, {1, 1}, nil, nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected positive bucket counts %v, got %v", want, got)
+	}
+	if got, want := columnLists(t, table, "quantiles", quantileValue), [][][2]float64{nil, nil, nil, nil, nil, {{0.99, 9.5}}, nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected quantiles %v, got %v", want, got)
+	}
+}
+
+// checkTracesTable checks the columns read back from the test traces.
+func checkTracesTable(t *testing.T, table arrow.Table) {
+	t.Helper()
+	checkColumns(t, table, tracesColumns, 2)
+	if got, want := columnStrings(t, table, "attributes"), []string{`[{"key":"atm.id","value":{"intValue":"111"}}]`, ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected attributes %q, got %q", want, got)
+	}
+	if got, want := columnStrings(t, table, "events"), []string{
+		`[{"timeUnixNano":"1735787045500000000","name":"exception","attributes":[{"key":"exception.message","value":{"stringValue":"balance too low"}}]}]`,
+		"",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %q, got %q", want, got)
+	}
+}
+
+func TestParquetTypedBody(t *testing.T) {
+	ld := plog.NewLogs()
+	lrs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	lrs.AppendEmpty().Body().SetStr("42")
+	lrs.AppendEmpty().Body().SetInt(42)
+	lrs.AppendEmpty().Body().SetEmptyMap().PutBool("ok", true)
+	lrs.AppendEmpty()
+
+	b, err := marshaler.NewParquetMarshaler().MarshalLogs(ld)
+	if err != nil {
+		t.Fatalf("MarshalLogs() failed: %v", err)
+	}
+	want := []string{
+		`{"stringValue":"42"}`,
+		`{"intValue":"42"}`,
+		`{"kvlistValue":{"values":[{"key":"ok","value":{"boolValue":true}}]}}`,
+		"",
+	}
+	if got := columnStrings(t, readParquet(t, b), "body"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected bodies %q, got %q", want, got)
+	}
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	for name, config := range map[string]marshaler.ParquetConfig{
+		"default": {},
+		"zstd":    {Compression: "zstd", ColumnCompression: map[string]string{"bucket_counts.list.element": "uncompressed"}},
+	} {
+		m := marshaler.NewParquetMarshalerWithConfig(config)
+		t.Run(name+"/logs", func(t *testing.T) {
+			b, err := m.MarshalLogs(nestedAttributesLogs())
+			if err != nil {
+				t.Fatalf("MarshalLogs() failed: %v", err)
+			}
+			checkLogsTable(t, readParquet(t, b))
+		})
+		t.Run(name+"/metrics", func(t *testing.T) {
+			b, err := m.MarshalMetrics(testMetrics())
+			if err != nil {
+				t.Fatalf("MarshalMetrics() failed: %v", err)
+			}
+			checkMetricsTable(t, readParquet(t, b))
+		})
+		t.Run(name+"/traces", func(t *testing.T) {
+			b, err := m.MarshalTraces(testTraces())
+			if err != nil {
+				t.Fatalf("MarshalTraces() failed: %v", err)
+			}
+			checkTracesTable(t, readParquet(t, b))
+		})
+	}
+}
+
+func TestParquetConfigColumnCompression(t *testing.T) {
+	for _, column := range []string{"body", "bucket_counts.list.element", "quantiles.list.element.quantile", "events"} {
+		config := marshaler.ParquetConfig{ColumnCompression: map[string]string{column: "zstd"}}
+		if err := config.Validate(); err != nil {
+			t.Errorf("Validate() failed for %s: %v", column, err)
+		}
+	}
+	for _, column := range []string{"bodyy", "bucket_counts", ""} {
+		config := marshaler.ParquetConfig{ColumnCompression: map[string]string{column: "zstd"}}
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected column %q to be invalid", column)
+		}
+	}
+}
+
+func TestParquetUnmarshalMetrics(t *testing.T) {
+	m := marshaler.NewParquetMarshaler()
+	b, err := m.MarshalMetrics(testMetrics())
+	if err != nil {
+		t.Fatalf("MarshalMetrics() failed: %v", err)
+	}
+	md, err := m.UnmarshalMetrics(b)
+	if err != nil {
+		t.Fatalf("UnmarshalMetrics() failed: %v", err)
+	}
+	want, _ := (&pmetric.JSONMarshaler{}).MarshalMetrics(testMetrics())
+	got, _ := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+	if string(got) != string(want) {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,361 @@
+package marshaler
+
+import (
+	"crypto/rand"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// hiveDefaultPartition is the value written for a missing or empty partition
+// value, as Hive and Spark do.
+const hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// timePlaceholder matches the time placeholders of a path template.
+var timePlaceholder = regexp.MustCompile(`^(yyyy|MM|dd|HH|mm|ss|-|_)+$`)
+
+// timeLayout converts a time placeholder into a time layout.
+var timeLayout = strings.NewReplacer("yyyy", "2006", "MM", "01", "dd", "02", "HH", "15", "mm", "04", "ss", "05")
+
+type pathPartKind int
+
+const (
+	pathLiteral pathPartKind = iota
+	pathResource
+	pathAttribute
+	pathTime
+	pathSignal
+	pathUUID
+	pathExtension
+)
+
+// pathPart is a literal or a placeholder of a path template. The text is the
+// literal, the attribute key or the time layout.
+type pathPart struct {
+	kind pathPartKind
+	text string
+}
+
+// PathTemplate is the template of the path a payload is written to, e.g.
+//
+//	warehouse/{resource.service.name}/year_month_date={yyyyMMdd}/part-{uuid}.{ext}
+//
+// Its placeholders are:
+//
+//   - {resource.<key>}: a resource attribute.
+//   - {attributes.<key>}: an attribute of the log record, data point or span.
+//   - {yyyy}, {MM}, {dd}, {HH}, {mm}, {ss} and combinations such as
+//     {yyyyMMdd} or {yyyy-MM-dd}: the UTC time of the log record, falling
+//     back to its observed time, of the data point or of the start of the
+//     span.
+//   - {signal}: logs, metrics or traces.
+//   - {uuid}: a random UUID, unique for every payload.
+//   - {ext}: the file name extension of the payload, e.g. csv.zst.
+//
+// Attribute and time placeholders partition a batch, see PartitionLogs.
+// Attribute values are escaped as Hive escapes partition values, so that
+// they hold no path separator, and missing or empty values are written as
+// __HIVE_DEFAULT_PARTITION__. A metric without data points is partitioned
+// with empty attributes and the zero time.
+type PathTemplate struct {
+	parts []pathPart
+}
+
+// ParsePathTemplate parses a path template. The path must be relative and
+// every placeholder known.
+func ParsePathTemplate(s string) (*PathTemplate, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty path template")
+	}
+	if path.IsAbs(s) || strings.HasPrefix(s, `\`) {
+		return nil, fmt.Errorf("path template must be relative: %s", s)
+	}
+	t := &PathTemplate{}
+	for rest := s; rest != ""; {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			t.parts = append(t.parts, pathPart{kind: pathLiteral, text: rest})
+			break
+		}
+		if open > 0 {
+			t.parts = append(t.parts, pathPart{kind: pathLiteral, text: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed placeholder in path template: %s", s)
+		}
+		part, err := parsePlaceholder(rest[open+1 : open+end])
+		if err != nil {
+			return nil, err
+		}
+		t.parts = append(t.parts, part)
+		rest = rest[open+end+1:]
+	}
+	for _, part := range t.parts {
+		if part.kind != pathLiteral {
+			continue
+		}
+		if strings.Contains(part.text, "}") {
+			return nil, fmt.Errorf("unopened placeholder in path template: %s", s)
+		}
+		for _, segment := range strings.Split(part.text, "/") {
+			if segment == ".." {
+				return nil, fmt.Errorf("path template must not leave its directory: %s", s)
+			}
+		}
+	}
+	return t, nil
+}
+
+func parsePlaceholder(name string) (pathPart, error) {
+	switch {
+	case name == "signal":
+		return pathPart{kind: pathSignal}, nil
+	case name == "uuid":
+		return pathPart{kind: pathUUID}, nil
+	case name == "ext":
+		return pathPart{kind: pathExtension}, nil
+	case strings.HasPrefix(name, "resource.") && len(name) > len("resource."):
+		return pathPart{kind: pathResource, text: strings.TrimPrefix(name, "resource.")}, nil
+	case strings.HasPrefix(name, "attributes.") && len(name) > len("attributes."):
+		return pathPart{kind: pathAttribute, text: strings.TrimPrefix(name, "attributes.")}, nil
+	case timePlaceholder.MatchString(name):
+		return pathPart{kind: pathTime, text: timeLayout.Replace(name)}, nil
+	default:
+		return pathPart{}, fmt.Errorf("unknown placeholder in path template: {%s}", name)
+	}
+}
+
+// PathPartition holds the records of a batch sharing the values of the
+// attribute and time placeholders of a path template.
+type PathPartition[T any] struct {
+	Batch T
+
+	template *PathTemplate
+	// values are the values of the partitioning placeholders, in order.
+	values []string
+}
+
+// Path renders the path of a payload of the partition, with a new {uuid}
+// every call. The extension is the one of FileExtension, its leading dot
+// being dropped for {ext}. A partition without template has no path.
+func (p PathPartition[T]) Path(signal, extension string) string {
+	if p.template == nil {
+		return ""
+	}
+	var b strings.Builder
+	values := p.values
+	for _, part := range p.template.parts {
+		switch part.kind {
+		case pathLiteral:
+			b.WriteString(part.text)
+		case pathResource, pathAttribute, pathTime:
+			b.WriteString(values[0])
+			values = values[1:]
+		case pathSignal:
+			b.WriteString(signal)
+		case pathUUID:
+			b.WriteString(newUUID())
+		case pathExtension:
+			b.WriteString(strings.TrimPrefix(extension, "."))
+		}
+	}
+	return b.String()
+}
+
+// PartitionLogs splits logs into partitions of the log records sharing the
+// values of the template, in order of first appearance. Every partition is a
+// copy holding the resources and scopes of its records, unless the batch
+// holds a single partition.
+func (t *PathTemplate) PartitionLogs(ld plog.Logs) []PathPartition[plog.Logs] {
+	var keys [][]string
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			lrs := sls.At(j).LogRecords()
+			for k := 0; k < lrs.Len(); k++ {
+				lr := lrs.At(k)
+				ts := lr.Timestamp()
+				if ts == 0 {
+					ts = lr.ObservedTimestamp()
+				}
+				keys = append(keys, t.values(rl.Resource().Attributes(), lr.Attributes(), ts))
+			}
+		}
+	}
+	return partition(t, ld, keys, routeLogs)
+}
+
+// PartitionMetrics splits metrics into partitions of the data points sharing
+// the values of the template, see PartitionLogs.
+func (t *PathTemplate) PartitionMetrics(md pmetric.Metrics) []PathPartition[pmetric.Metrics] {
+	var keys [][]string
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			metrics := sms.At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				metric := metrics.At(k)
+				if metricDataPointCount(metric) == 0 {
+					keys = append(keys, t.values(rm.Resource().Attributes(), pcommon.NewMap(), 0))
+					continue
+				}
+				forEachDataPoint(metric, func(ts pcommon.Timestamp, attrs pcommon.Map) {
+					keys = append(keys, t.values(rm.Resource().Attributes(), attrs, ts))
+				})
+			}
+		}
+	}
+	return partition(t, md, keys, routeMetrics)
+}
+
+// PartitionTraces splits traces into partitions of the spans sharing the
+// values of the template, see PartitionLogs.
+func (t *PathTemplate) PartitionTraces(td ptrace.Traces) []PathPartition[ptrace.Traces] {
+	var keys [][]string
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				s := spans.At(k)
+				keys = append(keys, t.values(rs.Resource().Attributes(), s.Attributes(), s.StartTimestamp()))
+			}
+		}
+	}
+	return partition(t, td, keys, routeTraces)
+}
+
+// partition groups the records of a batch by their values in a single pass.
+// route copies the records [from, to) of the batch into the batch of their
+// group, see routeLogs.
+func partition[T any](t *PathTemplate, batch T, keys [][]string, route func(T, int, int, int, func(int) int) []T) []PathPartition[T] {
+	var order [][]string
+	groups := map[string]int{}
+	indexes := make([]int, len(keys))
+	for i, values := range keys {
+		// Values are escaped, so they hold no NUL to be confused with.
+		key := strings.Join(values, "\x00")
+		g, ok := groups[key]
+		if !ok {
+			g = len(order)
+			groups[key] = g
+			order = append(order, values)
+		}
+		indexes[i] = g
+	}
+	if len(order) <= 1 {
+		var values []string
+		if len(order) == 1 {
+			values = order[0]
+		} else {
+			values = t.values(pcommon.NewMap(), pcommon.NewMap(), 0)
+		}
+		return []PathPartition[T]{{Batch: batch, template: t, values: values}}
+	}
+	batches := route(batch, 0, len(keys), len(order), func(i int) int {
+		return indexes[i]
+	})
+	partitions := make([]PathPartition[T], len(order))
+	for g, values := range order {
+		partitions[g] = PathPartition[T]{Batch: batches[g], template: t, values: values}
+	}
+	return partitions
+}
+
+// values returns the escaped values of the partitioning placeholders for a
+// record.
+func (t *PathTemplate) values(resource, attrs pcommon.Map, ts pcommon.Timestamp) []string {
+	var values []string
+	for _, part := range t.parts {
+		switch part.kind {
+		case pathResource:
+			values = append(values, partitionValue(resource, part.text))
+		case pathAttribute:
+			values = append(values, partitionValue(attrs, part.text))
+		case pathTime:
+			values = append(values, ts.AsTime().UTC().Format(part.text))
+		}
+	}
+	return values
+}
+
+// partitionValue returns the escaped value of an attribute.
+func partitionValue(attrs pcommon.Map, key string) string {
+	v, ok := attrs.Get(key)
+	if !ok || v.AsString() == "" {
+		return hiveDefaultPartition
+	}
+	return escapePartitionValue(v.AsString())
+}
+
+// escapePartitionValue escapes the characters Hive escapes in partition
+// values as %XX, and the dot segments that would leave the directory.
+func escapePartitionValue(s string) string {
+	if s == "." || s == ".." {
+		return strings.Repeat("%2E", len(s))
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte("\"#%'*/:=?\\{}[]^", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// forEachDataPoint calls fn with the time and attributes of every data point
+// of a metric.
+func forEachDataPoint(m pmetric.Metric, fn func(ts pcommon.Timestamp, attrs pcommon.Map)) {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < m.Gauge().DataPoints().Len(); i++ {
+			dp := m.Gauge().DataPoints().At(i)
+			fn(dp.Timestamp(), dp.Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < m.Sum().DataPoints().Len(); i++ {
+			dp := m.Sum().DataPoints().At(i)
+			fn(dp.Timestamp(), dp.Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < m.Histogram().DataPoints().Len(); i++ {
+			dp := m.Histogram().DataPoints().At(i)
+			fn(dp.Timestamp(), dp.Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < m.ExponentialHistogram().DataPoints().Len(); i++ {
+			dp := m.ExponentialHistogram().DataPoints().At(i)
+			fn(dp.Timestamp(), dp.Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < m.Summary().DataPoints().Len(); i++ {
+			dp := m.Summary().DataPoints().At(i)
+			fn(dp.Timestamp(), dp.Attributes())
+		}
+	}
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var u [16]byte
+	_, _ = rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,129 @@
+package marshaler_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestPathTemplatePartitionLogs(t *testing.T) {
+	ld := plog.NewLogs()
+	for _, record := range []struct {
+		service string
+		ts      pcommon.Timestamp
+	}{
+		{"atm", testTimestamp},
+		{"bank/core", testTimestamp},
+		{"atm", testTimestamp + 24*3600e9},
+		{"atm", testTimestamp + 60e9},
+		{"", testTimestamp},
+	} {
+		rl := ld.ResourceLogs().AppendEmpty()
+		if record.service != "" {
+			rl.Resource().Attributes().PutStr("service.name", record.service)
+		}
+		rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().SetTimestamp(record.ts)
+	}
+
+	tmpl, err := marshaler.ParsePathTemplate("warehouse/{resource.service.name}/year_month_date={yyyyMMdd}/part-{uuid}.{ext}")
+	if err != nil {
+		t.Fatalf("ParsePathTemplate() failed: %v", err)
+	}
+	partitions := tmpl.PartitionLogs(ld)
+
+	uuid := `[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}`
+	want := []struct {
+		path    string
+		records int
+	}{
+		{`warehouse/atm/year_month_date=20250102/part-` + uuid + `\.csv\.zst`, 2},
+		{`warehouse/bank%2Fcore/year_month_date=20250102/part-` + uuid + `\.csv\.zst`, 1},
+		{`warehouse/atm/year_month_date=20250103/part-` + uuid + `\.csv\.zst`, 1},
+		{`warehouse/__HIVE_DEFAULT_PARTITION__/year_month_date=20250102/part-` + uuid + `\.csv\.zst`, 1},
+	}
+	if len(partitions) != len(want) {
+		t.Fatalf("Expected %d partitions, got %d", len(want), len(partitions))
+	}
+	for i, p := range partitions {
+		path := p.Path("logs", ".csv.zst")
+		if !regexp.MustCompile("^" + want[i].path + "$").MatchString(path) {
+			t.Errorf("Expected partition %d to match %s, got %s", i, want[i].path, path)
+		}
+		if p.Path("logs", ".csv.zst") == path {
+			t.Errorf("Expected a new uuid for every path, got %s twice", path)
+		}
+		if got := p.Batch.LogRecordCount(); got != want[i].records {
+			t.Errorf("Expected %d records in partition %d, got %d", want[i].records, i, got)
+		}
+	}
+}
+
+func TestPathTemplatePartitionMetrics(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	metrics := rm.ScopeMetrics().AppendEmpty().Metrics()
+	dps := metrics.AppendEmpty().SetEmptyGauge().DataPoints()
+	for _, cassette := range []string{"a", "b", "a"} {
+		dp := dps.AppendEmpty()
+		dp.SetTimestamp(testTimestamp)
+		dp.Attributes().PutStr("cassette", cassette)
+	}
+	metrics.AppendEmpty().SetName("empty")
+
+	tmpl, err := marshaler.ParsePathTemplate("{signal}/cassette={attributes.cassette}/{yyyy-MM-dd}/{HH}.{ext}")
+	if err != nil {
+		t.Fatalf("ParsePathTemplate() failed: %v", err)
+	}
+	partitions := tmpl.PartitionMetrics(md)
+	want := []struct {
+		path       string
+		dataPoints int
+	}{
+		{"metrics/cassette=a/2025-01-02/03.csv", 2},
+		{"metrics/cassette=b/2025-01-02/03.csv", 1},
+		{"metrics/cassette=__HIVE_DEFAULT_PARTITION__/1970-01-01/00.csv", 0},
+	}
+	if len(partitions) != len(want) {
+		t.Fatalf("Expected %d partitions, got %d", len(want), len(partitions))
+	}
+	for i, p := range partitions {
+		if got := p.Path("metrics", ".csv"); got != want[i].path {
+			t.Errorf("Expected path %s, got %s", want[i].path, got)
+		}
+		if got := p.Batch.DataPointCount(); got != want[i].dataPoints {
+			t.Errorf("Expected %d data points in %s, got %d", want[i].dataPoints, want[i].path, got)
+		}
+	}
+}
+
+func TestPathTemplateSinglePartition(t *testing.T) {
+	tmpl, err := marshaler.ParsePathTemplate("traces/{resource.service.name}.{ext}")
+	if err != nil {
+		t.Fatalf("ParsePathTemplate() failed: %v", err)
+	}
+	td := testTraces()
+	partitions := tmpl.PartitionTraces(td)
+	if len(partitions) != 1 || partitions[0].Batch.SpanCount() != td.SpanCount() {
+		t.Fatalf("Expected a single partition holding every span, got %d", len(partitions))
+	}
+}
+
+func TestParsePathTemplateInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"/warehouse/{uuid}",
+		"../warehouse/{uuid}",
+		"warehouse/{unknown}",
+		"warehouse/{resource.}",
+		"warehouse/{uuid",
+		"warehouse/uuid}",
+	} {
+		if _, err := marshaler.ParsePathTemplate(s); err == nil {
+			t.Errorf("Expected %q to be invalid", s)
+		}
+	}
+}
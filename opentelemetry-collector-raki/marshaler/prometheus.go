@@ -0,0 +1,559 @@
+package marshaler
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// PrometheusConfig defines the options of the Prometheus text marshaler.
+type PrometheusConfig struct {
+	// OpenMetrics writes the OpenMetrics text format instead of the
+	// Prometheus text exposition format 0.0.4.
+	OpenMetrics bool `mapstructure:"openmetrics"`
+
+	// Timestamps appends the timestamp of every sample. The node_exporter
+	// textfile collector rejects samples with timestamps, so they are off by
+	// default.
+	Timestamps bool `mapstructure:"timestamps"`
+
+	// ResourceToLabels adds every resource attribute as a label. Otherwise
+	// only the job and instance labels are derived from the resource.
+	ResourceToLabels bool `mapstructure:"resource_to_labels"`
+}
+
+// Validate checks that the Prometheus options are supported.
+func (c PrometheusConfig) Validate() error {
+	return nil
+}
+
+// PrometheusMarshaler writes metrics in the Prometheus text exposition
+// format, following the OpenTelemetry to Prometheus compatibility rules:
+//
+//   - metric and label names are sanitized, unit suffixes are appended and
+//     monotonic sums become counters with a _total suffix;
+//   - data point attributes become labels, and the job and instance labels
+//     are derived from service.namespace, service.name and
+//     service.instance.id;
+//   - histograms are written as _bucket, _sum and _count series, exponential
+//     histograms being converted to cumulative buckets, and summaries as
+//     quantile, _sum and _count series.
+//
+// Sums and histograms with delta temporality have no Prometheus
+// representation and are dropped, as are metrics whose name collides with a
+// metric of another type.
+type PrometheusMarshaler struct {
+	config PrometheusConfig
+}
+
+func NewPrometheusMarshaler() PrometheusMarshaler {
+	return PrometheusMarshaler{}
+}
+
+// NewPrometheusMarshalerWithConfig creates a Prometheus text marshaler with
+// the given options.
+func NewPrometheusMarshalerWithConfig(config PrometheusConfig) PrometheusMarshaler {
+	return PrometheusMarshaler{config: config}
+}
+
+// MarshalMetrics converts OpenTelemetry metrics into the Prometheus text format.
+func (m PrometheusMarshaler) MarshalMetrics(md pmetric.Metrics) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteMetrics(&buf, md); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteMetrics writes OpenTelemetry metrics into w in the Prometheus text
+// format. The samples of a metric family must be contiguous, so the whole
+// batch is grouped by family before being written.
+func (m PrometheusMarshaler) WriteMetrics(w io.Writer, md pmetric.Metrics) error {
+	families := m.families(md)
+	bw := bufio.NewWriter(w)
+	for _, family := range families {
+		name := family.name
+		if m.config.OpenMetrics && family.typ == "counter" {
+			name = strings.TrimSuffix(name, "_total")
+		}
+		if family.help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", name, m.escapeHelp(family.help))
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, family.typ)
+		if m.config.OpenMetrics && family.unit != "" {
+			fmt.Fprintf(bw, "# UNIT %s %s\n", name, family.unit)
+		}
+		for _, sample := range family.samples {
+			bw.WriteString(sample)
+		}
+	}
+	if m.config.OpenMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// contentType returns the media type of the configured format.
+func (m PrometheusMarshaler) contentType() string {
+	if m.config.OpenMetrics {
+		return contentTypeOpenMetrics
+	}
+	return contentTypePrometheusText
+}
+
+// prometheusFamily is a metric family: the samples sharing a name and a type.
+type prometheusFamily struct {
+	name    string
+	typ     string
+	help    string
+	unit    string
+	samples []string
+}
+
+// families groups the samples of a batch by metric family, in the order the
+// families first appear.
+func (m PrometheusMarshaler) families(md pmetric.Metrics) []*prometheusFamily {
+	var families []*prometheusFamily
+	byName := map[string]*prometheusFamily{}
+
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		resourceLabels := m.resourceLabels(rm.Resource().Attributes())
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			sm := sms.At(j)
+			labels := append(append([]prometheusLabel(nil), resourceLabels...), scopeLabels(sm.Scope())...)
+			metrics := sm.Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				metric := metrics.At(k)
+				typ, ok := prometheusType(metric)
+				if !ok {
+					continue
+				}
+				name := prometheusMetricName(metric, typ)
+				family, ok := byName[name]
+				if !ok {
+					family = &prometheusFamily{name: name, typ: typ, help: metric.Description(), unit: prometheusUnit(metric.Unit(), metric.Type())}
+					byName[name] = family
+					families = append(families, family)
+				} else if family.typ != typ {
+					continue
+				}
+				m.appendSamples(family, metric, labels)
+			}
+		}
+	}
+	return families
+}
+
+// prometheusType returns the Prometheus type of a metric, reporting false for
+// metrics that cannot be represented.
+func prometheusType(metric pmetric.Metric) (string, bool) {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		return "gauge", true
+	case pmetric.MetricTypeSum:
+		sum := metric.Sum()
+		if !sum.IsMonotonic() {
+			return "gauge", true
+		}
+		return "counter", sum.AggregationTemporality() == pmetric.AggregationTemporalityCumulative
+	case pmetric.MetricTypeHistogram:
+		return "histogram", metric.Histogram().AggregationTemporality() == pmetric.AggregationTemporalityCumulative
+	case pmetric.MetricTypeExponentialHistogram:
+		return "histogram", metric.ExponentialHistogram().AggregationTemporality() == pmetric.AggregationTemporalityCumulative
+	case pmetric.MetricTypeSummary:
+		return "summary", true
+	}
+	return "", false
+}
+
+// appendSamples appends the samples of every data point of a metric.
+func (m PrometheusMarshaler) appendSamples(family *prometheusFamily, metric pmetric.Metric, labels []prometheusLabel) {
+	name := family.name
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		m.appendNumberSamples(family, metric.Gauge().DataPoints(), labels)
+	case pmetric.MetricTypeSum:
+		m.appendNumberSamples(family, metric.Sum().DataPoints(), labels)
+	case pmetric.MetricTypeHistogram:
+		dps := metric.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if dp.Flags().NoRecordedValue() {
+				continue
+			}
+			pointLabels := withAttributes(labels, dp.Attributes())
+			var cumulative uint64
+			for b := 0; b < dp.ExplicitBounds().Len() && b < dp.BucketCounts().Len(); b++ {
+				cumulative += dp.BucketCounts().At(b)
+				le := prometheusLabel{name: "le", value: formatPrometheusFloat(dp.ExplicitBounds().At(b))}
+				m.appendSample(family, name+"_bucket", append(pointLabels, le), float64(cumulative), dp.Timestamp())
+			}
+			m.appendHistogramTotals(family, pointLabels, dp.Count(), dp.HasSum(), dp.Sum(), dp.Timestamp())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if dp.Flags().NoRecordedValue() {
+				continue
+			}
+			pointLabels := withAttributes(labels, dp.Attributes())
+			for _, bucket := range exponentialBuckets(dp) {
+				le := prometheusLabel{name: "le", value: formatPrometheusFloat(bucket.le)}
+				m.appendSample(family, name+"_bucket", append(pointLabels, le), float64(bucket.count), dp.Timestamp())
+			}
+			m.appendHistogramTotals(family, pointLabels, dp.Count(), dp.HasSum(), dp.Sum(), dp.Timestamp())
+		}
+	case pmetric.MetricTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if dp.Flags().NoRecordedValue() {
+				continue
+			}
+			pointLabels := withAttributes(labels, dp.Attributes())
+			for q := 0; q < dp.QuantileValues().Len(); q++ {
+				qv := dp.QuantileValues().At(q)
+				quantile := prometheusLabel{name: "quantile", value: formatPrometheusFloat(qv.Quantile())}
+				m.appendSample(family, name, append(pointLabels, quantile), qv.Value(), dp.Timestamp())
+			}
+			m.appendSample(family, name+"_sum", pointLabels, dp.Sum(), dp.Timestamp())
+			m.appendSample(family, name+"_count", pointLabels, float64(dp.Count()), dp.Timestamp())
+		}
+	}
+}
+
+func (m PrometheusMarshaler) appendNumberSamples(family *prometheusFamily, dps pmetric.NumberDataPointSlice, labels []prometheusLabel) {
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		if dp.Flags().NoRecordedValue() {
+			continue
+		}
+		value := dp.DoubleValue()
+		if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+			value = float64(dp.IntValue())
+		}
+		m.appendSample(family, family.name, withAttributes(labels, dp.Attributes()), value, dp.Timestamp())
+	}
+}
+
+// appendHistogramTotals appends the +Inf bucket and the _sum and _count
+// series of a histogram data point.
+func (m PrometheusMarshaler) appendHistogramTotals(family *prometheusFamily, labels []prometheusLabel, count uint64, hasSum bool, sum float64, ts pcommon.Timestamp) {
+	inf := prometheusLabel{name: "le", value: "+Inf"}
+	m.appendSample(family, family.name+"_bucket", append(labels, inf), float64(count), ts)
+	if hasSum {
+		m.appendSample(family, family.name+"_sum", labels, sum, ts)
+	}
+	m.appendSample(family, family.name+"_count", labels, float64(count), ts)
+}
+
+// appendSample formats a sample line and appends it to a family.
+func (m PrometheusMarshaler) appendSample(family *prometheusFamily, name string, labels []prometheusLabel, value float64, ts pcommon.Timestamp) {
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteByte('{')
+		for i, label := range sortedLabels(labels) {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(label.name)
+			sb.WriteString(`="`)
+			sb.WriteString(escapeLabelValue(label.value))
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(formatPrometheusFloat(value))
+	if m.config.Timestamps && ts != 0 {
+		sb.WriteByte(' ')
+		if m.config.OpenMetrics {
+			sb.WriteString(strconv.FormatFloat(float64(ts)/1e9, 'f', -1, 64))
+		} else {
+			sb.WriteString(strconv.FormatInt(int64(ts)/1e6, 10))
+		}
+	}
+	sb.WriteByte('\n')
+	family.samples = append(family.samples, sb.String())
+}
+
+// prometheusLabel is a sanitized label name and its value.
+type prometheusLabel struct {
+	name  string
+	value string
+}
+
+// resourceLabels returns the labels derived from a resource: job and
+// instance, and every attribute when ResourceToLabels is set.
+func (m PrometheusMarshaler) resourceLabels(resource pcommon.Map) []prometheusLabel {
+	var labels []prometheusLabel
+	if m.config.ResourceToLabels {
+		labels = withAttributes(labels, resource)
+	}
+	job := serviceName(resource)
+	if namespace, ok := resource.Get("service.namespace"); ok && job != "" {
+		job = namespace.AsString() + "/" + job
+	}
+	if job != "" {
+		labels = append(labels, prometheusLabel{name: "job", value: job})
+	}
+	if instance, ok := resource.Get("service.instance.id"); ok {
+		labels = append(labels, prometheusLabel{name: "instance", value: instance.AsString()})
+	}
+	return labels
+}
+
+// scopeLabels returns the otel_scope_name and otel_scope_version labels.
+func scopeLabels(scope pcommon.InstrumentationScope) []prometheusLabel {
+	var labels []prometheusLabel
+	if scope.Name() != "" {
+		labels = append(labels, prometheusLabel{name: "otel_scope_name", value: scope.Name()})
+	}
+	if scope.Version() != "" {
+		labels = append(labels, prometheusLabel{name: "otel_scope_version", value: scope.Version()})
+	}
+	return labels
+}
+
+// withAttributes returns a copy of labels with the attributes appended as labels.
+func withAttributes(labels []prometheusLabel, attrs pcommon.Map) []prometheusLabel {
+	out := make([]prometheusLabel, len(labels), len(labels)+attrs.Len()+1)
+	copy(out, labels)
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		out = append(out, prometheusLabel{name: sanitizeLabelName(k), value: v.AsString()})
+		return true
+	})
+	return out
+}
+
+// sortedLabels sorts labels by name, the values of labels whose names
+// collide once sanitized being joined with ';'. The last job and instance
+// labels win over attributes of the same name.
+func sortedLabels(labels []prometheusLabel) []prometheusLabel {
+	values := make(map[string]string, len(labels))
+	var names []string
+	for _, label := range labels {
+		previous, ok := values[label.name]
+		switch {
+		case !ok:
+			names = append(names, label.name)
+			values[label.name] = label.value
+		case label.name == "job" || label.name == "instance" || label.name == "le" || label.name == "quantile":
+			values[label.name] = label.value
+		default:
+			values[label.name] = previous + ";" + label.value
+		}
+	}
+	sort.Strings(names)
+	sorted := make([]prometheusLabel, len(names))
+	for i, name := range names {
+		sorted[i] = prometheusLabel{name: name, value: values[name]}
+	}
+	return sorted
+}
+
+// exponentialBucket is a cumulative bucket of a converted exponential histogram.
+type exponentialBucket struct {
+	le    float64
+	count uint64
+}
+
+// exponentialBuckets converts the buckets of an exponential histogram data
+// point into cumulative buckets, from the most negative bucket to the most
+// positive, the zero bucket being bounded by the zero threshold.
+func exponentialBuckets(dp pmetric.ExponentialHistogramDataPoint) []exponentialBucket {
+	base := math.Exp2(math.Exp2(-float64(dp.Scale())))
+	var buckets []exponentialBucket
+	var cumulative uint64
+
+	negative := dp.Negative()
+	for i := negative.BucketCounts().Len() - 1; i >= 0; i-- {
+		cumulative += negative.BucketCounts().At(i)
+		index := float64(negative.Offset()) + float64(i)
+		buckets = append(buckets, exponentialBucket{le: -math.Pow(base, index), count: cumulative})
+	}
+	if dp.ZeroCount() > 0 || negative.BucketCounts().Len() > 0 {
+		cumulative += dp.ZeroCount()
+		buckets = append(buckets, exponentialBucket{le: dp.ZeroThreshold(), count: cumulative})
+	}
+	positive := dp.Positive()
+	for i := 0; i < positive.BucketCounts().Len(); i++ {
+		cumulative += positive.BucketCounts().At(i)
+		index := float64(positive.Offset()) + float64(i)
+		buckets = append(buckets, exponentialBucket{le: math.Pow(base, index+1), count: cumulative})
+	}
+	return buckets
+}
+
+// prometheusUnits maps UCUM units to the full unit names used as suffixes.
+var prometheusUnits = map[string]string{
+	"d":    "days",
+	"h":    "hours",
+	"min":  "minutes",
+	"s":    "seconds",
+	"ms":   "milliseconds",
+	"us":   "microseconds",
+	"ns":   "nanoseconds",
+	"By":   "bytes",
+	"KiBy": "kibibytes",
+	"MiBy": "mebibytes",
+	"GiBy": "gibibytes",
+	"TiBy": "tibibytes",
+	"KBy":  "kilobytes",
+	"MBy":  "megabytes",
+	"GBy":  "gigabytes",
+	"TBy":  "terabytes",
+	"m":    "meters",
+	"V":    "volts",
+	"A":    "amperes",
+	"J":    "joules",
+	"W":    "watts",
+	"g":    "grams",
+	"Cel":  "celsius",
+	"Hz":   "hertz",
+	"%":    "percent",
+}
+
+// prometheusPerUnits maps the UCUM units following a '/' to their names.
+var prometheusPerUnits = map[string]string{
+	"s":  "second",
+	"m":  "minute",
+	"h":  "hour",
+	"d":  "day",
+	"w":  "week",
+	"mo": "month",
+	"y":  "year",
+}
+
+// prometheusUnit returns the unit suffix of a metric: the full name of its
+// unit, ratio for dimensionless gauges, and nothing for annotations such as
+// {requests}.
+func prometheusUnit(unit string, typ pmetric.MetricType) string {
+	if i := strings.Index(unit, "{"); i >= 0 {
+		unit = unit[:i]
+	}
+	if unit == "1" {
+		if typ == pmetric.MetricTypeGauge {
+			return "ratio"
+		}
+		return ""
+	}
+	main, per, _ := strings.Cut(unit, "/")
+	suffix := ""
+	if main != "" {
+		suffix = unitName(main, prometheusUnits)
+	}
+	if per != "" {
+		if suffix != "" {
+			suffix += "_"
+		}
+		suffix += "per_" + unitName(per, prometheusPerUnits)
+	}
+	return suffix
+}
+
+func unitName(unit string, names map[string]string) string {
+	if name, ok := names[unit]; ok {
+		return name
+	}
+	return strings.Trim(sanitizeMetricName(unit), "_")
+}
+
+// prometheusMetricName returns the sanitized name of a metric with its unit
+// suffix, and the _total suffix for counters.
+func prometheusMetricName(metric pmetric.Metric, typ string) string {
+	name := sanitizeMetricName(metric.Name())
+	if unit := prometheusUnit(metric.Unit(), metric.Type()); unit != "" && !strings.HasSuffix(name, "_"+unit) {
+		name += "_" + unit
+	}
+	if typ == "counter" && !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	return name
+}
+
+// sanitizeMetricName replaces the characters that are not allowed in metric
+// names by '_', collapsing consecutive underscores, and prefixes names
+// starting with a digit.
+func sanitizeMetricName(name string) string {
+	var sb strings.Builder
+	var previous rune
+	for i, r := range name {
+		if i == 0 && r >= '0' && r <= '9' {
+			sb.WriteByte('_')
+			previous = '_'
+		}
+		if r != ':' && r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			r = '_'
+		}
+		if r == '_' && previous == '_' {
+			continue
+		}
+		sb.WriteRune(r)
+		previous = r
+	}
+	return sb.String()
+}
+
+// sanitizeLabelName replaces the characters that are not allowed in label
+// names by '_', and prefixes names starting with a digit with key_.
+func sanitizeLabelName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if i == 0 && r >= '0' && r <= '9' {
+			sb.WriteString("key_")
+		}
+		if r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			r = '_'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+// escapeHelp escapes a HELP text, OpenMetrics also escaping double quotes.
+func (m PrometheusMarshaler) escapeHelp(s string) string {
+	if m.config.OpenMetrics {
+		return labelValueEscaper.Replace(s)
+	}
+	return helpEscaper.Replace(s)
+}
+
+// formatPrometheusFloat formats a sample value or a bound.
+func formatPrometheusFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
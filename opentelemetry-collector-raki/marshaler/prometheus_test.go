@@ -0,0 +1,145 @@
+package marshaler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler/marshalertest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func prometheusTestMetrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "atm")
+	rm.Resource().Attributes().PutStr("service.instance.id", "atm-111")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("tailtracer")
+	metrics := sm.Metrics()
+
+	gauge := metrics.AppendEmpty()
+	gauge.SetName("atm.cash-level")
+	gauge.SetDescription("Cash left\nin the ATM")
+	gauge.SetUnit("1")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(testTimestamp)
+	dp.SetDoubleValue(0.25)
+	dp.Attributes().PutStr("1st.currency", `E"U\R`)
+
+	counter := metrics.AppendEmpty()
+	counter.SetName("atm.withdrawals")
+	counter.SetUnit("{withdrawals}")
+	sum := counter.SetEmptySum()
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	sum.SetIsMonotonic(true)
+	sum.DataPoints().AppendEmpty().SetIntValue(7)
+
+	delta := metrics.AppendEmpty()
+	delta.SetName("atm.deposits")
+	deltaSum := delta.SetEmptySum()
+	deltaSum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	deltaSum.SetIsMonotonic(true)
+	deltaSum.DataPoints().AppendEmpty().SetIntValue(3)
+
+	histogram := metrics.AppendEmpty()
+	histogram.SetName("atm.latency")
+	histogram.SetUnit("ms")
+	h := histogram.SetEmptyHistogram()
+	h.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	hdp := h.DataPoints().AppendEmpty()
+	hdp.SetCount(6)
+	hdp.SetSum(12.5)
+	hdp.BucketCounts().FromRaw([]uint64{1, 2, 3})
+	hdp.ExplicitBounds().FromRaw([]float64{1, 5})
+
+	exponential := metrics.AppendEmpty()
+	exponential.SetName("atm.size")
+	e := exponential.SetEmptyExponentialHistogram()
+	e.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	edp := e.DataPoints().AppendEmpty()
+	edp.SetCount(4)
+	edp.SetScale(0)
+	edp.SetZeroCount(1)
+	edp.Positive().SetOffset(1)
+	edp.Positive().BucketCounts().FromRaw([]uint64{1, 1})
+	edp.Negative().BucketCounts().FromRaw([]uint64{1})
+
+	summary := metrics.AppendEmpty()
+	summary.SetName("atm.session")
+	summary.SetUnit("s")
+	sdp := summary.SetEmptySummary().DataPoints().AppendEmpty()
+	sdp.SetCount(10)
+	sdp.SetSum(100)
+	q := sdp.QuantileValues().AppendEmpty()
+	q.SetQuantile(0.99)
+	q.SetValue(9.5)
+	return md
+}
+
+func TestPrometheusText(t *testing.T) {
+	m := marshaler.NewPrometheusTextMetrics()
+	if _, ok := marshaler.BaseMetricsMarshalers()[m.Encoding()]; !ok {
+		t.Errorf("Expected %s to be registered", m.Encoding())
+	}
+	buf, err := m.Marshal(prometheusTestMetrics())
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	labels := `instance="atm-111",job="atm",otel_scope_name="tailtracer"`
+	le := func(bound string) string {
+		return `{instance="atm-111",job="atm",le="` + bound + `",otel_scope_name="tailtracer"}`
+	}
+	want := strings.Join([]string{
+		`# HELP atm_cash_level_ratio Cash left\nin the ATM`,
+		`# TYPE atm_cash_level_ratio gauge`,
+		`atm_cash_level_ratio{instance="atm-111",job="atm",key_1st_currency="E\"U\\R",otel_scope_name="tailtracer"} 0.25`,
+		`# TYPE atm_withdrawals_total counter`,
+		`atm_withdrawals_total{` + labels + `} 7`,
+		`# TYPE atm_latency_milliseconds histogram`,
+		`atm_latency_milliseconds_bucket` + le("1") + ` 1`,
+		`atm_latency_milliseconds_bucket` + le("5") + ` 3`,
+		`atm_latency_milliseconds_bucket` + le("+Inf") + ` 6`,
+		`atm_latency_milliseconds_sum{` + labels + `} 12.5`,
+		`atm_latency_milliseconds_count{` + labels + `} 6`,
+		`# TYPE atm_size histogram`,
+		`atm_size_bucket` + le("-1") + ` 1`,
+		`atm_size_bucket` + le("0") + ` 2`,
+		`atm_size_bucket` + le("4") + ` 3`,
+		`atm_size_bucket` + le("8") + ` 4`,
+		`atm_size_bucket` + le("+Inf") + ` 4`,
+		`atm_size_count{` + labels + `} 4`,
+		`# TYPE atm_session_seconds summary`,
+		`atm_session_seconds{` + labels + `,quantile="0.99"} 9.5`,
+		`atm_session_seconds_sum{` + labels + `} 100`,
+		`atm_session_seconds_count{` + labels + `} 10`,
+	}, "\n") + "\n"
+	if string(buf) != want {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", want, buf)
+	}
+
+	om := marshaler.NewPrometheusTextMetricsWithConfig(marshaler.PrometheusConfig{OpenMetrics: true, Timestamps: true})
+	buf, err = om.Marshal(prometheusTestMetrics())
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	for _, line := range []string{
+		"# TYPE atm_withdrawals counter\n",
+		"# UNIT atm_latency_milliseconds milliseconds\n",
+		"} 0.25 1735787045\n",
+	} {
+		if !strings.Contains(string(buf), line) {
+			t.Errorf("Expected OpenMetrics output to contain %q, got:\n%s", line, buf)
+		}
+	}
+	if !strings.HasSuffix(string(buf), "# EOF\n") {
+		t.Errorf("Expected OpenMetrics output to end with # EOF, got:\n%s", buf)
+	}
+	if !strings.HasPrefix(om.ContentType(), "application/openmetrics-text") {
+		t.Errorf("Expected an OpenMetrics content type, got %s", om.ContentType())
+	}
+}
+
+func TestPrometheusTextConformance(t *testing.T) {
+	marshalertest.TestMetrics(t, marshaler.NewPrometheusTextMetrics(), nil)
+}
@@ -0,0 +1,392 @@
+package marshaler
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// ErrRecordTooLarge is returned when a single record does not fit in a chunk,
+// so that the batch cannot be split under the byte limit.
+var ErrRecordTooLarge = errors.New("record larger than the chunk size limit")
+
+// SplitConfig bounds the size of the payloads a batch is marshaled into. A
+// zero limit is not enforced.
+type SplitConfig struct {
+	// MaxBytes is the maximum size of a payload in bytes, after compression
+	// for compressed marshalers.
+	MaxBytes int `mapstructure:"max_bytes"`
+
+	// MaxRecords is the maximum number of log records, metric data points or
+	// spans in a payload.
+	MaxRecords int `mapstructure:"max_records"`
+}
+
+// Validate checks that the limits are not negative.
+func (c SplitConfig) Validate() error {
+	if c.MaxBytes < 0 {
+		return fmt.Errorf("invalid max bytes: %d", c.MaxBytes)
+	}
+	if c.MaxRecords < 0 {
+		return fmt.Errorf("invalid max records: %d", c.MaxRecords)
+	}
+	return nil
+}
+
+// MarshalLogsChunks serializes logs into one or more payloads under the
+// limits. Every payload is a complete output of the marshaler, with its own
+// CSV header or columnar footer, and can be read on its own.
+//
+// A batch within the limits is marshaled as is. Otherwise it is cut into runs
+// of at most MaxRecords log records, and every run over MaxBytes is halved
+// until it fits. Resources and scopes are repeated in every payload holding
+// some of their records.
+func MarshalLogsChunks(m Logs, logs plog.Logs, limits SplitConfig) ([][]byte, error) {
+	return marshalChunks(logs, logs.LogRecordCount(), m.Marshal, sliceLogs, limits)
+}
+
+// MarshalMetricsChunks serializes metrics into one or more payloads under the
+// limits, counting data points as records, see MarshalLogsChunks. A metric
+// without data points counts as one record.
+func MarshalMetricsChunks(m Metrics, metrics pmetric.Metrics, limits SplitConfig) ([][]byte, error) {
+	return marshalChunks(metrics, metricRecordCount(metrics), m.Marshal, sliceMetrics, limits)
+}
+
+// MarshalTracesChunks serializes traces into one or more payloads under the
+// limits, counting spans as records, see MarshalLogsChunks.
+func MarshalTracesChunks(m Traces, traces ptrace.Traces, limits SplitConfig) ([][]byte, error) {
+	return marshalChunks(traces, traces.SpanCount(), m.Marshal, sliceTraces, limits)
+}
+
+// marshalChunks splits a batch of n records into payloads under the limits.
+// slice returns the records [from, to) of the batch.
+func marshalChunks[T any](batch T, n int, marshal func(T) ([]byte, error), slice func(T, int, int) T, limits SplitConfig) ([][]byte, error) {
+	var chunks [][]byte
+	var split func(from, to int) error
+	split = func(from, to int) error {
+		part := batch
+		if from > 0 || to < n {
+			part = slice(batch, from, to)
+		}
+		b, err := marshal(part)
+		if err != nil {
+			return err
+		}
+		if limits.MaxBytes == 0 || len(b) <= limits.MaxBytes || to-from <= 1 {
+			if limits.MaxBytes > 0 && len(b) > limits.MaxBytes {
+				return fmt.Errorf("%w: record %d is %d bytes, over %d", ErrRecordTooLarge, from, len(b), limits.MaxBytes)
+			}
+			chunks = append(chunks, b)
+			return nil
+		}
+		mid := from + (to-from)/2
+		if err := split(from, mid); err != nil {
+			return err
+		}
+		return split(mid, to)
+	}
+
+	step := n
+	if limits.MaxRecords > 0 && limits.MaxRecords < n {
+		step = limits.MaxRecords
+	}
+	if n == 0 {
+		if err := split(0, 0); err != nil {
+			return nil, err
+		}
+		return chunks, nil
+	}
+	for from := 0; from < n; from += step {
+		if err := split(from, min(from+step, n)); err != nil {
+			return nil, err
+		}
+	}
+	return chunks, nil
+}
+
+// sliceLogs returns a copy of the log records [from, to), dropping the
+// resources and scopes left without records.
+func sliceLogs(ld plog.Logs, from, to int) plog.Logs {
+	return routeLogs(ld, from, to, 1, firstGroup)[0]
+}
+
+// sliceTraces returns a copy of the spans [from, to), dropping the resources
+// and scopes left without spans.
+func sliceTraces(td ptrace.Traces, from, to int) ptrace.Traces {
+	return routeTraces(td, from, to, 1, firstGroup)[0]
+}
+
+// sliceMetrics returns a copy of the data points [from, to), dropping the
+// metrics, resources and scopes left without data points.
+func sliceMetrics(md pmetric.Metrics, from, to int) pmetric.Metrics {
+	return routeMetrics(md, from, to, 1, firstGroup)[0]
+}
+
+// firstGroup routes every record to the first batch.
+func firstGroup(int) int {
+	return 0
+}
+
+// unsetIndexes returns n indexes set to -1.
+func unsetIndexes(n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = -1
+	}
+	return indexes
+}
+
+// routeLogs copies the log records [from, to) into groups batches, group
+// returning the batch of the i-th record of ld, or -1 to drop it. Only the
+// records in range are copied, along with their resource and scope the first
+// time a batch gets a record of them, and scopes before from are skipped as a
+// whole.
+func routeLogs(ld plog.Logs, from, to, groups int, group func(i int) int) []plog.Logs {
+	outs := make([]plog.Logs, groups)
+	for g := range outs {
+		outs[g] = plog.NewLogs()
+	}
+	rlOuts, slOuts := make([]plog.ResourceLogs, groups), make([]plog.ScopeLogs, groups)
+	lastResource, lastScope := unsetIndexes(groups), unsetIndexes(groups)
+
+	i, scope := 0, -1
+	rls := ld.ResourceLogs()
+	for r := 0; r < rls.Len() && i < to; r++ {
+		rl := rls.At(r)
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len() && i < to; j++ {
+			scope++
+			sl := sls.At(j)
+			lrs := sl.LogRecords()
+			if i+lrs.Len() <= from {
+				i += lrs.Len()
+				continue
+			}
+			for k := 0; k < lrs.Len() && i < to; k, i = k+1, i+1 {
+				if i < from {
+					continue
+				}
+				g := group(i)
+				if g < 0 {
+					continue
+				}
+				if lastResource[g] != r {
+					lastResource[g] = r
+					rlOuts[g] = outs[g].ResourceLogs().AppendEmpty()
+					rl.Resource().CopyTo(rlOuts[g].Resource())
+					rlOuts[g].SetSchemaUrl(rl.SchemaUrl())
+				}
+				if lastScope[g] != scope {
+					lastScope[g] = scope
+					slOuts[g] = rlOuts[g].ScopeLogs().AppendEmpty()
+					sl.Scope().CopyTo(slOuts[g].Scope())
+					slOuts[g].SetSchemaUrl(sl.SchemaUrl())
+				}
+				lrs.At(k).CopyTo(slOuts[g].LogRecords().AppendEmpty())
+			}
+		}
+	}
+	return outs
+}
+
+// routeTraces copies the spans [from, to) into groups batches, see
+// routeLogs.
+func routeTraces(td ptrace.Traces, from, to, groups int, group func(i int) int) []ptrace.Traces {
+	outs := make([]ptrace.Traces, groups)
+	for g := range outs {
+		outs[g] = ptrace.NewTraces()
+	}
+	rsOuts, ssOuts := make([]ptrace.ResourceSpans, groups), make([]ptrace.ScopeSpans, groups)
+	lastResource, lastScope := unsetIndexes(groups), unsetIndexes(groups)
+
+	i, scope := 0, -1
+	rss := td.ResourceSpans()
+	for r := 0; r < rss.Len() && i < to; r++ {
+		rs := rss.At(r)
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len() && i < to; j++ {
+			scope++
+			ss := sss.At(j)
+			spans := ss.Spans()
+			if i+spans.Len() <= from {
+				i += spans.Len()
+				continue
+			}
+			for k := 0; k < spans.Len() && i < to; k, i = k+1, i+1 {
+				if i < from {
+					continue
+				}
+				g := group(i)
+				if g < 0 {
+					continue
+				}
+				if lastResource[g] != r {
+					lastResource[g] = r
+					rsOuts[g] = outs[g].ResourceSpans().AppendEmpty()
+					rs.Resource().CopyTo(rsOuts[g].Resource())
+					rsOuts[g].SetSchemaUrl(rs.SchemaUrl())
+				}
+				if lastScope[g] != scope {
+					lastScope[g] = scope
+					ssOuts[g] = rsOuts[g].ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(ssOuts[g].Scope())
+					ssOuts[g].SetSchemaUrl(ss.SchemaUrl())
+				}
+				spans.At(k).CopyTo(ssOuts[g].Spans().AppendEmpty())
+			}
+		}
+	}
+	return outs
+}
+
+// routeMetrics copies the data points [from, to) into groups batches, see
+// routeLogs. A metric is copied without its data points the first time a
+// batch gets one of them, and a metric without data points is a record of
+// its own, copied as is.
+func routeMetrics(md pmetric.Metrics, from, to, groups int, group func(i int) int) []pmetric.Metrics {
+	outs := make([]pmetric.Metrics, groups)
+	for g := range outs {
+		outs[g] = pmetric.NewMetrics()
+	}
+	rmOuts, smOuts, mOuts := make([]pmetric.ResourceMetrics, groups), make([]pmetric.ScopeMetrics, groups), make([]pmetric.Metric, groups)
+	lastResource, lastScope, lastMetric := unsetIndexes(groups), unsetIndexes(groups), unsetIndexes(groups)
+
+	i, scope, metric := 0, -1, -1
+	rms := md.ResourceMetrics()
+	for r := 0; r < rms.Len() && i < to; r++ {
+		rm := rms.At(r)
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len() && i < to; j++ {
+			scope++
+			sm := sms.At(j)
+			// scopeMetrics returns the scope of the batch of group g, copying
+			// the resource and scope with the first record of the batch.
+			scopeMetrics := func(g int) pmetric.ScopeMetrics {
+				if lastResource[g] != r {
+					lastResource[g] = r
+					rmOuts[g] = outs[g].ResourceMetrics().AppendEmpty()
+					rm.Resource().CopyTo(rmOuts[g].Resource())
+					rmOuts[g].SetSchemaUrl(rm.SchemaUrl())
+				}
+				if lastScope[g] != scope {
+					lastScope[g] = scope
+					smOuts[g] = rmOuts[g].ScopeMetrics().AppendEmpty()
+					sm.Scope().CopyTo(smOuts[g].Scope())
+					smOuts[g].SetSchemaUrl(sm.SchemaUrl())
+				}
+				return smOuts[g]
+			}
+
+			metrics := sm.Metrics()
+			for k := 0; k < metrics.Len() && i < to; k++ {
+				metric++
+				m := metrics.At(k)
+				n := metricDataPointCount(m)
+				if i+max(n, 1) <= from {
+					i += max(n, 1)
+					continue
+				}
+				if n == 0 {
+					if g := group(i); g >= 0 {
+						m.CopyTo(scopeMetrics(g).Metrics().AppendEmpty())
+					}
+					i++
+					continue
+				}
+				for d := 0; d < n && i < to; d, i = d+1, i+1 {
+					if i < from {
+						continue
+					}
+					g := group(i)
+					if g < 0 {
+						continue
+					}
+					if lastMetric[g] != metric {
+						lastMetric[g] = metric
+						mOuts[g] = scopeMetrics(g).Metrics().AppendEmpty()
+						copyMetricWithoutDataPoints(m, mOuts[g])
+					}
+					copyDataPoint(m, d, mOuts[g])
+				}
+			}
+		}
+	}
+	return outs
+}
+
+// copyMetricWithoutDataPoints copies the description and type of a metric
+// into dest, leaving its data points empty.
+func copyMetricWithoutDataPoints(m, dest pmetric.Metric) {
+	dest.SetName(m.Name())
+	dest.SetDescription(m.Description())
+	dest.SetUnit(m.Unit())
+	m.Metadata().CopyTo(dest.Metadata())
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dest.SetEmptyGauge()
+	case pmetric.MetricTypeSum:
+		sum := dest.SetEmptySum()
+		sum.SetAggregationTemporality(m.Sum().AggregationTemporality())
+		sum.SetIsMonotonic(m.Sum().IsMonotonic())
+	case pmetric.MetricTypeHistogram:
+		dest.SetEmptyHistogram().SetAggregationTemporality(m.Histogram().AggregationTemporality())
+	case pmetric.MetricTypeExponentialHistogram:
+		dest.SetEmptyExponentialHistogram().SetAggregationTemporality(m.ExponentialHistogram().AggregationTemporality())
+	case pmetric.MetricTypeSummary:
+		dest.SetEmptySummary()
+	}
+}
+
+// copyDataPoint appends the i-th data point of a metric to the data points
+// of dest, a metric of the same type.
+func copyDataPoint(m pmetric.Metric, i int, dest pmetric.Metric) {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		m.Gauge().DataPoints().At(i).CopyTo(dest.Gauge().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeSum:
+		m.Sum().DataPoints().At(i).CopyTo(dest.Sum().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeHistogram:
+		m.Histogram().DataPoints().At(i).CopyTo(dest.Histogram().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeExponentialHistogram:
+		m.ExponentialHistogram().DataPoints().At(i).CopyTo(dest.ExponentialHistogram().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeSummary:
+		m.Summary().DataPoints().At(i).CopyTo(dest.Summary().DataPoints().AppendEmpty())
+	}
+}
+
+// metricRecordCount returns the number of records of a batch, one per data
+// point and one per metric without data points.
+func metricRecordCount(md pmetric.Metrics) int {
+	n := 0
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			metrics := sms.At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				n += max(metricDataPointCount(metrics.At(k)), 1)
+			}
+		}
+	}
+	return n
+}
+
+// metricDataPointCount returns the number of data points of a metric.
+func metricDataPointCount(m pmetric.Metric) int {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		return m.Gauge().DataPoints().Len()
+	case pmetric.MetricTypeSum:
+		return m.Sum().DataPoints().Len()
+	case pmetric.MetricTypeHistogram:
+		return m.Histogram().DataPoints().Len()
+	case pmetric.MetricTypeExponentialHistogram:
+		return m.ExponentialHistogram().DataPoints().Len()
+	case pmetric.MetricTypeSummary:
+		return m.Summary().DataPoints().Len()
+	}
+	return 0
+}
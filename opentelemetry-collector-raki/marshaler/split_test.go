@@ -0,0 +1,141 @@
+package marshaler_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestMarshalLogsChunks(t *testing.T) {
+	m := marshaler.NewOtlpCsvLogs()
+	u := marshaler.NewOtlpCsvLogsUnmarshaler()
+	whole, err := m.Marshal(testLogs())
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+
+	chunks, err := marshaler.MarshalLogsChunks(m, testLogs(), marshaler.SplitConfig{})
+	if err != nil {
+		t.Fatalf("MarshalLogsChunks() failed: %v", err)
+	}
+	if len(chunks) != 1 || !bytes.Equal(chunks[0], whole) {
+		t.Fatalf("Expected the batch to be marshaled as is without limits, got %d chunks", len(chunks))
+	}
+
+	header, _, _ := bytes.Cut(whole, []byte("\n"))
+	for _, limits := range []marshaler.SplitConfig{
+		{MaxRecords: 1},
+		{MaxRecords: 2},
+		{MaxBytes: len(whole) - 1},
+		{MaxBytes: len(whole) - 1, MaxRecords: 2},
+	} {
+		chunks, err := marshaler.MarshalLogsChunks(m, testLogs(), limits)
+		if err != nil {
+			t.Fatalf("MarshalLogsChunks(%+v) failed: %v", limits, err)
+		}
+		if len(chunks) < 2 {
+			t.Errorf("Expected %+v to split the batch, got %d chunks", limits, len(chunks))
+		}
+		records := 0
+		for _, chunk := range chunks {
+			if limits.MaxBytes > 0 && len(chunk) > limits.MaxBytes {
+				t.Errorf("Expected chunks under %d bytes, got %d", limits.MaxBytes, len(chunk))
+			}
+			if !bytes.HasPrefix(chunk, header) {
+				t.Errorf("Expected every chunk to start with the CSV header, got %q", chunk)
+			}
+			ld, err := u.Unmarshal(chunk)
+			if err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			if limits.MaxRecords > 0 && ld.LogRecordCount() > limits.MaxRecords {
+				t.Errorf("Expected at most %d records, got %d", limits.MaxRecords, ld.LogRecordCount())
+			}
+			records += ld.LogRecordCount()
+		}
+		if records != testLogs().LogRecordCount() {
+			t.Errorf("Expected %d records across chunks, got %d", testLogs().LogRecordCount(), records)
+		}
+	}
+
+	chunks, err = marshaler.MarshalLogsChunks(m, plog.NewLogs(), marshaler.SplitConfig{MaxRecords: 1})
+	if err != nil || len(chunks) != 1 {
+		t.Fatalf("Expected an empty batch to be a single chunk, got %d chunks and %v", len(chunks), err)
+	}
+
+	_, err = marshaler.MarshalLogsChunks(m, testLogs(), marshaler.SplitConfig{MaxBytes: len(header)})
+	if !errors.Is(err, marshaler.ErrRecordTooLarge) {
+		t.Fatalf("Expected ErrRecordTooLarge, got %v", err)
+	}
+}
+
+func TestMarshalMetricsAndTracesChunks(t *testing.T) {
+	md := testMetrics()
+	chunks, err := marshaler.MarshalMetricsChunks(marshaler.NewOtlpProtobufMetrics(), md, marshaler.SplitConfig{MaxRecords: 1})
+	if err != nil {
+		t.Fatalf("MarshalMetricsChunks() failed: %v", err)
+	}
+	points := 0
+	for _, chunk := range chunks {
+		got, err := marshaler.NewOtlpProtobufMetricsUnmarshaler().Unmarshal(chunk)
+		if err != nil {
+			t.Fatalf("Unmarshal() failed: %v", err)
+		}
+		if got.DataPointCount() > 1 {
+			t.Errorf("Expected at most one data point per chunk, got %d", got.DataPointCount())
+		}
+		points += got.DataPointCount()
+	}
+	if points != md.DataPointCount() {
+		t.Errorf("Expected %d data points across chunks, got %d", md.DataPointCount(), points)
+	}
+
+	td := testTraces()
+	chunks, err = marshaler.MarshalTracesChunks(marshaler.NewOtlpJSONTraces(), td, marshaler.SplitConfig{MaxRecords: 1})
+	if err != nil {
+		t.Fatalf("MarshalTracesChunks() failed: %v", err)
+	}
+	if len(chunks) != td.SpanCount() {
+		t.Errorf("Expected one chunk per span, got %d", len(chunks))
+	}
+}
+
+func TestMarshalChunksKeepRecords(t *testing.T) {
+	config := marshaler.CSVConfig{SkipHeader: true}
+	md := testMetrics()
+	md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().AppendEmpty().SetName("no_data_points")
+
+	for _, limits := range []marshaler.SplitConfig{{MaxRecords: 1}, {MaxRecords: 2}, {MaxRecords: 3}} {
+		for name, marshal := range map[string]func() ([]byte, [][]byte, error){
+			"logs": func() ([]byte, [][]byte, error) {
+				m := marshaler.NewOtlpCsvLogsWithConfig(config)
+				whole, _ := m.Marshal(testLogs())
+				chunks, err := marshaler.MarshalLogsChunks(m, testLogs(), limits)
+				return whole, chunks, err
+			},
+			"metrics": func() ([]byte, [][]byte, error) {
+				m := marshaler.NewOtlpCsvMetricsWithConfig(config)
+				whole, _ := m.Marshal(md)
+				chunks, err := marshaler.MarshalMetricsChunks(m, md, limits)
+				return whole, chunks, err
+			},
+			"traces": func() ([]byte, [][]byte, error) {
+				m := marshaler.NewOtlpCsvTracesWithConfig(config)
+				whole, _ := m.Marshal(testTraces())
+				chunks, err := marshaler.MarshalTracesChunks(m, testTraces(), limits)
+				return whole, chunks, err
+			},
+		} {
+			whole, chunks, err := marshal()
+			if err != nil {
+				t.Fatalf("Marshaling %s chunks of %+v failed: %v", name, limits, err)
+			}
+			if got := bytes.Join(chunks, nil); !bytes.Equal(got, whole) {
+				t.Errorf("Expected the %s chunks of %+v to hold the rows of the batch:\n%s\ngot:\n%s", name, limits, whole, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package marshaler_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+)
+
+func TestMarshalTo(t *testing.T) {
+	marshalers := marshaler.BaseMarshalers()
+	for _, encoding := range []string{"otlp_csv", "otlp_csv+gzip"} {
+		t.Run(encoding, func(t *testing.T) {
+			logs := marshalers.Logs[encoding]
+			if _, ok := logs.(marshaler.StreamingLogs); !ok {
+				t.Fatalf("Expected %s to stream logs", encoding)
+			}
+			want, err := logs.Marshal(testLogs())
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			var got bytes.Buffer
+			if err := marshaler.MarshalLogsTo(&got, logs, testLogs()); err != nil {
+				t.Fatalf("MarshalLogsTo() failed: %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Fatal("Expected streamed logs to match the marshaled bytes")
+			}
+
+			metrics := marshalers.Metrics[encoding]
+			want, err = metrics.Marshal(testMetrics())
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			got.Reset()
+			if err := marshaler.MarshalMetricsTo(&got, metrics, testMetrics()); err != nil {
+				t.Fatalf("MarshalMetricsTo() failed: %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Fatal("Expected streamed metrics to match the marshaled bytes")
+			}
+
+			traces := marshalers.Traces[encoding]
+			want, err = traces.Marshal(testTraces())
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			got.Reset()
+			if err := marshaler.MarshalTracesTo(&got, traces, testTraces()); err != nil {
+				t.Fatalf("MarshalTracesTo() failed: %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Fatal("Expected streamed traces to match the marshaled bytes")
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package marshaler
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// syslogFacilities maps the facility names to their codes, see RFC 5424
+// section 6.2.1.
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"ntp":      12,
+	"security": 13,
+	"console":  14,
+	"solaris":  15,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// defaultEnterpriseNumber is the private enterprise number reserved for
+// documentation by RFC 5612.
+const defaultEnterpriseNumber = 32473
+
+// SyslogConfig defines the options of the syslog marshaler.
+type SyslogConfig struct {
+	// Facility is the facility name of every message, e.g. local0. Defaults
+	// to user.
+	Facility string `mapstructure:"facility"`
+
+	// EnterpriseNumber is the private enterprise number of the structured
+	// data IDs, e.g. attributes@32473. Defaults to 32473, the number
+	// reserved for documentation.
+	EnterpriseNumber int `mapstructure:"enterprise_number"`
+
+	// OctetCounting prefixes every message with its length as described by
+	// RFC 6587, instead of ending it with a newline.
+	OctetCounting bool `mapstructure:"octet_counting"`
+}
+
+// Validate checks that the syslog options are supported.
+func (c SyslogConfig) Validate() error {
+	if _, ok := syslogFacilities[c.Facility]; c.Facility != "" && !ok {
+		return fmt.Errorf("unknown facility: %s", c.Facility)
+	}
+	if c.EnterpriseNumber < 0 {
+		return fmt.Errorf("invalid enterprise number: %d", c.EnterpriseNumber)
+	}
+	return nil
+}
+
+// SyslogMarshaler writes logs as RFC 5424 syslog messages, one per log
+// record:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+//
+// The priority combines the configured facility with the syslog severity of
+// the severity number, see syslogSeverity. The hostname, app name and process
+// ID come from the host.name, service.name and process.pid resource
+// attributes. Resource attributes, log record attributes and the trace
+// context are written as the resource, attributes and trace structured data
+// elements, and the body as the message.
+type SyslogMarshaler struct {
+	config SyslogConfig
+}
+
+func NewSyslogMarshaler() SyslogMarshaler {
+	return SyslogMarshaler{}
+}
+
+// NewSyslogMarshalerWithConfig creates a syslog marshaler with the given options.
+func NewSyslogMarshalerWithConfig(config SyslogConfig) SyslogMarshaler {
+	return SyslogMarshaler{config: config}
+}
+
+// MarshalLogs converts OpenTelemetry logs into syslog messages.
+func (m SyslogMarshaler) MarshalLogs(ld plog.Logs) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := m.WriteLogs(&buf, ld); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteLogs writes OpenTelemetry logs into w as syslog messages, one at a time.
+func (m SyslogMarshaler) WriteLogs(w io.Writer, ld plog.Logs) error {
+	bw := bufio.NewWriter(w)
+	msg := bytes.Buffer{}
+	facility := m.facility()
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		resource := rl.Resource().Attributes()
+		header := strings.Join([]string{
+			syslogHeaderField(resource, "host.name", 255),
+			syslogHeaderField(resource, serviceNameKey, 48),
+			syslogHeaderField(resource, "process.pid", 128),
+			"-",
+		}, " ")
+		resourceData := m.structuredDataElement("resource", resource)
+		ills := rl.ScopeLogs()
+		for j := 0; j < ills.Len(); j++ {
+			logs := ills.At(j).LogRecords()
+			for k := 0; k < logs.Len(); k++ {
+				lr := logs.At(k)
+				msg.Reset()
+				fmt.Fprintf(&msg, "<%d>1 ", facility*8+syslogSeverity(lr.SeverityNumber()))
+				if ts := logTimestamp(lr); ts != 0 {
+					msg.WriteString(ts.AsTime().UTC().Format("2006-01-02T15:04:05.999999Z07:00"))
+				} else {
+					msg.WriteByte('-')
+				}
+				msg.WriteByte(' ')
+				msg.WriteString(header)
+				msg.WriteByte(' ')
+				structuredData := resourceData + m.structuredDataElement("attributes", lr.Attributes()) + m.traceDataElement(lr)
+				if structuredData == "" {
+					structuredData = "-"
+				}
+				msg.WriteString(structuredData)
+				if lr.Body().Type() != pcommon.ValueTypeEmpty {
+					msg.WriteByte(' ')
+					body := lr.Body().AsString()
+					if !m.config.OctetCounting {
+						body = strings.ReplaceAll(body, "\n", `\n`)
+					}
+					msg.WriteString(body)
+				}
+
+				if m.config.OctetCounting {
+					bw.WriteString(strconv.Itoa(msg.Len()))
+					bw.WriteByte(' ')
+					bw.Write(msg.Bytes())
+				} else {
+					bw.Write(msg.Bytes())
+					bw.WriteByte('\n')
+				}
+			}
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write syslog message: %w", err)
+	}
+	return nil
+}
+
+// syslogSeverity maps a severity number to a syslog severity, from 0
+// (emergency) to 7 (debug). FATAL and FATAL2 are critical, FATAL3 alert and
+// FATAL4 emergency. Unspecified severities are informational.
+func syslogSeverity(severity plog.SeverityNumber) int {
+	switch {
+	case severity == plog.SeverityNumberUnspecified:
+		return 6
+	case severity <= plog.SeverityNumberDebug4:
+		return 7
+	case severity == plog.SeverityNumberInfo:
+		return 6
+	case severity <= plog.SeverityNumberInfo4:
+		return 5
+	case severity <= plog.SeverityNumberWarn4:
+		return 4
+	case severity <= plog.SeverityNumberError4:
+		return 3
+	case severity <= plog.SeverityNumberFatal2:
+		return 2
+	case severity == plog.SeverityNumberFatal3:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// syslogHeaderField returns a resource attribute as a header field, limited
+// to printable ASCII and to its maximum length, or the nil value "-".
+func syslogHeaderField(resource pcommon.Map, key string, maxLen int) string {
+	v, ok := resource.Get(key)
+	if !ok {
+		return "-"
+	}
+	field := syslogName(v.AsString(), maxLen)
+	if field == "" {
+		return "-"
+	}
+	return field
+}
+
+// syslogName replaces the characters that are not printable ASCII by '_' and
+// truncates the result to maxLen. Header fields and parameter names also
+// exclude the characters listed in except.
+func syslogName(s string, maxLen int, except ...rune) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if sb.Len() == maxLen {
+			break
+		}
+		if r < 33 || r > 126 || strings.ContainsRune(string(except), r) {
+			r = '_'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// structuredDataElement writes attributes as a structured data element,
+// empty when there are no attributes.
+func (m SyslogMarshaler) structuredDataElement(name string, attrs pcommon.Map) string {
+	if attrs.Len() == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('[')
+	sb.WriteString(m.sdID(name))
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		writeSDParam(&sb, k, v.AsString())
+		return true
+	})
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// traceDataElement writes the trace context of a log record as a structured
+// data element, empty when the record is not correlated to a span.
+func (m SyslogMarshaler) traceDataElement(lr plog.LogRecord) string {
+	if lr.TraceID().IsEmpty() && lr.SpanID().IsEmpty() {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('[')
+	sb.WriteString(m.sdID("trace"))
+	if !lr.TraceID().IsEmpty() {
+		writeSDParam(&sb, "trace_id", lr.TraceID().String())
+	}
+	if !lr.SpanID().IsEmpty() {
+		writeSDParam(&sb, "span_id", lr.SpanID().String())
+	}
+	writeSDParam(&sb, "trace_flags", strconv.FormatUint(uint64(lr.Flags()), 10))
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// facility returns the code of the configured facility, user by default.
+func (m SyslogMarshaler) facility() int {
+	if m.config.Facility == "" {
+		return syslogFacilities["user"]
+	}
+	return syslogFacilities[m.config.Facility]
+}
+
+// sdID returns the structured data ID of an element, qualified with the
+// enterprise number.
+func (m SyslogMarshaler) sdID(name string) string {
+	number := m.config.EnterpriseNumber
+	if number == 0 {
+		number = defaultEnterpriseNumber
+	}
+	return name + "@" + strconv.Itoa(number)
+}
+
+var sdParamValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// writeSDParam writes a structured data parameter. Names are limited to 32
+// printable ASCII characters other than '=', ' ', ']' and '"'.
+func writeSDParam(sb *strings.Builder, name, value string) {
+	sb.WriteByte(' ')
+	sb.WriteString(syslogName(name, 32, '=', ']', '"'))
+	sb.WriteString(`="`)
+	sb.WriteString(sdParamValueEscaper.Replace(value))
+	sb.WriteByte('"')
+}
@@ -0,0 +1,78 @@
+package marshaler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler/marshalertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestSyslog(t *testing.T) {
+	m := marshaler.NewSyslogLogs()
+	if _, ok := marshaler.BaseLogsMarshalers()[m.Encoding()]; !ok {
+		t.Errorf("Expected %s to be registered", m.Encoding())
+	}
+	ld := testLogs()
+	ld.ResourceLogs().At(1).Resource().Attributes().PutStr("host.name", "atm host")
+	ld.ResourceLogs().At(1).Resource().Attributes().PutInt("process.pid", 42)
+	lr := ld.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0)
+	lr.SetSeverityNumber(plog.SeverityNumberError)
+	lr.Attributes().PutStr("path", `C:\atm]"`)
+
+	buf, err := m.Marshal(ld)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	want := strings.Join([]string{
+		`<14>1 2025-01-02T03:04:05Z - default/atm-111/app/0 - - [resource@32473 service.name="default/atm-111/app/0"][attributes@32473 service="atm" attempt="3"][trace@32473 trace_id="0102030405060708090a0b0c0d0e0f10" span_id="0102030405060708" trace_flags="1"] hello, "world"`,
+		`<14>1 - - default/atm-111/app/0 - - [resource@32473 service.name="default/atm-111/app/0"] multi\nline`,
+		`<11>1 - atm_host default/atm-222/app/0 42 - [resource@32473 service.name="default/atm-222/app/0" host.name="atm host" process.pid="42"][attributes@32473 path="C:\\atm\]\""] second resource`,
+	}, "\n") + "\n"
+	if string(buf) != want {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", want, buf)
+	}
+
+	octets := marshaler.NewSyslogLogsWithConfig(marshaler.SyslogConfig{Facility: "local0", EnterpriseNumber: 1, OctetCounting: true})
+	buf, err = octets.Marshal(ld)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	if !strings.HasPrefix(string(buf), "256 <134>1 2025-01-02T03:04:05Z") || !strings.Contains(string(buf), "[resource@1 ") || !strings.Contains(string(buf), "multi\nline") {
+		t.Fatalf("Expected octet counted local0 messages, got:\n%s", buf)
+	}
+
+	for _, tt := range []struct {
+		severity plog.SeverityNumber
+		want     string
+	}{
+		{plog.SeverityNumberDebug3, "<15>"},
+		{plog.SeverityNumberInfo, "<14>"},
+		{plog.SeverityNumberInfo2, "<13>"},
+		{plog.SeverityNumberWarn, "<12>"},
+		{plog.SeverityNumberError4, "<11>"},
+		{plog.SeverityNumberFatal, "<10>"},
+		{plog.SeverityNumberFatal2, "<10>"},
+		{plog.SeverityNumberFatal3, "<9>"},
+		{plog.SeverityNumberFatal4, "<8>"},
+	} {
+		ld := plog.NewLogs()
+		ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().SetSeverityNumber(tt.severity)
+		buf, err := m.Marshal(ld)
+		if err != nil {
+			t.Fatalf("Marshal() failed: %v", err)
+		}
+		if !strings.HasPrefix(string(buf), tt.want) {
+			t.Errorf("Expected %s to have priority %s, got %s", tt.severity, tt.want, buf)
+		}
+	}
+
+	if err := (marshaler.SyslogConfig{Facility: "local8"}).Validate(); err == nil {
+		t.Error("Expected an unknown facility to be rejected")
+	}
+}
+
+func TestSyslogConformance(t *testing.T) {
+	marshalertest.TestLogs(t, marshaler.NewSyslogLogs(), nil)
+}
@@ -0,0 +1,107 @@
+package marshaler
+
+import "maps"
+
+// Unmarshalers is a collection of unmarshalers for logs, metrics, and traces,
+// keyed by the same encodings as Marshalers. Prometheus text, logfmt, syslog,
+// Chrome trace and folded stacks are write-only and have no unmarshaler.
+type Unmarshalers struct {
+	Logs    map[string]LogsUnmarshaler
+	Metrics map[string]MetricsUnmarshaler
+	Traces  map[string]TracesUnmarshaler
+}
+
+// BaseUnmarshalers returns the set of supported unmarshalers. The CSV
+// unmarshalers read the CSV written with config, the Parquet and Arrow IPC
+// unmarshalers read the batches written with any of their options.
+func BaseUnmarshalers(config CSVConfig) *Unmarshalers {
+	return &Unmarshalers{
+		Logs:    BaseLogsUnmarshalers(config),
+		Metrics: BaseMetricsUnmarshalers(config),
+		Traces:  BaseTracesUnmarshalers(config),
+	}
+}
+
+// BaseLogsUnmarshalers returns the set of supported logs unmarshalers
+func BaseLogsUnmarshalers(config CSVConfig) map[string]LogsUnmarshaler {
+	otlpCsv := NewOtlpCsvLogsUnmarshalerWithConfig(config)
+	otlpParquet := NewOtlpParquetLogsUnmarshaler()
+	otlpArrowIPC := NewOtlpArrowIPCLogsUnmarshaler()
+	otlpProtobuf := NewOtlpProtobufLogsUnmarshaler()
+	otlpJSON := NewOtlpJSONLogsUnmarshaler()
+	return withCompressedLogsUnmarshalers(map[string]LogsUnmarshaler{
+		otlpCsv.Encoding():      otlpCsv,
+		otlpParquet.Encoding():  otlpParquet,
+		otlpArrowIPC.Encoding(): otlpArrowIPC,
+		otlpProtobuf.Encoding(): otlpProtobuf,
+		otlpJSON.Encoding():     otlpJSON,
+	})
+}
+
+// BaseMetricsUnmarshalers returns the set of supported metrics unmarshalers
+func BaseMetricsUnmarshalers(config CSVConfig) map[string]MetricsUnmarshaler {
+	otlpCsv := NewOtlpCsvMetricsUnmarshalerWithConfig(config)
+	otlpParquet := NewOtlpParquetMetricsUnmarshaler()
+	otlpArrowIPC := NewOtlpArrowIPCMetricsUnmarshaler()
+	otlpProtobuf := NewOtlpProtobufMetricsUnmarshaler()
+	otlpJSON := NewOtlpJSONMetricsUnmarshaler()
+	return withCompressedMetricsUnmarshalers(map[string]MetricsUnmarshaler{
+		otlpCsv.Encoding():      otlpCsv,
+		otlpParquet.Encoding():  otlpParquet,
+		otlpArrowIPC.Encoding(): otlpArrowIPC,
+		otlpProtobuf.Encoding(): otlpProtobuf,
+		otlpJSON.Encoding():     otlpJSON,
+	})
+}
+
+// BaseTracesUnmarshalers returns the set of supported traces unmarshalers
+func BaseTracesUnmarshalers(config CSVConfig) map[string]TracesUnmarshaler {
+	otlpCsv := NewOtlpCsvTracesUnmarshalerWithConfig(config)
+	otlpParquet := NewOtlpParquetTracesUnmarshaler()
+	otlpArrowIPC := NewOtlpArrowIPCTracesUnmarshaler()
+	otlpProtobuf := NewOtlpProtobufTracesUnmarshaler()
+	otlpJSON := NewOtlpJSONTracesUnmarshaler()
+	return withCompressedTracesUnmarshalers(map[string]TracesUnmarshaler{
+		otlpCsv.Encoding():      otlpCsv,
+		otlpParquet.Encoding():  otlpParquet,
+		otlpArrowIPC.Encoding(): otlpArrowIPC,
+		otlpProtobuf.Encoding(): otlpProtobuf,
+		otlpJSON.Encoding():     otlpJSON,
+	})
+}
+
+// withCompressedLogsUnmarshalers registers every compression of the given
+// logs unmarshalers under their combined encoding, e.g. otlp_csv+zstd.
+func withCompressedLogsUnmarshalers(unmarshalers map[string]LogsUnmarshaler) map[string]LogsUnmarshaler {
+	for _, u := range maps.Clone(unmarshalers) {
+		for _, compression := range Compressions() {
+			compressed, _ := NewCompressedLogsUnmarshaler(u, compression)
+			unmarshalers[compressed.Encoding()] = compressed
+		}
+	}
+	return unmarshalers
+}
+
+// withCompressedMetricsUnmarshalers registers every compression of the given
+// metrics unmarshalers under their combined encoding, e.g. otlp_csv+zstd.
+func withCompressedMetricsUnmarshalers(unmarshalers map[string]MetricsUnmarshaler) map[string]MetricsUnmarshaler {
+	for _, u := range maps.Clone(unmarshalers) {
+		for _, compression := range Compressions() {
+			compressed, _ := NewCompressedMetricsUnmarshaler(u, compression)
+			unmarshalers[compressed.Encoding()] = compressed
+		}
+	}
+	return unmarshalers
+}
+
+// withCompressedTracesUnmarshalers registers every compression of the given
+// traces unmarshalers under their combined encoding, e.g. otlp_csv+zstd.
+func withCompressedTracesUnmarshalers(unmarshalers map[string]TracesUnmarshaler) map[string]TracesUnmarshaler {
+	for _, u := range maps.Clone(unmarshalers) {
+		for _, compression := range Compressions() {
+			compressed, _ := NewCompressedTracesUnmarshaler(u, compression)
+			unmarshalers[compressed.Encoding()] = compressed
+		}
+	}
+	return unmarshalers
+}
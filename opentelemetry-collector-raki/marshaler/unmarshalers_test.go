@@ -0,0 +1,107 @@
+package marshaler_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-tutorials/marshaler"
+)
+
+func TestUnmarshalersRoundTrip(t *testing.T) {
+	marshalers := marshaler.BaseMarshalers()
+	unmarshalers := marshaler.BaseUnmarshalers(marshaler.CSVConfig{})
+
+	for encoding, u := range unmarshalers.Logs {
+		t.Run("logs/"+encoding, func(t *testing.T) {
+			m, ok := marshalers.Logs[encoding]
+			if !ok {
+				t.Fatalf("Expected a logs marshaler for %s", encoding)
+			}
+			first, err := m.Marshal(testLogs())
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			ld, err := u.Unmarshal(first)
+			if err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			second, err := m.Marshal(ld)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			if !bytes.Equal(first, second) {
+				t.Fatal("Expected round-trip to be stable")
+			}
+		})
+	}
+
+	for encoding, u := range unmarshalers.Metrics {
+		t.Run("metrics/"+encoding, func(t *testing.T) {
+			m, ok := marshalers.Metrics[encoding]
+			if !ok {
+				t.Fatalf("Expected a metrics marshaler for %s", encoding)
+			}
+			first, err := m.Marshal(testMetrics())
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			md, err := u.Unmarshal(first)
+			if err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			second, err := m.Marshal(md)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			if !bytes.Equal(first, second) {
+				t.Fatal("Expected round-trip to be stable")
+			}
+		})
+	}
+
+	for encoding, u := range unmarshalers.Traces {
+		t.Run("traces/"+encoding, func(t *testing.T) {
+			m, ok := marshalers.Traces[encoding]
+			if !ok {
+				t.Fatalf("Expected a traces marshaler for %s", encoding)
+			}
+			first, err := m.Marshal(testTraces())
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			td, err := u.Unmarshal(first)
+			if err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			second, err := m.Marshal(td)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			if !bytes.Equal(first, second) {
+				t.Fatal("Expected round-trip to be stable")
+			}
+		})
+	}
+}
+
+func TestBaseUnmarshalersConfig(t *testing.T) {
+	config := marshaler.CSVConfig{
+		Delimiter:       ";",
+		TimestampFormat: marshaler.TimestampFormatUnixNano,
+	}
+	m := marshaler.NewOtlpCsvLogsWithConfig(config)
+	b, err := m.Marshal(testLogs())
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	ld, err := marshaler.BaseUnmarshalers(config).Logs["otlp_csv"].Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	if ld.LogRecordCount() != testLogs().LogRecordCount() {
+		t.Fatalf("Expected %d log records, got %d", testLogs().LogRecordCount(), ld.LogRecordCount())
+	}
+	if _, err := marshaler.BaseUnmarshalers(marshaler.CSVConfig{}).Logs["otlp_csv"].Unmarshal(b); err == nil {
+		t.Fatal("Expected the default CSV unmarshaler to fail on the configured dialect")
+	}
+}